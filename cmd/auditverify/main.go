@@ -0,0 +1,40 @@
+// Command auditverify checks the hash chain of an audit log written by the
+// gateway (AUDIT_LOG) and reports the first record where it breaks.
+//
+//	go run ./cmd/auditverify audit.log
+//
+// It exits with status 1 when the chain is broken and 2 on usage or I/O errors.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: auditverify <audit log>")
+		os.Exit(2)
+	}
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer f.Close()
+
+	head, err := audit.Verify(f)
+	var broken *audit.ChainError
+	if errors.As(err, &broken) {
+		fmt.Fprintf(os.Stderr, "BROKEN: %v (%d records verified before it)\n", broken, head.Seq)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Printf("OK: %d records, last hash %s\n", head.Seq, head.Hash)
+}
@@ -1,18 +1,67 @@
 package config
 
 import (
+	_ "embed" // For the built-in route table, exchange rates, transfer limits and access policy.
 	"log"
 	"os"
 
 	"github.com/joho/godotenv" // For loading .env file.
 )
 
+// Route table shipped with the binary, used when ROUTES_FILE is not set.
+//
+//go:embed routes.yaml
+var DefaultRoutes []byte
+
+// Exchange rates shipped with the binary, used when FX_RATES_FILE is not set.
+//
+//go:embed fx_rates.yaml
+var DefaultFXRates []byte
+
+// Transfer limits shipped with the binary, used when TRANSFER_LIMITS_FILE is not set.
+//
+//go:embed transfer_limits.yaml
+var DefaultTransferLimits []byte
+
+// Access policy shipped with the binary, used when POLICY_FILE is not set.
+//
+//go:embed policy.yaml
+var DefaultPolicy []byte
+
 // Holds the application configuration.
 type Config struct {
 	APIGatewayPort             string
 	UserProductServiceGRPCHost string
 	AuthServiceGRPCHost        string
 	TransactionServiceGRPCHost string
+	RoutesFile                 string
+	SchemaValidation           string
+	CursorSecret               string
+	DefaultCurrency            string
+	FXRatesFile                string
+	FXRefreshInterval          string
+	FXMaxRateAge               string
+	SchedulerDB                string
+	SchedulerInterval          string
+	PaymentRequestsDB          string
+	TransferLimitsFile         string
+	Notifier                   string
+	StepUpSecret               string
+	StepUpTransferThreshold    string
+	TwoFactorDB                string
+	AccountTokensDB            string
+	AccountTokenSecret         string
+	AppURL                     string
+	AdminUserIDs               string
+	AuditLog                   string
+	PolicyFile                 string
+	AccountLocksDB             string
+	SessionsDB                 string
+	DeletionDB                 string
+	DeletionGracePeriod        string
+	DeletionInterval           string
+	ExportsDB                  string
+	ExportsDir                 string
 }
 
 // Gets the .env values or returns a default one.
@@ -35,5 +84,41 @@ func LoadConfig() *Config {
 		UserProductServiceGRPCHost: getEnv("USER_PRODUCT_SERVICE_GRPC_HOST", "localhost:50052"),
 		AuthServiceGRPCHost:        getEnv("AUTH_SERVICE_GRPC_HOST", "localhost:50053"),
 		TransactionServiceGRPCHost: getEnv("TRANSACTION_SERVICE_GRPC_HOST", "localhost:50051"),
+		RoutesFile:                 getEnv("ROUTES_FILE", ""),
+		SchemaValidation:           getEnv("SCHEMA_VALIDATION", defaultSchemaValidation()),
+		CursorSecret:               getEnv("CURSOR_SECRET", ""),
+		DefaultCurrency:            getEnv("DEFAULT_CURRENCY", "COP"),
+		FXRatesFile:                getEnv("FX_RATES_FILE", ""),
+		FXRefreshInterval:          getEnv("FX_REFRESH_INTERVAL", ""),
+		FXMaxRateAge:               getEnv("FX_MAX_RATE_AGE", "24h"),
+		SchedulerDB:                getEnv("SCHEDULER_DB", "scheduler.db"),
+		SchedulerInterval:          getEnv("SCHEDULER_INTERVAL", "30s"),
+		PaymentRequestsDB:          getEnv("PAYMENT_REQUESTS_DB", "payment_requests.db"),
+		TransferLimitsFile:         getEnv("TRANSFER_LIMITS_FILE", ""),
+		Notifier:                   getEnv("NOTIFIER", "log"),
+		StepUpSecret:               getEnv("STEP_UP_SECRET", ""),
+		StepUpTransferThreshold:    getEnv("STEP_UP_TRANSFER_THRESHOLD", "1000000"),
+		TwoFactorDB:                getEnv("TWO_FACTOR_DB", "two_factor.db"),
+		AccountTokensDB:            getEnv("ACCOUNT_TOKENS_DB", "account_tokens.db"),
+		AccountTokenSecret:         getEnv("ACCOUNT_TOKEN_SECRET", ""),
+		AppURL:                     getEnv("APP_URL", "https://nova.dmirandam.com"),
+		AdminUserIDs:               getEnv("ADMIN_USER_IDS", ""),
+		AuditLog:                   getEnv("AUDIT_LOG", "audit.log"),
+		PolicyFile:                 getEnv("POLICY_FILE", ""),
+		AccountLocksDB:             getEnv("ACCOUNT_LOCKS_DB", "account_locks.db"),
+		SessionsDB:                 getEnv("SESSIONS_DB", "sessions.db"),
+		DeletionDB:                 getEnv("DELETION_DB", "account_deletions.db"),
+		DeletionGracePeriod:        getEnv("DELETION_GRACE_PERIOD", "336h"),
+		DeletionInterval:           getEnv("DELETION_INTERVAL", "5m"),
+		ExportsDB:                  getEnv("EXPORTS_DB", "exports.db"),
+		ExportsDir:                 getEnv("EXPORTS_DIR", "exports"),
+	}
+}
+
+// Validates against the OpenAPI document by default in development only.
+func defaultSchemaValidation() string {
+	if os.Getenv("GO_ENV") == "development" {
+		return "dev"
 	}
+	return "off"
 }
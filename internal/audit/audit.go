@@ -0,0 +1,84 @@
+// Package audit records who did what to whom.
+//
+// FileSink keeps events in a SHA-256 hash chain so that a record edited,
+// removed or reordered in place breaks verification. The chain is not keyed:
+// anyone who can rewrite the whole file can recompute every hash and produce
+// a log that verifies, and dropping the last records leaves a valid, shorter
+// chain. Detecting that needs a copy of the head (see Head) or of the log
+// kept where the gateway's host can't write.
+package audit
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Outcomes of an audited action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+)
+
+// Who did what to whom, and how it went.
+type Event struct {
+	Time time.Time `json:"time"`
+	// User id of whoever acted; empty when unknown (e.g. a rejected token).
+	Actor string `json:"actor"`
+	// Dotted name of the action, e.g. verification.update.
+	Action string `json:"action"`
+	// User id the action was about.
+	Target  string            `json:"target"`
+	Outcome string            `json:"outcome"`
+	Details map[string]string `json:"details,omitempty"`
+	Origin
+}
+
+// The request an event came from.
+type Origin struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type originKey struct{}
+
+// Returns a copy of ctx carrying the origin of the request being served.
+func WithOrigin(ctx context.Context, o Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// The origin stored by WithOrigin, if any.
+func OriginFrom(ctx context.Context) Origin {
+	o, _ := ctx.Value(originKey{}).(Origin)
+	return o
+}
+
+// Stores audit events.
+type Sink interface {
+	Record(ctx context.Context, e Event) error
+}
+
+// Reads stored audit events back.
+type Source interface {
+	Events(ctx context.Context, fn func(Event) error) error
+}
+
+// Records e in sink, filling in its time and the origin of the request in ctx
+// when unset. A failure to record is logged, not returned, so it never undoes
+// the action; a nil sink records nothing.
+func Record(ctx context.Context, sink Sink, e Event) {
+	if sink == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Origin == (Origin{}) {
+		e.Origin = OriginFrom(ctx)
+	}
+	if err := sink.Record(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("audit: recording %s on %s by %s: %v", e.Action, e.Target, e.Actor, err)
+	}
+}
@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Hash the first record of a log chains to.
+var genesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// A line of a FileSink log. Hash covers Seq, Prev and the exact bytes of
+// Event, so removing, reordering or editing a record breaks the chain from
+// there on.
+type record struct {
+	Seq   uint64          `json:"seq"`
+	Prev  string          `json:"prev"`
+	Event json.RawMessage `json:"event"`
+	Hash  string          `json:"hash"`
+}
+
+func recordHash(seq uint64, prev string, event []byte) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatUint(seq, 10) + "\n" + prev + "\n"))
+	h.Write(event)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Last record of a log.
+type Head struct {
+	Seq  uint64
+	Hash string
+}
+
+// Where and why a log failed verification.
+type ChainError struct {
+	// 1-based line number.
+	Line   int
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit log line %d: %s", e.Line, e.Reason)
+}
+
+// Checks that r holds an unbroken chain of records starting at the first
+// one, and returns the last. A *ChainError tells where the chain breaks.
+func Verify(r io.Reader) (Head, error) {
+	head := Head{Hash: genesisHash}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err == io.EOF && len(data) == 0 {
+			return head, nil
+		}
+		if err != nil && err != io.EOF {
+			return head, err
+		}
+		if err == io.EOF {
+			return head, &ChainError{Line: line, Reason: "incomplete record (no trailing newline)"}
+		}
+		rec, reason := parseRecord(data)
+		if reason == "" {
+			reason = checkLink(head, rec)
+		}
+		if reason != "" {
+			return head, &ChainError{Line: line, Reason: reason}
+		}
+		head = Head{Seq: rec.Seq, Hash: rec.Hash}
+	}
+}
+
+func parseRecord(line []byte) (*record, string) {
+	var rec record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, "not a JSON record: " + err.Error()
+	}
+	if rec.Hash == "" {
+		return nil, "record has no hash (written before logs were hash-chained?)"
+	}
+	var e Event
+	if err := json.Unmarshal(rec.Event, &e); err != nil {
+		return nil, "invalid event: " + err.Error()
+	}
+	return &rec, ""
+}
+
+// Why rec can't follow head, or "" when it can.
+func checkLink(head Head, rec *record) string {
+	if rec.Seq != head.Seq+1 {
+		return fmt.Sprintf("sequence %d follows %d", rec.Seq, head.Seq)
+	}
+	if rec.Prev != head.Hash {
+		return "previous hash does not match the record before"
+	}
+	if rec.Hash != recordHash(rec.Seq, rec.Prev, rec.Event) {
+		return "hash does not match the record's content"
+	}
+	return ""
+}
+
+// Appends events to a file as a hash chain, one JSON record per line; see
+// Verify. Records are synced to disk before Record returns. A single process
+// may write to a given file.
+type FileSink struct {
+	mu   sync.Mutex
+	f    *os.File
+	head Head
+	// End of the last record written and synced in full.
+	size int64
+	// Set when a failed write couldn't be removed from the file; appending
+	// after it would break the chain, so every later Record fails.
+	broken error
+}
+
+// Opens the log at path, creating it if needed, to append to its chain.
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	head, err := lastRecord(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading audit log %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading audit log %s: %w", path, err)
+	}
+	return &FileSink{f: f, head: head, size: info.Size()}, nil
+}
+
+// Records are far shorter than this; the last one is looked for within it.
+const maxRecordSize = 1 << 20
+
+// The head of the chain in f, read from its last record. The rest of the
+// chain is checked by Verify, not here.
+func lastRecord(f *os.File) (Head, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return Head{}, err
+	}
+	if info.Size() == 0 {
+		return Head{Hash: genesisHash}, nil
+	}
+	size := min(info.Size(), maxRecordSize+1)
+	tail := make([]byte, size)
+	if _, err := f.ReadAt(tail, info.Size()-size); err != nil {
+		return Head{}, err
+	}
+	if tail[len(tail)-1] != '\n' {
+		return Head{}, errors.New("the last record is incomplete")
+	}
+	tail = tail[:len(tail)-1]
+	start := bytes.LastIndexByte(tail, '\n') + 1
+	if start == 0 && size < info.Size() {
+		return Head{}, errors.New("the last record is too long")
+	}
+	rec, reason := parseRecord(tail[start:])
+	if reason != "" {
+		return Head{}, errors.New("last record: " + reason)
+	}
+	return Head{Seq: rec.Seq, Hash: rec.Hash}, nil
+}
+
+func (s *FileSink) Record(_ context.Context, e Event) error {
+	event, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken != nil {
+		return s.broken
+	}
+	rec := record{Seq: s.head.Seq + 1, Prev: s.head.Hash, Event: event}
+	rec.Hash = recordHash(rec.Seq, rec.Prev, event)
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	// The head only moves once the whole record is on disk. Anything less is
+	// cut off, so the next record follows the last complete one.
+	_, err = s.f.Write(line)
+	if err == nil {
+		err = s.f.Sync()
+	}
+	if err != nil {
+		if terr := s.f.Truncate(s.size); terr != nil {
+			s.broken = fmt.Errorf("audit log left with a partial record after %w; removing it failed: %v", err, terr)
+			return s.broken
+		}
+		return err
+	}
+	s.size += int64(len(line))
+	s.head = Head{Seq: rec.Seq, Hash: rec.Hash}
+	return nil
+}
+
+// Calls fn with each event recorded so far, oldest first, stopping at the
+// first error. The chain is not checked; see Verify.
+func (s *FileSink) Events(ctx context.Context, fn func(Event) error) error {
+	// Only complete records are read, never one being written.
+	s.mu.Lock()
+	size := s.size
+	s.mu.Unlock()
+	br := bufio.NewReader(io.NewSectionReader(s.f, 0, size))
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var rec record
+		var e Event
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return &ChainError{Line: line, Reason: "not a JSON record: " + err.Error()}
+		}
+		if err := json.Unmarshal(rec.Event, &e); err != nil {
+			return &ChainError{Line: line, Reason: "invalid event: " + err.Error()}
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+var (
+	_ Sink   = (*FileSink)(nil)
+	_ Source = (*FileSink)(nil)
+)
@@ -0,0 +1,67 @@
+//go:build unix
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"syscall"
+	"testing"
+)
+
+// A write cut short (here by the file size limit) is removed from the file,
+// so the next record still follows the last complete one.
+func TestRecordRemovesPartialWrite(t *testing.T) {
+	path := writeLog(t, 0)
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	if err := s.Record(ctx, event(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_FSIZE, &limit); err != nil {
+		t.Fatal(err)
+	}
+	// Room for about half a record. The runtime ignores SIGXFSZ, so the
+	// write fails with EFBIG instead.
+	short := limit
+	short.Cur = uint64(s.size + s.size/2)
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &short); err != nil {
+		t.Skipf("setting the file size limit: %v", err)
+	}
+	err = s.Record(ctx, event(1))
+	if rerr := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limit); rerr != nil {
+		t.Fatal(rerr)
+	}
+	if err == nil {
+		t.Fatal("Record succeeded past the file size limit")
+	}
+	if s.broken != nil {
+		t.Fatalf("sink broken: %v", s.broken)
+	}
+
+	if err := s.Record(ctx, event(2)); err != nil {
+		t.Fatal(err)
+	}
+	head, err := verifyFile(t, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Seq != 2 {
+		t.Errorf("head at %d, want 2", head.Seq)
+	}
+	recs := lines(t, path)
+	if len(recs) != 2 {
+		t.Fatalf("%d records, want 2", len(recs))
+	}
+	rec, _ := parseRecord(recs[1])
+	var e Event
+	if err := json.Unmarshal(rec.Event, &e); err != nil || !e.Time.Equal(event(2).Time) {
+		t.Errorf("second record is %s, want the event at %v", rec.Event, event(2).Time)
+	}
+}
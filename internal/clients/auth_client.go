@@ -31,3 +31,13 @@ func (c *AuthServiceClient) CloseConnection() error {
 	}
 	return nil
 }
+
+// Conn returns the underlying connection, for calls made without the typed client.
+func (c *AuthServiceClient) Conn() grpc.ClientConnInterface {
+	return c.conn
+}
+
+// ServiceName returns the fully-qualified gRPC service name.
+func (c *AuthServiceClient) ServiceName() string {
+	return pb.AuthService_ServiceDesc.ServiceName
+}
@@ -31,3 +31,13 @@ func (c *TransactionServiceClient) CloseConnection() error {
 	}
 	return nil
 }
+
+// Conn returns the underlying connection, for calls made without the typed client.
+func (c *TransactionServiceClient) Conn() grpc.ClientConnInterface {
+	return c.conn
+}
+
+// ServiceName returns the fully-qualified gRPC service name.
+func (c *TransactionServiceClient) ServiceName() string {
+	return pb.TransactionService_ServiceDesc.ServiceName
+}
@@ -31,3 +31,13 @@ func (c *UserProductServiceClient) CloseConnection() error {
 	}
 	return nil
 }
+
+// Conn returns the underlying connection, for calls made without the typed client.
+func (c *UserProductServiceClient) Conn() grpc.ClientConnInterface {
+	return c.conn
+}
+
+// ServiceName returns the fully-qualified gRPC service name.
+func (c *UserProductServiceClient) ServiceName() string {
+	return pb.UserProductService_ServiceDesc.ServiceName
+}
@@ -24,6 +24,8 @@ func RespondGrpcError(w http.ResponseWriter, err error) {
 			RespondWithError(w, http.StatusServiceUnavailable, "Backend service unavailable: "+err.Error())
 		case codes.DeadlineExceeded:
 			RespondWithError(w, http.StatusGatewayTimeout, "Backend service timeout: "+err.Error())
+		case codes.Unimplemented:
+			RespondWithError(w, http.StatusNotImplemented, st.Message())
 		default:
 			log.Printf("gRPC error: code=%v, message=%s", st.Code(), st.Message())
 			RespondWithError(w, http.StatusBadGateway, "Backend service error: "+err.Error())
@@ -55,3 +57,8 @@ func RespondWithJSON(w http.ResponseWriter, statusCode int, payload interface{})
 func RespondWithError(w http.ResponseWriter, statusCode int, message string) {
 	RespondWithJSON(w, statusCode, map[string]string{"error": message})
 }
+
+// RespondWithErrorCode writes an error JSON response with a machine-readable code.
+func RespondWithErrorCode(w http.ResponseWriter, statusCode int, code, message string) {
+	RespondWithJSON(w, statusCode, map[string]string{"error": message, "code": code})
+}
@@ -0,0 +1,87 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("account deletion not found")
+
+// Statuses of a deletion request.
+const (
+	// Waiting out the grace period; the user can still cancel.
+	StatusScheduled = "scheduled"
+	StatusCanceled  = "canceled"
+	// Steps are being carried out.
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	// A step failed and the ones before it were undone; the account is as it was.
+	StatusFailed = "failed"
+	// A step failed and could not be undone, or came after an irreversible
+	// one, or the gateway stopped mid-way:
+	// the account is partly deleted and someone has to finish the job.
+	StatusNeedsReview = "needs_review"
+)
+
+// Statuses of a step.
+const (
+	StepPending    = "pending"
+	StepDone       = "done"
+	StepFailed     = "failed"
+	StepUndone     = "undone"
+	StepUndoFailed = "undo_failed"
+)
+
+// Progress of one step of a deletion.
+type StepState struct {
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at,omitzero"`
+}
+
+// A user's request to delete their account. A user has at most one; asking
+// again after a cancellation or failure starts it over.
+type Request struct {
+	UserID      string
+	Status      string
+	RequestedAt time.Time
+	// End of the grace period; the steps run after it.
+	DueAt     time.Time
+	UpdatedAt time.Time
+	Steps     []StepState
+	// Why it failed or needs review.
+	Error string
+	// What the steps saved to undo themselves, e.g. the ids of the canceled
+	// scheduled transfers.
+	Data map[string]string
+}
+
+// Whether the request still holds back or acts on the account.
+func (r *Request) Pending() bool {
+	return r.Status == StatusScheduled || r.Status == StatusRunning
+}
+
+// Persists deletion requests.
+type Store interface {
+	// ErrNotFound when userID never asked for deletion.
+	Get(ctx context.Context, userID string) (*Request, error)
+	// Stores r, replacing the user's previous request unless it is still
+	// pending or completed. False when it was and nothing was stored.
+	Schedule(ctx context.Context, r *Request) (bool, error)
+	// Cancels the user's request while it is scheduled. False when it isn't.
+	Cancel(ctx context.Context, userID string, now time.Time) (bool, error)
+
+	// Scheduled requests due at or before now.
+	Due(ctx context.Context, now time.Time, limit int) ([]*Request, error)
+	// Marks a scheduled request as running. False when it was canceled or
+	// claimed by another worker.
+	Start(ctx context.Context, userID string, now time.Time) (bool, error)
+	// Stores the status, steps, error and data of a running request.
+	Save(ctx context.Context, r *Request) error
+	// Marks requests left running by a previous process as needing review.
+	AbandonRunning(ctx context.Context, now time.Time) (int, error)
+
+	Close() error
+}
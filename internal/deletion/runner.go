@@ -0,0 +1,226 @@
+package deletion
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+)
+
+const (
+	// Requests handled per tick.
+	batchSize = 20
+	// Limit on a single step or undo.
+	stepTimeout = 15 * time.Second
+)
+
+// One part of deleting an account, usually in one backend service.
+type Step struct {
+	Name string
+	Do   func(ctx context.Context, r *Request) error
+	// Reverts Do when a later step fails. Nil when there is nothing to revert
+	// or it can't be reverted; the step then stays done. Steps whose effect
+	// matters go last when they can't be reverted.
+	Undo func(ctx context.Context, r *Request) error
+	// Set when Do deletes what can't be brought back. Once such a step is
+	// done, a later failure undoes nothing: the deletion needs review, with
+	// the earlier steps left in place for support to finish it.
+	Irreversible bool
+}
+
+// A request for userID that runs steps once grace has passed.
+func NewRequest(userID string, steps []Step, now time.Time, grace time.Duration) *Request {
+	return &Request{
+		UserID:      userID,
+		Status:      StatusScheduled,
+		RequestedAt: now,
+		DueAt:       now.Add(grace),
+		UpdatedAt:   now,
+		Steps:       stepStates(steps),
+	}
+}
+
+func stepStates(steps []Step) []StepState {
+	states := make([]StepState, len(steps))
+	for i, s := range steps {
+		states[i] = StepState{Name: s.Name, Status: StepPending}
+	}
+	return states
+}
+
+// Carries out due deletions in the background: runs the steps in order and,
+// when one fails, undoes those before it in reverse order.
+type Runner struct {
+	store Store
+	steps []Step
+	audit audit.Sink
+	now   func() time.Time
+}
+
+func NewRunner(store Store, steps []Step, auditSink audit.Sink) *Runner {
+	return &Runner{store: store, steps: steps, audit: auditSink, now: time.Now}
+}
+
+// Checks for due deletions every interval until ctx is done.
+func (d *Runner) Run(ctx context.Context, interval time.Duration) {
+	// A deletion still marked running was cut off by a crash or restart; which
+	// of its steps went through is unknown.
+	if n, err := d.store.AbandonRunning(ctx, d.now()); err != nil {
+		log.Printf("Deletion: marking interrupted deletions: %v", err)
+	} else if n > 0 {
+		log.Printf("Deletion: %d deletion(s) were interrupted mid-way and need review", n)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Runner) tick(ctx context.Context) {
+	due, err := d.store.Due(ctx, d.now(), batchSize)
+	if err != nil {
+		log.Printf("Deletion: listing due deletions: %v", err)
+		return
+	}
+	for _, r := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		started, err := d.store.Start(ctx, r.UserID, d.now())
+		if err != nil {
+			log.Printf("Deletion: starting deletion of %s: %v", r.UserID, err)
+		}
+		if !started {
+			continue
+		}
+		d.execute(ctx, r)
+	}
+}
+
+func (d *Runner) execute(ctx context.Context, r *Request) {
+	r.Status, r.Error, r.Steps = StatusRunning, "", stepStates(d.steps)
+	if r.Data == nil {
+		r.Data = make(map[string]string)
+	}
+
+	for i, step := range d.steps {
+		err := ctx.Err()
+		if err == nil {
+			stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+			err = step.Do(stepCtx, r)
+			cancel()
+		}
+		if err != nil {
+			d.mark(r, i, StepFailed, err)
+			d.recordStep(ctx, r, step.Name, "do", err)
+			r.Error = fmt.Sprintf("%s: %v", step.Name, err)
+			if d.irreversibleBefore(i) {
+				r.Status = StatusNeedsReview
+				d.save(r)
+				d.recordOutcome(ctx, r)
+				return
+			}
+			d.undo(ctx, r, i)
+			return
+		}
+		d.mark(r, i, StepDone, nil)
+		d.recordStep(ctx, r, step.Name, "do", nil)
+		d.save(r)
+	}
+	r.Status = StatusCompleted
+	d.save(r)
+	d.recordOutcome(ctx, r)
+}
+
+// Whether a step before i can't be reverted; i is only reached once it is done.
+func (d *Runner) irreversibleBefore(i int) bool {
+	for _, step := range d.steps[:i] {
+		if step.Irreversible {
+			return true
+		}
+	}
+	return false
+}
+
+// Undoes the steps before failed, last first. Undos run even when the runner
+// is stopping, so a failed deletion is never left half done without trying.
+func (d *Runner) undo(ctx context.Context, r *Request, failed int) {
+	ctx = context.WithoutCancel(ctx)
+	r.Status = StatusFailed
+	for i := failed - 1; i >= 0; i-- {
+		step := d.steps[i]
+		if step.Undo == nil {
+			continue
+		}
+		stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+		err := step.Undo(stepCtx, r)
+		cancel()
+		d.recordStep(ctx, r, step.Name, "undo", err)
+		if err != nil {
+			d.mark(r, i, StepUndoFailed, err)
+			r.Status = StatusNeedsReview
+			continue
+		}
+		d.mark(r, i, StepUndone, nil)
+	}
+	d.save(r)
+	d.recordOutcome(ctx, r)
+}
+
+func (d *Runner) mark(r *Request, i int, status string, err error) {
+	r.Steps[i].Status, r.Steps[i].At, r.Steps[i].Error = status, d.now(), ""
+	if err != nil {
+		r.Steps[i].Error = err.Error()
+	}
+}
+
+// Progress is stored even when the runner is stopping, so the request shows
+// how far the deletion got.
+func (d *Runner) save(r *Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r.UpdatedAt = d.now()
+	if err := d.store.Save(ctx, r); err != nil {
+		log.Printf("Deletion: saving deletion of %s: %v", r.UserID, err)
+	}
+}
+
+// Deletions are carried out on the user's request, so the user is the actor.
+func (d *Runner) recordStep(ctx context.Context, r *Request, step, phase string, err error) {
+	e := audit.Event{
+		Actor:   r.UserID,
+		Action:  "account.deletion.step",
+		Target:  r.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"step": step, "phase": phase},
+	}
+	if err != nil {
+		e.Outcome = audit.OutcomeFailure
+		e.Details["error"] = err.Error()
+	}
+	audit.Record(ctx, d.audit, e)
+}
+
+func (d *Runner) recordOutcome(ctx context.Context, r *Request) {
+	e := audit.Event{
+		Actor:   r.UserID,
+		Action:  "account.deletion",
+		Target:  r.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"status": r.Status},
+	}
+	if r.Status != StatusCompleted {
+		e.Outcome = audit.OutcomeFailure
+		e.Details["error"] = r.Error
+	}
+	audit.Record(ctx, d.audit, e)
+}
@@ -0,0 +1,106 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+
+// Steps recording their calls in calls.
+type recorder struct{ calls []string }
+
+func (rec *recorder) step(name string, fail, undoable bool) Step {
+	s := Step{Name: name, Do: func(context.Context, *Request) error {
+		rec.calls = append(rec.calls, "do "+name)
+		if fail {
+			return errors.New("unavailable")
+		}
+		return nil
+	}}
+	if undoable {
+		s.Undo = func(context.Context, *Request) error {
+			rec.calls = append(rec.calls, "undo "+name)
+			return nil
+		}
+	}
+	return s
+}
+
+// Runs a deletion of u1 through steps and returns it as stored.
+func runDeletion(t *testing.T, steps []Step) *Request {
+	t.Helper()
+	ctx := context.Background()
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "deletions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	r := NewRequest("u1", steps, t0, time.Hour)
+	if ok, err := store.Schedule(ctx, r); err != nil || !ok {
+		t.Fatalf("Schedule = %v, %v", ok, err)
+	}
+	runner := NewRunner(store, steps, nil)
+	runner.now = func() time.Time { return t0.Add(2 * time.Hour) }
+	runner.tick(ctx)
+
+	got, err := store.Get(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func checkSteps(t *testing.T, r *Request, want []string) {
+	t.Helper()
+	for i, s := range r.Steps {
+		if s.Status != want[i] {
+			t.Errorf("step %s is %s, want %s", s.Name, s.Status, want[i])
+		}
+	}
+}
+
+func TestRunnerUndoesInReverse(t *testing.T) {
+	rec := &recorder{}
+	got := runDeletion(t, []Step{
+		rec.step("lock", false, true),
+		rec.step("sessions", false, false),
+		rec.step("transaction_account", false, true),
+		rec.step("profile", true, false),
+	})
+
+	wantCalls := []string{"do lock", "do sessions", "do transaction_account", "do profile", "undo transaction_account", "undo lock"}
+	if !reflect.DeepEqual(rec.calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", rec.calls, wantCalls)
+	}
+	if got.Status != StatusFailed {
+		t.Errorf("status = %s, want %s", got.Status, StatusFailed)
+	}
+	// A step that can't be undone stays done rather than claiming to be undone.
+	checkSteps(t, got, []string{StepUndone, StepDone, StepUndone, StepFailed})
+}
+
+func TestRunnerUndoesNothingAfterIrreversibleStep(t *testing.T) {
+	rec := &recorder{}
+	profile := rec.step("profile", false, false)
+	profile.Irreversible = true
+	got := runDeletion(t, []Step{
+		rec.step("lock", false, true),
+		profile,
+		rec.step("credentials", true, false),
+	})
+
+	wantCalls := []string{"do lock", "do profile", "do credentials"}
+	if !reflect.DeepEqual(rec.calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", rec.calls, wantCalls)
+	}
+	if got.Status != StatusNeedsReview || got.Error != "credentials: unavailable" {
+		t.Errorf("status = %s (%q), want %s", got.Status, got.Error, StatusNeedsReview)
+	}
+	checkSteps(t, got, []string{StepDone, StepDone, StepFailed})
+}
@@ -0,0 +1,167 @@
+package deletion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS deletions (
+	user_id      TEXT PRIMARY KEY,
+	status       TEXT NOT NULL,
+	requested_at INTEGER NOT NULL,
+	due_at       INTEGER NOT NULL,
+	updated_at   INTEGER NOT NULL,
+	steps        TEXT NOT NULL,
+	error        TEXT NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS deletions_due ON deletions (status, due_at);
+`
+
+// Store backed by a SQLite file. Times are stored as Unix seconds, steps and
+// data as JSON.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating account deletion table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+const deletionColumns = `user_id, status, requested_at, due_at, updated_at, steps, error, data`
+
+func (st *SQLiteStore) Get(ctx context.Context, userID string) (*Request, error) {
+	reqs, err := st.query(ctx, `SELECT `+deletionColumns+` FROM deletions WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 {
+		return nil, ErrNotFound
+	}
+	return reqs[0], nil
+}
+
+func (st *SQLiteStore) Schedule(ctx context.Context, r *Request) (bool, error) {
+	steps, data, err := encode(r)
+	if err != nil {
+		return false, err
+	}
+	res, err := st.db.ExecContext(ctx, `INSERT INTO deletions (`+deletionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET status = excluded.status, requested_at = excluded.requested_at,
+			due_at = excluded.due_at, updated_at = excluded.updated_at, steps = excluded.steps,
+			error = excluded.error, data = excluded.data
+		WHERE deletions.status NOT IN (?, ?, ?)`,
+		r.UserID, r.Status, r.RequestedAt.Unix(), r.DueAt.Unix(), r.UpdatedAt.Unix(), steps, r.Error, data,
+		StatusScheduled, StatusRunning, StatusCompleted)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Cancel(ctx context.Context, userID string, now time.Time) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE deletions SET status = ?, updated_at = ? WHERE user_id = ? AND status = ?`,
+		StatusCanceled, now.Unix(), userID, StatusScheduled)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Due(ctx context.Context, now time.Time, limit int) ([]*Request, error) {
+	return st.query(ctx, `SELECT `+deletionColumns+` FROM deletions WHERE status = ? AND due_at <= ? ORDER BY due_at LIMIT ?`,
+		StatusScheduled, now.Unix(), limit)
+}
+
+func (st *SQLiteStore) Start(ctx context.Context, userID string, now time.Time) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE deletions SET status = ?, updated_at = ? WHERE user_id = ? AND status = ?`,
+		StatusRunning, now.Unix(), userID, StatusScheduled)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Save(ctx context.Context, r *Request) error {
+	steps, data, err := encode(r)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.ExecContext(ctx, `UPDATE deletions SET status = ?, updated_at = ?, steps = ?, error = ?, data = ? WHERE user_id = ?`,
+		r.Status, r.UpdatedAt.Unix(), steps, r.Error, data, r.UserID)
+	return err
+}
+
+func (st *SQLiteStore) AbandonRunning(ctx context.Context, now time.Time) (int, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE deletions SET status = ?, error = ?, updated_at = ? WHERE status = ?`,
+		StatusNeedsReview, "interrupted while deleting the account", now.Unix(), StatusRunning)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func encode(r *Request) (steps, data string, err error) {
+	s, err := json.Marshal(r.Steps)
+	if err != nil {
+		return "", "", err
+	}
+	d, err := json.Marshal(r.Data)
+	if err != nil {
+		return "", "", err
+	}
+	return string(s), string(d), nil
+}
+
+func (st *SQLiteStore) query(ctx context.Context, query string, args ...any) ([]*Request, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reqs []*Request
+	for rows.Next() {
+		var r Request
+		var requestedAt, dueAt, updatedAt int64
+		var steps, data string
+		if err := rows.Scan(&r.UserID, &r.Status, &requestedAt, &dueAt, &updatedAt, &steps, &r.Error, &data); err != nil {
+			return nil, err
+		}
+		r.RequestedAt, r.DueAt, r.UpdatedAt = time.Unix(requestedAt, 0), time.Unix(dueAt, 0), time.Unix(updatedAt, 0)
+		if err := json.Unmarshal([]byte(steps), &r.Steps); err != nil {
+			return nil, fmt.Errorf("deletion of %s: steps: %w", r.UserID, err)
+		}
+		if err := json.Unmarshal([]byte(data), &r.Data); err != nil {
+			return nil, fmt.Errorf("deletion of %s: data: %w", r.UserID, err)
+		}
+		reqs = append(reqs, &r)
+	}
+	return reqs, rows.Err()
+}
+
+var _ Store = (*SQLiteStore)(nil)
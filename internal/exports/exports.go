@@ -0,0 +1,67 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("data export not found")
+
+// Statuses of an export job.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	// The archive can be downloaded until the job expires.
+	StatusReady  = "ready"
+	StatusFailed = "failed"
+)
+
+// A user's request for a copy of their data.
+type Job struct {
+	ID        string
+	UserID    string
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// When the archive and the job are removed; unset until the job ends.
+	ExpiresAt time.Time
+	// Path and size of the archive once ready.
+	File  string
+	Size  int64
+	Error string
+}
+
+// Whether the job hasn't ended yet.
+func (j *Job) Active() bool {
+	return j.Status == StatusPending || j.Status == StatusRunning
+}
+
+// Whether the job ended and its retention is over at now.
+func (j *Job) Expired(now time.Time) bool {
+	return !j.ExpiresAt.IsZero() && !now.Before(j.ExpiresAt)
+}
+
+// Persists export jobs. The archives themselves are files the runner writes.
+type Store interface {
+	// Stores a pending job. False when the user already has an active one.
+	Create(ctx context.Context, j *Job) (bool, error)
+	// ErrNotFound when the job doesn't exist or belongs to someone else.
+	Get(ctx context.Context, userID, id string) (*Job, error)
+	// The user's pending or running job; ErrNotFound when there is none.
+	Active(ctx context.Context, userID string) (*Job, error)
+
+	// Pending jobs, oldest first.
+	Pending(ctx context.Context, limit int) ([]*Job, error)
+	// Marks a pending job as running. False when it is no longer pending.
+	Start(ctx context.Context, id string, now time.Time) (bool, error)
+	// Records how a job ended: status, expiry, file, size, error.
+	Finish(ctx context.Context, j *Job) error
+	// Marks jobs left running by a previous process as failed.
+	AbandonRunning(ctx context.Context, now time.Time) (int, error)
+	// Ended jobs that expired at or before now.
+	Expired(ctx context.Context, now time.Time, limit int) ([]*Job, error)
+	Delete(ctx context.Context, id string) error
+
+	Close() error
+}
@@ -0,0 +1,160 @@
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// How long archives can be downloaded, and failed jobs shown, before
+	// they are removed.
+	retention = 24 * time.Hour
+	// Jobs started and expired jobs removed per tick.
+	batchSize = 10
+	// Limit on building a single archive.
+	buildTimeout = 10 * time.Minute
+)
+
+// Writes the data of the job's user into the archive.
+type Builder func(ctx context.Context, j *Job, zw *zip.Writer) error
+
+// Builds pending archives in the background, one at a time, and removes them
+// once expired.
+type Runner struct {
+	store Store
+	dir   string
+	build Builder
+	now   func() time.Time
+}
+
+// Archives are written to dir, which is created if needed.
+func NewRunner(store Store, dir string, build Builder) (*Runner, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating export directory: %w", err)
+	}
+	return &Runner{store: store, dir: dir, build: build, now: time.Now}, nil
+}
+
+// Checks for pending and expired jobs every interval until ctx is done.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	// A job still marked running was cut off by a crash or restart; the user
+	// has to ask again.
+	if n, err := r.store.AbandonRunning(ctx, r.now()); err != nil {
+		log.Printf("Exports: marking interrupted jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("Exports: %d job(s) were interrupted and marked failed", n)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) tick(ctx context.Context) {
+	expired, err := r.store.Expired(ctx, r.now(), batchSize)
+	if err != nil {
+		log.Printf("Exports: listing expired jobs: %v", err)
+	}
+	for _, j := range expired {
+		if err := r.remove(ctx, j); err != nil {
+			log.Printf("Exports: removing job %s: %v", j.ID, err)
+		}
+	}
+
+	pending, err := r.store.Pending(ctx, batchSize)
+	if err != nil {
+		log.Printf("Exports: listing pending jobs: %v", err)
+		return
+	}
+	for _, j := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		started, err := r.store.Start(ctx, j.ID, r.now())
+		if err != nil {
+			log.Printf("Exports: starting job %s: %v", j.ID, err)
+		}
+		if !started {
+			continue
+		}
+		if err := r.execute(ctx, j); err != nil {
+			log.Printf("Exports: job %s: %v", j.ID, err)
+		}
+	}
+}
+
+func (r *Runner) execute(ctx context.Context, j *Job) error {
+	buildCtx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+
+	path := filepath.Join(r.dir, j.ID+".zip")
+	size, err := r.write(buildCtx, j, path)
+	if err != nil {
+		j.Status, j.Error = StatusFailed, err.Error()
+	} else {
+		j.Status, j.File, j.Size = StatusReady, path, size
+	}
+	return r.finish(j)
+}
+
+// Writes the archive to a temporary file and moves it to path once complete,
+// so a partial archive is never served.
+func (r *Runner) write(ctx context.Context, j *Job, path string) (int64, error) {
+	tmp, err := os.CreateTemp(r.dir, j.ID+"-*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	if err := r.build(ctx, j, zw); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Outcomes are recorded even when the runner is stopping, so a finished job
+// is never left looking in progress.
+func (r *Runner) finish(j *Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	j.UpdatedAt = r.now()
+	j.ExpiresAt = j.UpdatedAt.Add(retention)
+	return r.store.Finish(ctx, j)
+}
+
+func (r *Runner) remove(ctx context.Context, j *Job) error {
+	if j.File != "" {
+		if err := os.Remove(j.File); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return r.store.Delete(ctx, j.ID)
+}
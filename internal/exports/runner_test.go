@@ -0,0 +1,136 @@
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+
+func openStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "exports.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func createJob(t *testing.T, store Store, id, userID string) {
+	t.Helper()
+	j := &Job{ID: id, UserID: userID, Status: StatusPending, CreatedAt: t0, UpdatedAt: t0}
+	if ok, err := store.Create(context.Background(), j); err != nil || !ok {
+		t.Fatalf("Create(%s) = %v, %v", id, ok, err)
+	}
+}
+
+func TestGetOnlyOwnJobs(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	createJob(t, store, "e1", "u1")
+
+	if j, err := store.Get(ctx, "u1", "e1"); err != nil || j.UserID != "u1" {
+		t.Fatalf("Get by owner = %+v, %v", j, err)
+	}
+	if _, err := store.Get(ctx, "u2", "e1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get by another user: err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Active(ctx, "u2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Active of another user: err = %v, want ErrNotFound", err)
+	}
+	// One job in progress per user, but other users are independent.
+	if ok, _ := store.Create(ctx, &Job{ID: "e2", UserID: "u1", Status: StatusPending, CreatedAt: t0, UpdatedAt: t0}); ok {
+		t.Error("second active job created")
+	}
+	createJob(t, store, "e3", "u2")
+}
+
+// Runs one tick of a runner over store at now, building archives with build.
+func tick(t *testing.T, store Store, dir string, now time.Time, build Builder) {
+	t.Helper()
+	r, err := NewRunner(store, dir, build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time { return now }
+	r.tick(context.Background())
+}
+
+func TestRunnerBuildsThenExpires(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	dir := t.TempDir()
+	createJob(t, store, "e1", "u1")
+
+	built := t0.Add(time.Minute)
+	tick(t, store, dir, built, func(_ context.Context, j *Job, zw *zip.Writer) error {
+		f, err := zw.Create("profile.json")
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, `{"user_id":"`+j.UserID+`"}`)
+		return err
+	})
+	j, err := store.Get(ctx, "u1", "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != StatusReady || !j.ExpiresAt.Equal(built.Add(retention)) {
+		t.Fatalf("job = %+v, want ready until %v", j, built.Add(retention))
+	}
+	zr, err := zip.OpenReader(j.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr.Close()
+	if len(zr.File) != 1 || zr.File[0].Name != "profile.json" {
+		t.Errorf("archive holds %v", zr.File)
+	}
+	if j.Expired(j.ExpiresAt.Add(-time.Second)) || !j.Expired(j.ExpiresAt) {
+		t.Error("Expired does not switch at ExpiresAt")
+	}
+
+	// Kept until it expires, then removed with its archive.
+	tick(t, store, dir, j.ExpiresAt.Add(-time.Second), nil)
+	if _, err := store.Get(ctx, "u1", "e1"); err != nil {
+		t.Fatalf("before expiry: %v", err)
+	}
+	tick(t, store, dir, j.ExpiresAt, nil)
+	if _, err := store.Get(ctx, "u1", "e1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("after expiry: err = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(j.File); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("archive left behind: %v", err)
+	}
+}
+
+func TestRunnerFailedBuildLeavesNoArchive(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	dir := t.TempDir()
+	createJob(t, store, "e1", "u1")
+
+	tick(t, store, dir, t0, func(_ context.Context, _ *Job, zw *zip.Writer) error {
+		if _, err := zw.Create("profile.json"); err != nil {
+			return err
+		}
+		return errors.New("reading movements: unavailable")
+	})
+	j, err := store.Get(ctx, "u1", "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != StatusFailed || j.Error != "reading movements: unavailable" || j.File != "" {
+		t.Errorf("job = %+v, want failed without a file", j)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("export directory holds %v", entries)
+	}
+}
@@ -0,0 +1,155 @@
+package exports
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS export_jobs (
+	id         TEXT PRIMARY KEY,
+	user_id    TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL,
+	expires_at INTEGER,
+	file       TEXT NOT NULL,
+	size       INTEGER NOT NULL,
+	error      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS export_jobs_status ON export_jobs (status, created_at);
+-- A user has at most one job in progress.
+CREATE UNIQUE INDEX IF NOT EXISTS export_jobs_active ON export_jobs (user_id) WHERE status IN ('pending', 'running');
+`
+
+// Store backed by a SQLite file. Times are stored as Unix seconds.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating export job table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+const jobColumns = `id, user_id, status, created_at, updated_at, expires_at, file, size, error`
+
+func (st *SQLiteStore) Create(ctx context.Context, j *Job) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `INSERT INTO export_jobs (`+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		j.ID, j.UserID, j.Status, j.CreatedAt.Unix(), j.UpdatedAt.Unix(), nullableUnix(j.ExpiresAt), j.File, j.Size, j.Error)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Get(ctx context.Context, userID, id string) (*Job, error) {
+	return st.queryOne(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE id = ? AND user_id = ?`, id, userID)
+}
+
+func (st *SQLiteStore) Active(ctx context.Context, userID string) (*Job, error) {
+	return st.queryOne(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE user_id = ? AND status IN (?, ?)`,
+		userID, StatusPending, StatusRunning)
+}
+
+func (st *SQLiteStore) Pending(ctx context.Context, limit int) ([]*Job, error) {
+	return st.query(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE status = ? ORDER BY created_at LIMIT ?`, StatusPending, limit)
+}
+
+func (st *SQLiteStore) Start(ctx context.Context, id string, now time.Time) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE export_jobs SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		StatusRunning, now.Unix(), id, StatusPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Finish(ctx context.Context, j *Job) error {
+	_, err := st.db.ExecContext(ctx, `UPDATE export_jobs SET status = ?, updated_at = ?, expires_at = ?, file = ?, size = ?, error = ? WHERE id = ?`,
+		j.Status, j.UpdatedAt.Unix(), nullableUnix(j.ExpiresAt), j.File, j.Size, j.Error, j.ID)
+	return err
+}
+
+func (st *SQLiteStore) AbandonRunning(ctx context.Context, now time.Time) (int, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE export_jobs SET status = ?, error = ?, updated_at = ?, expires_at = ? WHERE status = ?`,
+		StatusFailed, "interrupted while the archive was being built", now.Unix(), now.Add(retention).Unix(), StatusRunning)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (st *SQLiteStore) Expired(ctx context.Context, now time.Time, limit int) ([]*Job, error) {
+	return st.query(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE expires_at IS NOT NULL AND expires_at <= ? ORDER BY expires_at LIMIT ?`,
+		now.Unix(), limit)
+}
+
+func (st *SQLiteStore) Delete(ctx context.Context, id string) error {
+	_, err := st.db.ExecContext(ctx, `DELETE FROM export_jobs WHERE id = ?`, id)
+	return err
+}
+
+func (st *SQLiteStore) queryOne(ctx context.Context, query string, args ...any) (*Job, error) {
+	jobs, err := st.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, ErrNotFound
+	}
+	return jobs[0], nil
+}
+
+func (st *SQLiteStore) query(ctx context.Context, query string, args ...any) ([]*Job, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		var j Job
+		var createdAt, updatedAt int64
+		var expiresAt sql.NullInt64
+		if err := rows.Scan(&j.ID, &j.UserID, &j.Status, &createdAt, &updatedAt, &expiresAt, &j.File, &j.Size, &j.Error); err != nil {
+			return nil, err
+		}
+		j.CreatedAt, j.UpdatedAt = time.Unix(createdAt, 0), time.Unix(updatedAt, 0)
+		if expiresAt.Valid {
+			j.ExpiresAt = time.Unix(expiresAt.Int64, 0)
+		}
+		jobs = append(jobs, &j)
+	}
+	return jobs, rows.Err()
+}
+
+func nullableUnix(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Unix()
+}
+
+var _ Store = (*SQLiteStore)(nil)
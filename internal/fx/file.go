@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Serves rates from a file, re-read periodically so rates can be updated
+// without a restart. Without a path it serves the fallback table only, as
+// reference rates.
+type FileProvider struct {
+	path string
+
+	mu    sync.RWMutex
+	table *Table
+}
+
+func NewFileProvider(path string, fallback []byte) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if path == "" {
+		table, err := ParseTable(fallback)
+		if err != nil {
+			return nil, err
+		}
+		table.Reference = true
+		p.table = table
+		return p, nil
+	}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileProvider) reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	table, err := ParseTable(data)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.table = table
+	p.mu.Unlock()
+	return nil
+}
+
+// Re-reads the file every interval until ctx is done, keeping the last good
+// rates when a reload fails.
+func (p *FileProvider) Run(ctx context.Context, interval time.Duration) {
+	if p.path == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.reload(); err != nil {
+				log.Printf("Failed to reload exchange rates from %s, keeping previous rates: %v", p.path, err)
+			}
+		}
+	}
+}
+
+func (p *FileProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	p.mu.RLock()
+	table := p.table
+	p.mu.RUnlock()
+	return table.Rate(from, to)
+}
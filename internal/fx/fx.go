@@ -0,0 +1,117 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+var (
+	ErrUnsupported = errors.New("no exchange rate for currency")
+	// The rate may show amounts in another currency but not price a transfer.
+	ErrNotTradable = errors.New("exchange rate can't be used for transfers")
+)
+
+// Decimals kept on cross rates.
+const rateDecimals = 12
+
+// Price of one unit of From in To, as published by Source at AsOf.
+type Rate struct {
+	From   string
+	To     string
+	Value  money.Decimal
+	AsOf   time.Time
+	Source string
+	// Taken from the built-in reference table, which is never updated.
+	Reference bool
+}
+
+// Nil when r can price a transfer at now: it doesn't come from the reference
+// table and was published at most maxAge before now.
+func (r Rate) Tradable(now time.Time, maxAge time.Duration) error {
+	if r.Reference {
+		return fmt.Errorf("%w: only the built-in reference rates are loaded", ErrNotTradable)
+	}
+	if r.AsOf.IsZero() || now.Sub(r.AsOf) > maxAge {
+		return fmt.Errorf("%w: rates as of %s are older than %s", ErrNotTradable, r.AsOf.UTC().Format(time.RFC3339), maxAge)
+	}
+	return nil
+}
+
+// Supplies exchange rates.
+type Provider interface {
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// Rates published against a single base currency.
+type Table struct {
+	Base   string
+	AsOf   time.Time
+	Source string
+	Rates  map[string]money.Decimal
+	// Set on the built-in table; see Rate.Reference.
+	Reference bool
+}
+
+type tableFile struct {
+	Base   string            `yaml:"base"`
+	AsOf   time.Time         `yaml:"as_of"`
+	Source string            `yaml:"source"`
+	Rates  map[string]string `yaml:"rates"`
+}
+
+// Parses a rates file (YAML or JSON); see config/fx_rates.yaml.
+func ParseTable(data []byte) (*Table, error) {
+	var f tableFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing rates: %w", err)
+	}
+	if f.Base == "" {
+		return nil, errors.New("rates: missing base currency")
+	}
+
+	t := &Table{Base: strings.ToUpper(f.Base), AsOf: f.AsOf, Source: f.Source, Rates: make(map[string]money.Decimal, len(f.Rates)+1)}
+	var problems []error
+	for code, s := range f.Rates {
+		d, err := money.Parse(s)
+		if err == nil && d.Sign() <= 0 {
+			err = errors.New("must be positive")
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("rates: %s: %w", code, err))
+			continue
+		}
+		t.Rates[strings.ToUpper(code)] = d
+	}
+	if err := errors.Join(problems...); err != nil {
+		return nil, err
+	}
+	t.Rates[t.Base] = money.FromInt(1)
+	return t, nil
+}
+
+// Rate from one currency to another, crossing through the base when neither is it.
+func (t *Table) Rate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	fromRate, ok := t.Rates[from]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w %s", ErrUnsupported, from)
+	}
+	toRate, ok := t.Rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w %s", ErrUnsupported, to)
+	}
+
+	value := money.FromInt(1)
+	if from != to {
+		value = toRate.Quo(fromRate, rateDecimals)
+		value = value.Round(value.Decimals())
+	}
+	return Rate{From: from, To: to, Value: value, AsOf: t.AsOf, Source: t.Source, Reference: t.Reference}, nil
+}
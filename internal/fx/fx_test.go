@@ -0,0 +1,162 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+const ratesFile = `
+base: usd
+as_of: 2025-07-01T00:00:00Z
+source: ecb
+rates:
+  EUR: "0.85"
+  COP: "4050.00"
+  JPY: "144"
+`
+
+func TestTableRate(t *testing.T) {
+	table, err := ParseTable([]byte(ratesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"USD", "EUR", "0.85"},
+		{"usd", "cop", "4050"},
+		{"EUR", "EUR", "1"},
+		// Inverse of a base rate.
+		{"EUR", "USD", "1.176470588235"},
+		{"COP", "USD", "0.00024691358"},
+		// Crossed through the base, rounded to 12 decimals.
+		{"EUR", "COP", "4764.705882352941"},
+		{"COP", "JPY", "0.035555555556"},
+		{"JPY", "EUR", "0.005902777778"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"_"+tt.to, func(t *testing.T) {
+			r, err := table.Rate(tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Value.String(); got != tt.want {
+				t.Errorf("rate = %s, want %s", got, tt.want)
+			}
+			if r.Source != "ecb" || !r.AsOf.Equal(t0) || r.Reference {
+				t.Errorf("rate = %+v, want source ecb as of %v", r, t0)
+			}
+		})
+	}
+
+	if _, err := table.Rate("USD", "GBP"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("unknown currency: err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestParseTableRejects(t *testing.T) {
+	for name, data := range map[string]string{
+		"no base":       `rates: {EUR: "0.85"}`,
+		"zero rate":     "base: USD\nrates: {EUR: \"0\"}",
+		"negative rate": "base: USD\nrates: {EUR: \"-1\"}",
+		"not a number":  "base: USD\nrates: {EUR: \"abc\"}",
+		"not yaml":      "base: [",
+	} {
+		if _, err := ParseTable([]byte(data)); err == nil {
+			t.Errorf("%s: ParseTable succeeded", name)
+		}
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fallback", func(t *testing.T) {
+		p, err := NewFileProvider("", []byte(ratesFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := p.Rate(ctx, "USD", "EUR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Reference {
+			t.Error("built-in rate not marked as reference")
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rates.yaml")
+		if err := os.WriteFile(path, []byte(ratesFile), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewFileProvider(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, err := p.Rate(ctx, "USD", "EUR"); err != nil || r.Value.String() != "0.85" || r.Reference {
+			t.Fatalf("Rate = %+v, %v", r, err)
+		}
+
+		updated := "base: USD\nas_of: 2025-07-02T00:00:00Z\nsource: ecb\nrates: {EUR: \"0.86\"}\n"
+		if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.reload(); err != nil {
+			t.Fatal(err)
+		}
+		if r, _ := p.Rate(ctx, "USD", "EUR"); r.Value.String() != "0.86" || !r.AsOf.Equal(t0.Add(24*time.Hour)) {
+			t.Errorf("after reload: rate = %+v, want 0.86 as of the next day", r)
+		}
+
+		// A broken file keeps the last good rates.
+		if err := os.WriteFile(path, []byte("base: ["), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.reload(); err == nil {
+			t.Error("reloading a broken file succeeded")
+		}
+		if r, _ := p.Rate(ctx, "USD", "EUR"); r.Value.String() != "0.86" {
+			t.Errorf("after a failed reload: rate = %s, want 0.86", r.Value)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := NewFileProvider(filepath.Join(t.TempDir(), "missing.yaml"), []byte(ratesFile)); err == nil {
+			t.Error("NewFileProvider succeeded without its file")
+		}
+	})
+}
+
+func TestTradable(t *testing.T) {
+	tests := []struct {
+		name string
+		rate Rate
+		now  time.Time
+		ok   bool
+	}{
+		{"fresh", Rate{AsOf: t0}, t0.Add(23 * time.Hour), true},
+		{"at the limit", Rate{AsOf: t0}, t0.Add(24 * time.Hour), true},
+		{"stale", Rate{AsOf: t0}, t0.Add(25 * time.Hour), false},
+		{"no date", Rate{}, t0, false},
+		{"reference", Rate{AsOf: t0, Reference: true}, t0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rate.Tradable(tt.now, 24*time.Hour)
+			if tt.ok && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrNotTradable) {
+				t.Errorf("err = %v, want ErrNotTradable", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/notify"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/tokens"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 24 * time.Hour
+)
+
+// ForgotPasswordReq is the body of POST /password/forgot.
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordReq is the body of POST /password/reset.
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ConfirmEmailReq is the body of POST /verifications/email/confirm.
+type ConfirmEmailReq struct {
+	Token string `json:"token"`
+}
+
+// Password operations the auth service has no RPCs for yet.
+type PasswordBackend interface {
+	// False while the auth service can't reset passwords; the reset routes
+	// aren't registered then.
+	Supported() bool
+	// Id of the user registered with email; codes.NotFound when there is none.
+	UserIDByEmail(ctx context.Context, email string) (string, error)
+	SetPassword(ctx context.Context, userID, password string) error
+}
+
+// A PasswordBackend answering every call with codes.Unimplemented, until the
+// auth service can look users up by email and set passwords.
+type UnsupportedPasswords struct{}
+
+func (UnsupportedPasswords) Supported() bool { return false }
+
+func (UnsupportedPasswords) UserIDByEmail(context.Context, string) (string, error) {
+	return "", status.Error(codes.Unimplemented, "password reset is not supported by the auth service yet")
+}
+
+func (UnsupportedPasswords) SetPassword(context.Context, string, string) error {
+	return status.Error(codes.Unimplemented, "password reset is not supported by the auth service yet")
+}
+
+// Serves the flows confirmed through links emailed to the user.
+type AccountHandler struct {
+	UserProductClient *clients.UserProductServiceClient
+	Passwords         PasswordBackend
+	Tokens            *tokens.Issuer
+	Notifier          notify.Notifier
+	// Base URL of the web app the emailed links open.
+	AppURL string
+	Audit  audit.Sink
+}
+
+func NewAccountHandler(userClient *clients.UserProductServiceClient, passwords PasswordBackend, tokens *tokens.Issuer, notifier notify.Notifier, appURL string, auditSink audit.Sink) *AccountHandler {
+	return &AccountHandler{
+		UserProductClient: userClient,
+		Passwords:         passwords,
+		Tokens:            tokens,
+		Notifier:          notifier,
+		AppURL:            strings.TrimRight(appURL, "/"),
+		Audit:             auditSink,
+	}
+}
+
+// ForgotPassword handles POST /password/forgot
+func (h *AccountHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var reqBody ForgotPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	email := strings.TrimSpace(reqBody.Email)
+	if email == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing email")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// The answer is the same whether or not the email is registered.
+	sent := transformers.AccountMessageResp{Success: true, Message: "If the email is registered, a reset link was sent to it"}
+	userID, err := h.Passwords.UserIDByEmail(ctx, email)
+	if status.Code(err) == codes.NotFound {
+		common.RespondWithJSON(w, http.StatusAccepted, sent)
+		return
+	}
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+
+	if err := h.sendPasswordReset(ctx, userID, email); err != nil {
+		respondPasswordResetError(w, "ForgotPassword", err)
+		return
+	}
+	log.Println("ForgotPassword: reset link sent to userId:", userID)
+	common.RespondWithJSON(w, http.StatusAccepted, sent)
+}
+
+// Returned by sendPasswordReset when the email could not be sent.
+var errNotSent = errors.New("could not send the email")
+
+// Emails userID a password reset link at email.
+func (h *AccountHandler) sendPasswordReset(ctx context.Context, userID, email string) error {
+	token, _, err := h.Tokens.Issue(tokens.PurposePasswordReset, userID, email, passwordResetTTL, time.Now())
+	if err != nil {
+		return fmt.Errorf("issuing token: %w", err)
+	}
+	err = h.Notifier.Send(ctx, notify.Message{
+		To:      email,
+		Subject: "Reset your Nova password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your Nova account. To choose a new one, open:\n\n%s\n\nThe link expires in %d minutes and works once. If it wasn't you, ignore this message.",
+			h.link("/reset-password", token), int(passwordResetTTL.Minutes())),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNotSent, err)
+	}
+	return nil
+}
+
+func respondPasswordResetError(w http.ResponseWriter, caller string, err error) {
+	log.Printf("%s: %v", caller, err)
+	if errors.Is(err, errNotSent) {
+		common.RespondWithError(w, http.StatusServiceUnavailable, "Could not send the reset email")
+		return
+	}
+	common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+}
+
+// ResetPassword handles POST /password/reset
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var reqBody ResetPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if reqBody.Token == "" || reqBody.Password == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing 'token' or 'password'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	claims, err := h.Tokens.Check(reqBody.Token, tokens.PurposePasswordReset, now)
+	if err == nil {
+		err = h.Tokens.Redeem(ctx, claims, now)
+	}
+	if err != nil {
+		respondTokenError(w, err)
+		return
+	}
+	// Redeemed first so two requests can't both use the link; it works again
+	// when the password wasn't changed.
+	if err := h.Passwords.SetPassword(ctx, claims.UserID, reqBody.Password); err != nil {
+		h.releaseToken(claims)
+		common.RespondGrpcError(w, err)
+		return
+	}
+	log.Println("ResetPassword: password changed for userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusOK, transformers.AccountMessageResp{Success: true, Message: "Password changed"})
+}
+
+// SendEmailVerification handles POST /me/verifications/email
+func (h *AccountHandler) SendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// The address on file, which may be newer than the token's.
+	user, err := h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: claims.UserID})
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	if user.GetEmail() == "" {
+		common.RespondWithError(w, http.StatusConflict, "No email address to verify")
+		return
+	}
+	verified, err := h.emailVerified(ctx, claims.UserID)
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	if verified {
+		common.RespondWithJSON(w, http.StatusOK, transformers.AccountMessageResp{Success: true, Message: "Email already verified"})
+		return
+	}
+
+	token, _, err := h.Tokens.Issue(tokens.PurposeEmailVerification, claims.UserID, user.GetEmail(), emailVerificationTTL, time.Now())
+	if err != nil {
+		log.Println("SendEmailVerification: issuing token:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	err = h.Notifier.Send(ctx, notify.Message{
+		To:      user.GetEmail(),
+		Subject: "Verify your Nova email",
+		Body: fmt.Sprintf("To confirm this is your email address, open:\n\n%s\n\nThe link expires in %d hours.",
+			h.link("/verify-email", token), int(emailVerificationTTL.Hours())),
+	})
+	if err != nil {
+		log.Println("SendEmailVerification: sending email:", err)
+		common.RespondWithError(w, http.StatusServiceUnavailable, "Could not send the verification email")
+		return
+	}
+	log.Println("SendEmailVerification: link sent to userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusAccepted, transformers.AccountMessageResp{Success: true, Message: "Verification link sent"})
+}
+
+// ConfirmEmail handles POST /verifications/email/confirm
+func (h *AccountHandler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var reqBody ConfirmEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if reqBody.Token == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing 'token'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	claims, err := h.Tokens.Check(reqBody.Token, tokens.PurposeEmailVerification, now)
+	if err != nil {
+		respondTokenError(w, err)
+		return
+	}
+	// A link sent to an address the user has since replaced proves nothing.
+	user, err := h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: claims.UserID})
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	if !strings.EqualFold(user.GetEmail(), claims.Email) {
+		common.RespondWithErrorCode(w, http.StatusConflict, "token_email_changed", "The email address changed since the link was sent")
+		return
+	}
+	if err := h.Tokens.Redeem(ctx, claims, now); err != nil {
+		respondTokenError(w, err)
+		return
+	}
+
+	grpcResp, err := h.UserProductClient.Client.UpdateVerificationByUserId(ctx, &pb.UpdateVerificationByUserIdRequest{
+		UserId: claims.UserID,
+		Type:   verificationEmail,
+		Status: verificationComplete,
+	})
+	if err != nil {
+		h.releaseToken(claims)
+	}
+	event := audit.Event{
+		Time:    now,
+		Actor:   claims.UserID,
+		Action:  "verification.update",
+		Target:  claims.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"type": verificationEmail, "status": verificationComplete, "via": "email_link"},
+	}
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondGrpcError(w, err)
+		return
+	}
+	audit.Record(ctx, h.Audit, event)
+	log.Println("ConfirmEmail: email verified for userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusOK, transformers.UpdateVerificationRespJSON(grpcResp))
+}
+
+// Makes a redeemed token usable again after what it was redeemed for failed.
+// Runs past the request's deadline, which may be what failed it.
+func (h *AccountHandler) releaseToken(claims *tokens.Claims) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Tokens.Release(ctx, claims); err != nil {
+		log.Println("Releasing account token:", err)
+	}
+}
+
+// Whether the email verification of userID is complete.
+func (h *AccountHandler) emailVerified(ctx context.Context, userID string) (bool, error) {
+	resp, err := h.UserProductClient.Client.GetVerificationsByUserId(ctx, &pb.GetVerificationsByUserIdRequest{UserId: userID})
+	if err != nil {
+		return false, err
+	}
+	for _, v := range resp.GetVerifications() {
+		if strings.EqualFold(v.GetType(), verificationEmail) && strings.EqualFold(v.GetStatus(), verificationComplete) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Link to path of the web app carrying token.
+func (h *AccountHandler) link(path, token string) string {
+	return h.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func respondTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, tokens.ErrInvalid):
+		common.RespondWithErrorCode(w, http.StatusBadRequest, "token_invalid", "Invalid link")
+	case errors.Is(err, tokens.ErrExpired):
+		common.RespondWithErrorCode(w, http.StatusGone, "token_expired", "The link expired; ask for a new one")
+	case errors.Is(err, tokens.ErrUsed):
+		common.RespondWithErrorCode(w, http.StatusGone, "token_used", "The link was already used")
+	default:
+		log.Println("Account token error:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// Movements shown with a user to support staff.
+const adminRecentMovements = 20
+
+// LockAccountReq is the body of POST /admin/users/{user_id}/lock.
+type LockAccountReq struct {
+	Reason string `json:"reason"`
+}
+
+// Serves the support staff endpoints under /admin. The route table guards
+// each with the scopes it needs; every action is recorded in the audit trail.
+type AdminHandler struct {
+	UserProductClient *clients.UserProductServiceClient
+	Transfers         *TransactionHandler
+	// Sends password reset links and looks users up by email.
+	Accounts *AccountHandler
+	Locks    locks.Store
+	Audit    audit.Sink
+}
+
+func NewAdminHandler(userClient *clients.UserProductServiceClient, transfers *TransactionHandler, accounts *AccountHandler, locks locks.Store, auditSink audit.Sink) *AdminHandler {
+	return &AdminHandler{
+		UserProductClient: userClient,
+		Transfers:         transfers,
+		Accounts:          accounts,
+		Locks:             locks,
+		Audit:             auditSink,
+	}
+}
+
+// FindUser handles GET /admin/users?username=|email=|phone=
+func (h *AdminHandler) FindUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	var by, value string
+	for _, key := range []string{"username", "email", "phone"} {
+		if v := strings.TrimSpace(query.Get(key)); v != "" {
+			if by != "" {
+				common.RespondWithError(w, http.StatusBadRequest, "Give only one of 'username', 'email' or 'phone'")
+				return
+			}
+			by, value = key, v
+		}
+	}
+	if by == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing 'username', 'email' or 'phone'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	event := audit.Event{
+		Time:    time.Now(),
+		Actor:   claims.UserID,
+		Action:  "admin.user.find",
+		Details: map[string]string{"by": by, "query": value},
+	}
+	userID, err := h.findUserID(ctx, by, value)
+	var user *pb.GetUserByIdResponse
+	if err == nil {
+		user, err = h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: userID})
+	}
+	var lock *locks.Lock
+	if err == nil {
+		lock, err = h.lockOf(ctx, userID)
+	}
+	event.Target = userID
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondGrpcError(w, err)
+		return
+	}
+	event.Outcome = audit.OutcomeSuccess
+	audit.Record(ctx, h.Audit, event)
+
+	common.RespondWithJSON(w, http.StatusOK, transformers.AdminUserRespJSON(transformers.AdminUserJSON(userID, user, lock)))
+}
+
+// Id of the user whose by (username, email or phone) is value.
+func (h *AdminHandler) findUserID(ctx context.Context, by, value string) (string, error) {
+	switch by {
+	case "username":
+		resp, err := h.UserProductClient.Client.GetUserByUsername(ctx, &pb.GetUserByUsernameRequest{Username: value})
+		if err != nil {
+			return "", err
+		}
+		return resp.GetUserId(), nil
+	case "email":
+		return h.Accounts.Passwords.UserIDByEmail(ctx, value)
+	}
+	return "", status.Error(codes.Unimplemented, "looking users up by phone is not supported by the user service yet")
+}
+
+// GetUser handles GET /admin/users/{user_id}
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	userID := mux.Vars(r)["user_id"]
+	if userID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	formatter, _, err := h.Transfers.responseFormatter(r.Context(), r)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	event := audit.Event{
+		Time:   time.Now(),
+		Actor:  claims.UserID,
+		Action: "admin.user.view",
+		Target: userID,
+	}
+	fail := func(err error) {
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondGrpcError(w, err)
+	}
+
+	user, err := h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: userID})
+	if err != nil {
+		fail(err)
+		return
+	}
+	lock, err := h.lockOf(ctx, userID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	verifications, err := h.UserProductClient.Client.GetVerificationsByUserId(ctx, &pb.GetVerificationsByUserIdRequest{UserId: userID})
+	if err != nil {
+		fail(err)
+		return
+	}
+	pockets, err := h.UserProductClient.Client.GetPocketsByUserId(ctx, &pb.GetPocketsByUserIdRequest{UserId: userID})
+	if err != nil {
+		fail(err)
+		return
+	}
+	q := &movementQuery{UserId: userID, To: uint64(time.Now().Unix()), PageSize: adminRecentMovements}
+	movements, _, _, err := h.Transfers.scanMovements(r.Context(), q, nil, "")
+	if err != nil {
+		fail(err)
+		return
+	}
+	event.Outcome = audit.OutcomeSuccess
+	audit.Record(ctx, h.Audit, event)
+
+	common.RespondWithJSON(w, http.StatusOK, transformers.AdminUserDetailRespJSON(
+		transformers.AdminUserJSON(userID, user, lock), verifications, pockets, movements, formatter))
+}
+
+// LockAccount handles POST /admin/users/{user_id}/lock
+func (h *AdminHandler) LockAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	userID := mux.Vars(r)["user_id"]
+	if userID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	var reqBody LockAccountReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	reason := strings.TrimSpace(reqBody.Reason)
+	if reason == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing 'reason'")
+		return
+	}
+	if userID == claims.UserID {
+		common.RespondWithError(w, http.StatusBadRequest, "You can't lock your own account")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	event := audit.Event{
+		Time:    now,
+		Actor:   claims.UserID,
+		Action:  "admin.user.lock",
+		Target:  userID,
+		Details: map[string]string{"reason": reason},
+	}
+	if _, err := h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: userID}); err != nil {
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondGrpcError(w, err)
+		return
+	}
+	lock := &locks.Lock{UserID: userID, Reason: reason, LockedBy: claims.UserID, LockedAt: now}
+	locked, err := h.Locks.Lock(ctx, lock)
+	if err != nil {
+		log.Println("LockAccount: locking:", err)
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !locked {
+		event.Outcome = audit.OutcomeFailure
+		event.Details["error"] = "already locked"
+		audit.Record(ctx, h.Audit, event)
+		common.RespondWithErrorCode(w, http.StatusConflict, "account_already_locked", "The account is already locked")
+		return
+	}
+	event.Outcome = audit.OutcomeSuccess
+	audit.Record(ctx, h.Audit, event)
+	log.Println("LockAccount: userId:", userID, "locked by:", claims.UserID)
+
+	common.RespondWithJSON(w, http.StatusOK, transformers.AccountLockRespJSON(userID, lock, "Account locked"))
+}
+
+// UnlockAccount handles DELETE /admin/users/{user_id}/lock
+func (h *AdminHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	userID := mux.Vars(r)["user_id"]
+	if userID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	event := audit.Event{
+		Time:   time.Now(),
+		Actor:  claims.UserID,
+		Action: "admin.user.unlock",
+		Target: userID,
+	}
+	unlocked, err := h.Locks.Unlock(ctx, userID)
+	if err != nil {
+		log.Println("UnlockAccount: unlocking:", err)
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !unlocked {
+		event.Outcome = audit.OutcomeFailure
+		event.Details = map[string]string{"error": "not locked"}
+		audit.Record(ctx, h.Audit, event)
+		common.RespondWithError(w, http.StatusNotFound, "The account is not locked")
+		return
+	}
+	event.Outcome = audit.OutcomeSuccess
+	audit.Record(ctx, h.Audit, event)
+	log.Println("UnlockAccount: userId:", userID, "unlocked by:", claims.UserID)
+
+	common.RespondWithJSON(w, http.StatusOK, transformers.AccountLockRespJSON(userID, nil, "Account unlocked"))
+}
+
+// SendPasswordReset handles POST /admin/users/{user_id}/password-reset
+func (h *AdminHandler) SendPasswordReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	userID := mux.Vars(r)["user_id"]
+	if userID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	event := audit.Event{
+		Time:   time.Now(),
+		Actor:  claims.UserID,
+		Action: "admin.password_reset",
+		Target: userID,
+	}
+	user, err := h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: userID})
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		common.RespondGrpcError(w, err)
+		return
+	}
+	if user.GetEmail() == "" {
+		event.Outcome = audit.OutcomeFailure
+		event.Details = map[string]string{"error": "no email"}
+		audit.Record(ctx, h.Audit, event)
+		common.RespondWithError(w, http.StatusConflict, "The user has no email address to send the link to")
+		return
+	}
+	// The link goes to the user, never to the staff member asking for it.
+	if err := h.Accounts.sendPasswordReset(ctx, userID, user.GetEmail()); err != nil {
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
+		respondPasswordResetError(w, "SendPasswordReset", err)
+		return
+	}
+	event.Outcome = audit.OutcomeSuccess
+	audit.Record(ctx, h.Audit, event)
+	log.Println("SendPasswordReset: reset link sent to userId:", userID, "by:", claims.UserID)
+
+	common.RespondWithJSON(w, http.StatusAccepted, transformers.AccountMessageResp{Success: true, Message: "Reset link sent to the user's email"})
+}
+
+// The lock on userID, or nil when it isn't locked.
+func (h *AdminHandler) lockOf(ctx context.Context, userID string) (*locks.Lock, error) {
+	lock, err := h.Locks.Get(ctx, userID)
+	if errors.Is(err, locks.ErrNotFound) {
+		return nil, nil
+	}
+	return lock, err
+}
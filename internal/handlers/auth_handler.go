@@ -3,31 +3,50 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
 
 	// Import from common-protos
 	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/auth_service"
 )
 
+// LoginReq is the body of POST /login.
+type LoginReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
 type AuthHandler struct {
 	AuthClient *clients.AuthServiceClient
+	StepUp     *stepup.Service
+	TwoFactor  *twofactor.Service
+	// Accounts locked by support can't log in; nil disables the check.
+	Locks locks.Store
+	Audit audit.Sink
+	// Tracks the sessions logins open; nil disables tracking.
+	Sessions sessions.Store
 }
 
-func NewAuthHandler(AuthClient *clients.AuthServiceClient) *AuthHandler {
-	return &AuthHandler{AuthClient: AuthClient}
+func NewAuthHandler(AuthClient *clients.AuthServiceClient, stepUp *stepup.Service, twoFactor *twofactor.Service, locks locks.Store, auditSink audit.Sink, sessionStore sessions.Store) *AuthHandler {
+	return &AuthHandler{AuthClient: AuthClient, StepUp: stepUp, TwoFactor: twoFactor, Locks: locks, Audit: auditSink, Sessions: sessionStore}
 }
 
 // Login
 func (h *AuthHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
-	var reqBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+	var reqBody LoginReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -43,13 +62,123 @@ func (h *AuthHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
 	grpcResp, err := h.AuthClient.Client.LoginUser(ctx, &pb.LoginRequest{Email: reqBody.Email, Password: reqBody.Password})
 	if err != nil {
 		defer cancel()
+		h.auditLogin(ctx, "", audit.OutcomeFailure, map[string]string{"email": reqBody.Email, "error": err.Error()})
 		common.RespondGrpcError(w, err)
 		return
 	}
 
 	httpResp := transformers.LoginRespJSON(grpcResp)
+	if !httpResp.Success {
+		h.auditLogin(ctx, "", audit.OutcomeFailure, map[string]string{"email": reqBody.Email, "error": httpResp.Message})
+	}
+
+	if (h.Locks != nil || h.TwoFactor != nil) && httpResp.Success && httpResp.Data != "" {
+		claims, err := middleware.ParseToken(httpResp.Data)
+		if err != nil {
+			defer cancel()
+			log.Println("PostLogin: auth service returned an unreadable token:", err)
+			common.RespondWithError(w, http.StatusBadGateway, "Invalid token from auth service")
+			return
+		}
+		if h.Locks != nil {
+			locked, err := locks.IsLocked(ctx, h.Locks, claims.UserID)
+			if err != nil {
+				defer cancel()
+				log.Println("PostLogin: checking account lock:", err)
+				common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			if locked {
+				defer cancel()
+				h.auditLogin(ctx, claims.UserID, audit.OutcomeDenied, map[string]string{"email": reqBody.Email, "error": "account locked"})
+				common.RespondWithErrorCode(w, http.StatusForbidden, "account_locked", "The account is locked; contact support")
+				return
+			}
+		}
+
+		// With two-factor authentication on, the token is held back until /login/2fa.
+		if h.TwoFactor != nil {
+			enabled, err := h.TwoFactor.Enabled(ctx, claims.UserID)
+			if err != nil {
+				defer cancel()
+				log.Println("PostLogin: checking two-factor enrollment:", err)
+				common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			if enabled {
+				h.auditLogin(ctx, claims.UserID, audit.OutcomeSuccess, map[string]string{"email": reqBody.Email, "second_factor": "pending"})
+				id, expiresAt := h.TwoFactor.StartLogin(claims.UserID, httpResp.Data, time.Now())
+				common.RespondWithJSON(w, http.StatusOK, transformers.LoginTwoFactorRespJSON(id, expiresAt))
+				defer cancel()
+				return
+			}
+		}
+	}
+
+	if httpResp.Success {
+		h.auditLogin(ctx, tokenUserID(httpResp.Data), audit.OutcomeSuccess, map[string]string{"email": reqBody.Email})
+		if !h.startSession(r, httpResp.Data) {
+			defer cancel()
+			common.RespondWithError(w, http.StatusServiceUnavailable, "Could not start the session")
+			return
+		}
+	}
+	setAccessTokenCookie(w, httpResp.Data)
+	common.RespondWithJSON(w, http.StatusOK, httpResp)
+	defer cancel()
+}
+
+// Records a login attempt; userID is empty when it is not known.
+func (h *AuthHandler) auditLogin(ctx context.Context, userID, outcome string, details map[string]string) {
+	audit.Record(ctx, h.Audit, audit.Event{
+		Actor:   userID,
+		Action:  "auth.login",
+		Target:  userID,
+		Outcome: outcome,
+		Details: details,
+	})
+}
+
+// User id in an access token, or "" when it can't be read.
+func tokenUserID(token string) string {
+	claims, err := middleware.ParseToken(token)
+	if err != nil {
+		return ""
+	}
+	return claims.UserID
+}
 
-	token := httpResp["data"].(string)
+// Tracks the session a new access token opens. False when it couldn't be
+// recorded: the token would be refused, so the login has to fail.
+func (h *AuthHandler) startSession(r *http.Request, token string) bool {
+	if h.Sessions == nil {
+		return true
+	}
+	claims, err := middleware.ParseToken(token)
+	if err != nil {
+		log.Println("startSession: unreadable token:", err)
+		return false
+	}
+	now := time.Now()
+	err = h.Sessions.Create(r.Context(), &sessions.Session{
+		ID:         uuid.NewString(),
+		UserID:     claims.UserID,
+		TokenHash:  sessions.HashToken(token),
+		Device:     sessions.DeviceLabel(r.UserAgent()),
+		IP:         audit.OriginFrom(r.Context()).IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  time.Unix(claims.Exp, 0),
+	})
+	if err != nil {
+		log.Println("startSession: recording session:", err)
+		return false
+	}
+	return true
+}
+
+// Sets the accessToken cookie read by the auth middleware.
+func setAccessTokenCookie(w http.ResponseWriter, token string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "accessToken",
 		Value:    token,
@@ -59,14 +188,44 @@ func (h *AuthHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteNoneMode,
 		MaxAge:   900, // 15 minutes in seconds to match exp claim
 	})
-
-	common.RespondWithJSON(w, http.StatusOK, httpResp)
-	defer cancel()
 }
 
 // Logout
 func (h *AuthHandler) PostLogout(w http.ResponseWriter, r *http.Request) {
 	httpResp := transformers.LogOutRespJSON()
+	// Logout is public: the user is known only if their token is still valid.
+	userID := ""
+	if token := requestToken(r); token != "" {
+		userID = tokenUserID(token)
+		h.endSession(r.Context(), token)
+	}
+	audit.Record(r.Context(), h.Audit, audit.Event{
+		Actor:   userID,
+		Action:  "auth.logout",
+		Target:  userID,
+		Outcome: audit.OutcomeSuccess,
+	})
+	clearAccessTokenCookie(w)
+
+	common.RespondWithJSON(w, http.StatusOK, httpResp)
+}
+
+// Revokes the session token belongs to, if it is tracked.
+func (h *AuthHandler) endSession(ctx context.Context, token string) {
+	if h.Sessions == nil || token == "" {
+		return
+	}
+	s, err := h.Sessions.ByToken(ctx, sessions.HashToken(token))
+	if err != nil {
+		return
+	}
+	if _, err := h.Sessions.Revoke(ctx, s.UserID, s.ID, time.Now()); err != nil {
+		log.Println("PostLogout: revoking session:", err)
+	}
+}
+
+// Removes the accessToken cookie.
+func clearAccessTokenCookie(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "accessToken",
 		Value:    "",
@@ -76,6 +235,4 @@ func (h *AuthHandler) PostLogout(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteNoneMode,
 		MaxAge:   -1,
 	})
-
-	common.RespondWithJSON(w, http.StatusOK, httpResp)
 }
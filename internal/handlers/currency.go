@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+// Header naming the currency amounts should also be shown in; the currency
+// query parameter takes precedence.
+const displayCurrencyHeader = "X-Currency"
+
+// Formatter for a response, converting into the display currency the client
+// asked for. The rate is nil when no conversion applies.
+func (h *TransactionHandler) responseFormatter(ctx context.Context, r *http.Request) (money.Formatter, *fx.Rate, error) {
+	f := money.NewFormatter(h.Currency, r)
+
+	code := r.URL.Query().Get("currency")
+	if code == "" {
+		code = r.Header.Get(displayCurrencyHeader)
+	}
+	if code == "" || strings.EqualFold(code, h.Currency.Code) {
+		return f, nil, nil
+	}
+
+	target, err := money.LookupCurrency(code)
+	if err != nil {
+		return f, nil, fmt.Errorf("Invalid 'currency': %v", err)
+	}
+	rate, err := h.Rates.Rate(ctx, h.Currency.Code, target.Code)
+	if err != nil {
+		return f, nil, rateError(err)
+	}
+	f.Conversion = &money.Conversion{To: target, Rate: rate.Value}
+	return f, &rate, nil
+}
+
+func rateError(err error) error {
+	if errors.Is(err, fx.ErrUnsupported) {
+		return fmt.Errorf("No exchange rate available: %w", err)
+	}
+	return err
+}
+
+// Answers a transferAmount error: rates unfit for transfers are the
+// gateway's problem, anything else the request's.
+func respondTransferAmountError(w http.ResponseWriter, err error) {
+	if errors.Is(err, fx.ErrNotTradable) {
+		log.Println("Refusing a cross-currency transfer:", err)
+		common.RespondWithErrorCode(w, http.StatusServiceUnavailable, "fx_rates_unavailable", "Transfers in another currency are unavailable: no current exchange rates")
+		return
+	}
+	common.RespondWithError(w, http.StatusBadRequest, err.Error())
+}
+
+// Amount of a transfer request in the account currency, with the rate used
+// when the request was in another currency. Rates older than MaxRateAge, or
+// the built-in ones, only serve for display.
+func (h *TransactionHandler) transferAmount(ctx context.Context, req TransferReq) (money.Decimal, *fx.Rate, error) {
+	if req.Currency == "" || strings.EqualFold(req.Currency, h.Currency.Code) {
+		return req.Amount, nil, nil
+	}
+
+	from, err := money.LookupCurrency(req.Currency)
+	if err != nil {
+		return money.Decimal{}, nil, fmt.Errorf("Invalid 'currency': %v", err)
+	}
+	if _, err := req.Amount.Units(from.Digits, math.MaxInt64); err != nil {
+		return money.Decimal{}, nil, fmt.Errorf("Invalid 'amount': %v", err)
+	}
+	rate, err := h.Rates.Rate(ctx, from.Code, h.Currency.Code)
+	if err != nil {
+		return money.Decimal{}, nil, rateError(err)
+	}
+	if err := rate.Tradable(time.Now(), h.MaxRateAge); err != nil {
+		return money.Decimal{}, nil, err
+	}
+
+	converted := req.Amount.Mul(rate.Value).Round(transferDecimals)
+	if converted.Sign() <= 0 {
+		return money.Decimal{}, nil, fmt.Errorf("Invalid 'amount': less than one %s after conversion", h.Currency.Code)
+	}
+	return converted, &rate, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+// Serves rate for every pair.
+type fixedRate fx.Rate
+
+func (f fixedRate) Rate(_ context.Context, from, to string) (fx.Rate, error) {
+	r := fx.Rate(f)
+	r.From, r.To = from, to
+	return r, nil
+}
+
+func TestTransferAmountNeedsCurrentRates(t *testing.T) {
+	cop, err := money.LookupCurrency("COP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	tests := []struct {
+		name string
+		rate fx.Rate
+		want error
+	}{
+		{"current", fx.Rate{Value: money.MustParse("4050"), AsOf: now.Add(-time.Hour)}, nil},
+		{"stale", fx.Rate{Value: money.MustParse("4050"), AsOf: now.Add(-48 * time.Hour)}, fx.ErrNotTradable},
+		{"built-in", fx.Rate{Value: money.MustParse("4050"), AsOf: now, Reference: true}, fx.ErrNotTradable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &TransactionHandler{Currency: cop, Rates: fixedRate(tt.rate), MaxRateAge: 24 * time.Hour}
+			got, _, err := h.transferAmount(context.Background(), TransferReq{Amount: money.MustParse("10"), Currency: "USD"})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && got.String() != "40500" {
+				t.Errorf("amount = %s, want 40500", got)
+			}
+			// The account currency needs no rate at all.
+			if _, _, err := h.transferAmount(context.Background(), TransferReq{Amount: money.MustParse("10"), Currency: "COP"}); err != nil {
+				t.Errorf("same currency: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/exports"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/statements"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	tb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// Starts exports of everything the gateway and the backends hold about a
+// user, and builds and serves their archives.
+type DataExportHandler struct {
+	Store             exports.Store
+	UserProductClient *clients.UserProductServiceClient
+	Transactions      *TransactionHandler
+	Sessions          sessions.Store
+	// Audit trail the user's events are copied from.
+	AuditLog audit.Source
+	StepUp   *stepup.Service
+	Audit    audit.Sink
+}
+
+func NewDataExportHandler(store exports.Store, userClient *clients.UserProductServiceClient, transactions *TransactionHandler, sessionStore sessions.Store, auditLog audit.Source, stepUp *stepup.Service, auditSink audit.Sink) *DataExportHandler {
+	return &DataExportHandler{
+		Store:             store,
+		UserProductClient: userClient,
+		Transactions:      transactions,
+		Sessions:          sessionStore,
+		AuditLog:          auditLog,
+		StepUp:            stepUp,
+		Audit:             auditSink,
+	}
+}
+
+// RequestExport handles GET /me/export. It starts an export, or returns the
+// one in progress; the archive is built in the background.
+func (h *DataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if h.respondActiveExport(ctx, w, claims.UserID) {
+		return
+	}
+	if !requireStepUp(w, r, h.StepUp, stepup.ActionExportData, stepup.Operation(claims.UserID)) {
+		return
+	}
+
+	now := time.Now()
+	job := &exports.Job{ID: uuid.New().String(), UserID: claims.UserID, Status: exports.StatusPending, CreatedAt: now, UpdatedAt: now}
+	created, err := h.Store.Create(ctx, job)
+	if err != nil {
+		log.Println("RequestExport: creating export job:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	// Another request started one in the meantime.
+	if !created {
+		if !h.respondActiveExport(ctx, w, claims.UserID) {
+			common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	audit.Record(ctx, h.Audit, audit.Event{
+		Actor:   claims.UserID,
+		Action:  "data.export.request",
+		Target:  claims.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"export_id": job.ID},
+	})
+	log.Println("RequestExport: export", job.ID, "started for userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusAccepted, transformers.ExportJobRespJSON(job, "Data export started"))
+}
+
+// Responds with the user's export in progress, if there is one.
+func (h *DataExportHandler) respondActiveExport(ctx context.Context, w http.ResponseWriter, userID string) bool {
+	job, err := h.Store.Active(ctx, userID)
+	if errors.Is(err, exports.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Println("RequestExport: reading export job:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return true
+	}
+	common.RespondWithJSON(w, http.StatusAccepted, transformers.ExportJobRespJSON(job, "Data export in progress"))
+	return true
+}
+
+// GetExport handles GET /me/export/{export_id}
+func (h *DataExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	job, ok := h.exportFromRequest(w, r)
+	if !ok {
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.ExportJobRespJSON(job, "Data export retrieved"))
+}
+
+// DownloadExport handles GET /me/export/{export_id}/download
+func (h *DataExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	job, ok := h.exportFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if job.Status != exports.StatusReady {
+		common.RespondWithErrorCode(w, http.StatusConflict, "export_not_ready", "The export is "+job.Status)
+		return
+	}
+	f, err := os.Open(job.File)
+	if errors.Is(err, os.ErrNotExist) {
+		common.RespondWithError(w, http.StatusNotFound, "Export not found")
+		return
+	}
+	if err != nil {
+		log.Println("DownloadExport: opening archive:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer f.Close()
+
+	audit.Record(r.Context(), h.Audit, audit.Event{
+		Actor:   job.UserID,
+		Action:  "data.export.download",
+		Target:  job.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"export_id": job.ID},
+	})
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		log.Println("DownloadExport: could not extend write deadline:", err)
+	}
+	filename := fmt.Sprintf("nova-data-%s.zip", job.CreatedAt.UTC().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	http.ServeContent(w, r, filename, job.UpdatedAt, f)
+}
+
+// The caller's export named in the path; responds with an error when there
+// is none.
+func (h *DataExportHandler) exportFromRequest(w http.ResponseWriter, r *http.Request) (*exports.Job, bool) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	exportID := mux.Vars(r)["export_id"]
+	if exportID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing export_id")
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.Store.Get(ctx, claims.UserID, exportID)
+	if err != nil && !errors.Is(err, exports.ErrNotFound) {
+		log.Println("Reading export job:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return nil, false
+	}
+	// Expired jobs are gone even if the runner hasn't removed them yet.
+	if err != nil || job.Expired(time.Now()) {
+		common.RespondWithError(w, http.StatusNotFound, "Export not found")
+		return nil, false
+	}
+	return job, true
+}
+
+// Build writes the archive of an export: the profile, favorites, pockets and
+// verifications from the user service, the full movement history, the active
+// sessions and the user's audit events.
+func (h *DataExportHandler) Build(ctx context.Context, job *exports.Job, zw *zip.Writer) error {
+	userID := job.UserID
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	user, err := h.UserProductClient.Client.GetUserById(callCtx, &pb.GetUserByIdRequest{UserId: userID})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("reading profile: %w", err)
+	}
+	if err := writeJSONFile(zw, "profile.json", transformers.GetUserRespJSON(user)); err != nil {
+		return err
+	}
+
+	callCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	favorites, err := h.UserProductClient.Client.GetFavoritesByUserId(callCtx, &pb.GetFavoritesByUserIdRequest{UserId: userID})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("reading favorites: %w", err)
+	}
+	if err := writeJSONFile(zw, "favorites.json", transformers.GetFavoritesRespJSON(favorites).Favorites); err != nil {
+		return err
+	}
+
+	callCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	pockets, err := h.UserProductClient.Client.GetPocketsByUserId(callCtx, &pb.GetPocketsByUserIdRequest{UserId: userID})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("reading pockets: %w", err)
+	}
+	if err := writeJSONFile(zw, "pockets.json", transformers.GetPocketsRespJSON(pockets).Pockets); err != nil {
+		return err
+	}
+
+	callCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	verifications, err := h.UserProductClient.Client.GetVerificationsByUserId(callCtx, &pb.GetVerificationsByUserIdRequest{UserId: userID})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("reading verifications: %w", err)
+	}
+	if err := writeJSONFile(zw, "verifications.json", transformers.GetVerificationsRespJSON(verifications).Verifications); err != nil {
+		return err
+	}
+
+	if err := h.writeMovements(ctx, zw, userID, user.GetUsername()); err != nil {
+		return fmt.Errorf("writing movements: %w", err)
+	}
+
+	active, err := h.Sessions.List(ctx, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("listing sessions: %w", err)
+	}
+	if err := writeJSONFile(zw, "sessions.json", transformers.SessionsRespJSON(active, "").Sessions); err != nil {
+		return err
+	}
+
+	if err := h.writeAuditEvents(ctx, zw, userID); err != nil {
+		return fmt.Errorf("writing audit events: %w", err)
+	}
+	return nil
+}
+
+// Writes movements.csv, newest first, in the format of statement exports.
+func (h *DataExportHandler) writeMovements(ctx context.Context, zw *zip.Writer, userID, username string) error {
+	f, err := createZipFile(zw, "movements.csv")
+	if err != nil {
+		return err
+	}
+	writer, _, err := statements.NewWriter(statements.FormatCSV, f)
+	if err != nil {
+		return err
+	}
+	st := &statements.Statement{UserID: userID, Username: username, Currency: h.Transactions.Currency}
+	if err := writer.Begin(st); err != nil {
+		return err
+	}
+	err = h.Transactions.eachMovementBatch(ctx, userID, func(batch []*tb.Movement) error {
+		for _, m := range batch {
+			if err := writer.Write(statementEntry(m, username, h.Transactions.Currency)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.End(st)
+}
+
+// Writes audit_events.json with the events the user took part in, as actor
+// or target, oldest first.
+func (h *DataExportHandler) writeAuditEvents(ctx context.Context, zw *zip.Writer, userID string) error {
+	f, err := createZipFile(zw, "audit_events.json")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "["); err != nil {
+		return err
+	}
+	first := true
+	err = h.AuditLog.Events(ctx, func(e audit.Event) error {
+		if e.Actor != userID && e.Target != userID {
+			return nil
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if !first {
+			data = append([]byte(",\n"), data...)
+		}
+		first = false
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, "]\n")
+	return err
+}
+
+func createZipFile(zw *zip.Writer, name string) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+}
+
+func writeJSONFile(zw *zip.Writer, name string, v any) error {
+	f, err := createZipFile(zw, name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/exports"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+)
+
+// Calls handle for exportID as userID.
+func serveExport(handle http.HandlerFunc, userID, exportID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me/export/"+exportID, nil)
+	req = mux.SetURLVars(req, map[string]string{"export_id": exportID})
+	req = req.WithContext(context.WithValue(req.Context(), "tokenClaims", &middleware.TokenClaims{UserID: userID}))
+	rec := httptest.NewRecorder()
+	handle(rec, req)
+	return rec
+}
+
+func TestExportAccess(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := exports.OpenSQLite(filepath.Join(dir, "exports.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now().Truncate(time.Second)
+	archive := filepath.Join(dir, "e1.zip")
+	if err := os.WriteFile(archive, []byte("PK archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, j := range []*exports.Job{
+		{ID: "e1", UserID: "u1", Status: exports.StatusReady, File: archive, ExpiresAt: now.Add(time.Hour)},
+		// Past its retention; the runner hasn't removed it yet.
+		{ID: "expired", UserID: "u2", Status: exports.StatusReady, File: archive, ExpiresAt: now.Add(-time.Second)},
+		{ID: "running", UserID: "u3", Status: exports.StatusRunning},
+	} {
+		j.CreatedAt, j.UpdatedAt = now.Add(-time.Hour), now.Add(-time.Hour)
+		if _, err := store.Create(ctx, j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &DataExportHandler{Store: store}
+	tests := []struct {
+		name     string
+		handle   http.HandlerFunc
+		userID   string
+		exportID string
+		want     int
+		body     string
+	}{
+		{"owner", h.GetExport, "u1", "e1", http.StatusOK, ""},
+		{"owner downloads", h.DownloadExport, "u1", "e1", http.StatusOK, "PK archive"},
+		{"another user", h.GetExport, "u2", "e1", http.StatusNotFound, ""},
+		{"another user downloads", h.DownloadExport, "u2", "e1", http.StatusNotFound, ""},
+		{"expired", h.GetExport, "u2", "expired", http.StatusNotFound, ""},
+		{"expired download", h.DownloadExport, "u2", "expired", http.StatusNotFound, ""},
+		{"not ready", h.DownloadExport, "u3", "running", http.StatusConflict, ""},
+		{"unknown", h.GetExport, "u1", "missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveExport(tt.handle, tt.userID, tt.exportID)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+// Serves events from a slice.
+type eventList []audit.Event
+
+func (l eventList) Events(_ context.Context, fn func(audit.Event) error) error {
+	for _, e := range l {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportIncludesOwnAuditEvents(t *testing.T) {
+	t0 := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	events := eventList{
+		{Time: t0, Actor: "u1", Action: "auth.login", Target: "u1", Outcome: audit.OutcomeSuccess},
+		{Time: t0.Add(time.Minute), Actor: "u2", Action: "auth.login", Target: "u2", Outcome: audit.OutcomeSuccess},
+		{Time: t0.Add(2 * time.Minute), Actor: "admin1", Action: "admin.lock", Target: "u1", Outcome: audit.OutcomeSuccess},
+		{Time: t0.Add(3 * time.Minute), Actor: "u1", Action: "transfer.create", Target: "u1", Outcome: audit.OutcomeDenied,
+			Details: map[string]string{"to_user": "u2"}},
+		{Time: t0.Add(4 * time.Minute), Actor: "admin1", Action: "admin.lock", Target: "u2", Outcome: audit.OutcomeSuccess},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	h := &DataExportHandler{AuditLog: events}
+	if err := h.writeAuditEvents(context.Background(), zw, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open("audit_events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []audit.Event
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("audit_events.json: %v\n%s", err, data)
+	}
+	// Events the user acted in or was the target of, oldest first.
+	want := []audit.Event{events[0], events[2], events[3]}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(got), len(want), data)
+	}
+	for i := range want {
+		if !got[i].Time.Equal(want[i].Time) || got[i].Actor != want[i].Actor || got[i].Action != want[i].Action || got[i].Target != want[i].Target {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if got[2].Details["to_user"] != "u2" {
+		t.Errorf("details = %v, want to_user u2", got[2].Details)
+	}
+
+	// Someone without events gets an empty list, not null.
+	buf.Reset()
+	zw = zip.NewWriter(&buf)
+	if err := h.writeAuditEvents(context.Background(), zw, "u9"); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	zr, _ = zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	f, _ = zr.Open("audit_events.json")
+	data, _ = io.ReadAll(f)
+	if string(bytes.TrimSpace(data)) != "[]" {
+		t.Errorf("no events: %s, want []", data)
+	}
+}
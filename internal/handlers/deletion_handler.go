@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/deletion"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/scheduler"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	tb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// Deletion operations the auth and transaction services have no RPCs for yet.
+type DeletionBackend interface {
+	// False while the services can't carry out deletions; none is scheduled
+	// then, since every one would fail at the end of its grace period.
+	Supported() bool
+	// Closes the user's account in the transaction service.
+	CloseAccount(ctx context.Context, userID string) error
+	// Deletes the user's credentials from the auth service.
+	DeleteCredentials(ctx context.Context, userID string) error
+}
+
+// A DeletionBackend answering every call with codes.Unimplemented, until the
+// auth and transaction services can delete what they hold about a user.
+// Deletions are refused up front while it is in use.
+type UnsupportedDeletion struct{}
+
+func (UnsupportedDeletion) Supported() bool { return false }
+
+func (UnsupportedDeletion) CloseAccount(context.Context, string) error {
+	return status.Error(codes.Unimplemented, "closing accounts is not supported by the transaction service yet")
+}
+
+func (UnsupportedDeletion) DeleteCredentials(context.Context, string) error {
+	return status.Error(codes.Unimplemented, "deleting users is not supported by the auth service yet")
+}
+
+// Schedules account deletions and provides the steps carrying them out.
+type DeletionHandler struct {
+	Store             deletion.Store
+	UserProductClient *clients.UserProductServiceClient
+	TransactionClient *clients.TransactionServiceClient
+	Backend           DeletionBackend
+	Locks             locks.Store
+	Sessions          sessions.Store
+	Schedules         scheduler.Store
+	Payments          payments.Store
+	StepUp            *stepup.Service
+	Audit             audit.Sink
+	// Time the user has to change their mind.
+	GracePeriod time.Duration
+}
+
+func NewDeletionHandler(store deletion.Store, userClient *clients.UserProductServiceClient, transactionClient *clients.TransactionServiceClient, backend DeletionBackend, locks locks.Store, sessionStore sessions.Store, schedules scheduler.Store, paymentRequests payments.Store, stepUp *stepup.Service, auditSink audit.Sink, gracePeriod time.Duration) *DeletionHandler {
+	return &DeletionHandler{
+		Store:             store,
+		UserProductClient: userClient,
+		TransactionClient: transactionClient,
+		Backend:           backend,
+		Locks:             locks,
+		Sessions:          sessionStore,
+		Schedules:         schedules,
+		Payments:          paymentRequests,
+		StepUp:            stepUp,
+		Audit:             auditSink,
+		GracePeriod:       gracePeriod,
+	}
+}
+
+// ScheduleDeletion handles POST /me/deletion. The account is deleted once the
+// grace period is over, unless the user cancels before.
+func (h *DeletionHandler) ScheduleDeletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	userID := claims.UserID
+
+	if !h.Backend.Supported() {
+		common.RespondWithErrorCode(w, http.StatusNotImplemented, "deletion_unsupported", "Account deletion is not available yet")
+		return
+	}
+
+	if !requireStepUp(w, r, h.StepUp, stepup.ActionDeleteUser, stepup.Operation("account", userID)) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.checkBalanceSettled(ctx, userID); err != nil {
+		var unsettled balanceNotSettledError
+		if errors.As(err, &unsettled) {
+			common.RespondWithErrorCode(w, http.StatusConflict, "balance_not_settled", "Withdraw or transfer your balance of "+unsettled.balance+" before deleting the account")
+			return
+		}
+		common.RespondGrpcError(w, err)
+		return
+	}
+
+	req := deletion.NewRequest(userID, h.Steps(), time.Now(), h.GracePeriod)
+	scheduled, err := h.Store.Schedule(ctx, req)
+	if err != nil {
+		log.Println("ScheduleDeletion: scheduling deletion:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !scheduled {
+		common.RespondWithErrorCode(w, http.StatusConflict, "deletion_pending", "The account is already being deleted")
+		return
+	}
+	audit.Record(ctx, h.Audit, audit.Event{
+		Actor:   claims.UserID,
+		Action:  "account.deletion.schedule",
+		Target:  userID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"due_at": req.DueAt.UTC().Format(time.RFC3339)},
+	})
+	log.Println("ScheduleDeletion: deletion of userId:", userID, "scheduled for", req.DueAt.UTC().Format(time.RFC3339))
+	common.RespondWithJSON(w, http.StatusAccepted, transformers.DeletionRespJSON(req, "Account deletion scheduled"))
+}
+
+// GetDeletion handles GET /me/deletion
+func (h *DeletionHandler) GetDeletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	req, err := h.Store.Get(ctx, claims.UserID)
+	if errors.Is(err, deletion.ErrNotFound) {
+		common.RespondWithError(w, http.StatusNotFound, "No account deletion was requested")
+		return
+	}
+	if err != nil {
+		log.Println("GetDeletion: reading deletion:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.DeletionRespJSON(req, "Account deletion retrieved"))
+}
+
+// CancelDeletion handles DELETE /me/deletion
+func (h *DeletionHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	canceled, err := h.Store.Cancel(ctx, claims.UserID, time.Now())
+	if err != nil {
+		log.Println("CancelDeletion: canceling deletion:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	req, err := h.Store.Get(ctx, claims.UserID)
+	if errors.Is(err, deletion.ErrNotFound) {
+		common.RespondWithError(w, http.StatusNotFound, "No account deletion was requested")
+		return
+	}
+	if err != nil {
+		log.Println("CancelDeletion: reading deletion:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !canceled {
+		common.RespondWithErrorCode(w, http.StatusConflict, "deletion_not_cancelable", "Only a scheduled deletion can be canceled; this one is "+req.Status)
+		return
+	}
+	audit.Record(ctx, h.Audit, audit.Event{
+		Actor:   claims.UserID,
+		Action:  "account.deletion.cancel",
+		Target:  claims.UserID,
+		Outcome: audit.OutcomeSuccess,
+	})
+	common.RespondWithJSON(w, http.StatusOK, transformers.DeletionRespJSON(req, "Account deletion canceled"))
+}
+
+type balanceNotSettledError struct{ balance string }
+
+func (e balanceNotSettledError) Error() string {
+	return "balance of " + e.balance + " is not settled"
+}
+
+// Nil when userID's balance is zero, a balanceNotSettledError when it isn't.
+func (h *DeletionHandler) checkBalanceSettled(ctx context.Context, userID string) error {
+	now := uint64(time.Now().Unix())
+	resp, err := h.TransactionClient.Client.Balance(ctx, &tb.GetBalanceRequest{UserId: userID, FromTime: now, ToTime: now})
+	if err != nil {
+		return err
+	}
+	balance, err := money.Parse(resp.GetCurrent())
+	if err != nil {
+		return fmt.Errorf("reading balance %q: %w", resp.GetCurrent(), err)
+	}
+	if !balance.IsZero() {
+		return balanceNotSettledError{balance: balance.String()}
+	}
+	return nil
+}
+
+// Steps deleting an account, in order. The account is locked first so nothing
+// moves money in or out while it is taken apart. Ended sessions aren't
+// restored when a deletion is undone: the user logs in again. The profile and
+// the credentials go last since they can't be restored; the favorites,
+// pockets and verifications deleted with the profile aren't readable to
+// bring them back.
+func (h *DeletionHandler) Steps() []deletion.Step {
+	return []deletion.Step{
+		{Name: "lock", Do: h.lockAccount, Undo: h.unlockAccount},
+		{Name: "sessions", Do: h.endSessions},
+		{Name: "check", Do: h.checkAccount},
+		{Name: "scheduled_transfers", Do: h.cancelSchedules, Undo: h.resumeSchedules},
+		{Name: "payment_requests", Do: h.cancelPaymentRequests, Undo: h.reopenPaymentRequests},
+		{Name: "transaction_account", Do: h.closeAccount, Undo: h.reopenAccount},
+		{Name: "profile", Do: h.deleteProfile, Irreversible: true},
+		{Name: "credentials", Do: h.deleteCredentials},
+	}
+}
+
+// An account support already locked stays locked when the deletion is undone.
+func (h *DeletionHandler) lockAccount(ctx context.Context, req *deletion.Request) error {
+	locked, err := h.Locks.Lock(ctx, &locks.Lock{UserID: req.UserID, Reason: "account deletion", LockedBy: req.UserID, LockedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	if locked {
+		req.Data["locked"] = "true"
+	}
+	return nil
+}
+
+func (h *DeletionHandler) endSessions(ctx context.Context, req *deletion.Request) error {
+	_, err := h.Sessions.RevokeAll(ctx, req.UserID, time.Now())
+	return err
+}
+
+func (h *DeletionHandler) unlockAccount(ctx context.Context, req *deletion.Request) error {
+	if req.Data["locked"] != "true" {
+		return nil
+	}
+	_, err := h.Locks.Unlock(ctx, req.UserID)
+	return err
+}
+
+// Checks the balance again, since money may have come in during the grace
+// period, and keeps the username to open a new transaction account if the
+// deletion is undone.
+func (h *DeletionHandler) checkAccount(ctx context.Context, req *deletion.Request) error {
+	if err := h.checkBalanceSettled(ctx, req.UserID); err != nil {
+		return err
+	}
+	user, err := h.UserProductClient.Client.GetUserById(ctx, &pb.GetUserByIdRequest{UserId: req.UserID})
+	if err != nil {
+		return fmt.Errorf("reading profile: %w", err)
+	}
+	req.Data["username"] = user.GetUsername()
+	return nil
+}
+
+// Cancels the user's active scheduled transfers, keeping their ids to resume
+// them.
+func (h *DeletionHandler) cancelSchedules(ctx context.Context, req *deletion.Request) error {
+	list, err := h.Schedules.ListSchedules(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	var canceled []string
+	for _, s := range list {
+		if s.Status != scheduler.StatusActive {
+			continue
+		}
+		if err := h.Schedules.CancelSchedule(ctx, req.UserID, s.ID); err != nil {
+			return err
+		}
+		canceled = append(canceled, s.ID)
+		req.Data["scheduled_transfers"] = strings.Join(canceled, ",")
+	}
+	return nil
+}
+
+func (h *DeletionHandler) resumeSchedules(ctx context.Context, req *deletion.Request) error {
+	for _, id := range splitIDs(req.Data["scheduled_transfers"]) {
+		if _, err := h.Schedules.ResumeSchedule(ctx, req.UserID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancels the pending payment requests the user made, shares of their splits
+// included, and those addressed to them, keeping their ids to reopen them.
+func (h *DeletionHandler) cancelPaymentRequests(ctx context.Context, req *deletion.Request) error {
+	outgoing, err := h.Payments.ListOutgoing(ctx, req.UserID, payments.StatusPending)
+	if err != nil {
+		return err
+	}
+	incoming, err := h.Payments.ListIncoming(ctx, req.UserID, payments.StatusPending)
+	if err != nil {
+		return err
+	}
+	var canceled []string
+	for _, p := range append(outgoing, incoming...) {
+		ok, err := h.Payments.Transition(ctx, p.ID, payments.StatusPending, payments.StatusCanceled, "", "account deleted", time.Now())
+		if err != nil {
+			return err
+		}
+		// Expired or accepted in the meantime: not ours to reopen.
+		if ok {
+			canceled = append(canceled, p.ID)
+			req.Data["payment_requests"] = strings.Join(canceled, ",")
+		}
+	}
+	return nil
+}
+
+// Reopens the canceled requests; those past their expiry then expire as usual.
+func (h *DeletionHandler) reopenPaymentRequests(ctx context.Context, req *deletion.Request) error {
+	for _, id := range splitIDs(req.Data["payment_requests"]) {
+		if _, err := h.Payments.Transition(ctx, id, payments.StatusCanceled, payments.StatusPending, "", "", time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ids joined with commas; none for "".
+func splitIDs(joined string) []string {
+	if joined == "" {
+		return nil
+	}
+	return strings.Split(joined, ",")
+}
+
+func (h *DeletionHandler) closeAccount(ctx context.Context, req *deletion.Request) error {
+	return h.Backend.CloseAccount(ctx, req.UserID)
+}
+
+// Opens a new, empty account; the closed one had no balance.
+func (h *DeletionHandler) reopenAccount(ctx context.Context, req *deletion.Request) error {
+	_, err := h.TransactionClient.Client.Account(ctx, &tb.CreateAccountRequest{UserId: req.UserID, Username: req.Data["username"], Bank: false})
+	return err
+}
+
+func (h *DeletionHandler) deleteProfile(ctx context.Context, req *deletion.Request) error {
+	_, err := h.UserProductClient.Client.DeleteUserById(ctx, &pb.DeleteUserByIdRequest{Id: req.UserID})
+	return err
+}
+
+func (h *DeletionHandler) deleteCredentials(ctx context.Context, req *deletion.Request) error {
+	return h.Backend.DeleteCredentials(ctx, req.UserID)
+}
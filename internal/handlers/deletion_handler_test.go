@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/deletion"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/scheduler"
+)
+
+func TestScheduleDeletionUnsupported(t *testing.T) {
+	h := &DeletionHandler{Backend: UnsupportedDeletion{}}
+	req := httptest.NewRequest(http.MethodPost, "/me/deletion", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "tokenClaims", &middleware.TokenClaims{UserID: "u1"}))
+	rec := httptest.NewRecorder()
+	h.ScheduleDeletion(rec, req)
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusNotImplemented, rec.Body.String())
+	}
+}
+
+func TestDeletionCancelsAndReopens(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	schedules, err := scheduler.OpenSQLite(filepath.Join(dir, "scheduler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer schedules.Close()
+	requests, err := payments.OpenSQLite(filepath.Join(dir, "payment_requests.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer requests.Close()
+
+	now := time.Now().Truncate(time.Second)
+	for _, s := range []*scheduler.Schedule{
+		{ID: "active", UserID: "u1", Status: scheduler.StatusActive},
+		{ID: "completed", UserID: "u1", Status: scheduler.StatusCompleted},
+		{ID: "other user", UserID: "u2", Status: scheduler.StatusActive},
+	} {
+		s.ToUserID, s.Amount, s.Currency, s.Frequency, s.TimeZone = "u3", "10", "USD", scheduler.FrequencyDaily, "UTC"
+		s.StartAt, s.NextRunAt, s.CreatedAt = now, now.Add(time.Hour), now
+		if err := schedules.CreateSchedule(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []*payments.Request{
+		{ID: "outgoing", RequesterID: "u1", PayerID: "u2", Status: payments.StatusPending},
+		{ID: "incoming", RequesterID: "u2", PayerID: "u1", Status: payments.StatusPending},
+		{ID: "paid", RequesterID: "u1", PayerID: "u2", Status: payments.StatusPaid},
+		{ID: "between others", RequesterID: "u2", PayerID: "u3", Status: payments.StatusPending},
+	} {
+		r.Amount, r.Currency = "10", "USD"
+		r.CreatedAt, r.UpdatedAt, r.ExpiresAt = now, now, now.Add(time.Hour)
+		if err := requests.Create(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &DeletionHandler{Schedules: schedules, Payments: requests}
+	req := &deletion.Request{UserID: "u1", Data: map[string]string{}}
+	if err := h.cancelSchedules(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.cancelPaymentRequests(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	checkSchedules(t, schedules, map[string]string{
+		"active": scheduler.StatusCanceled, "completed": scheduler.StatusCompleted, "other user": scheduler.StatusActive,
+	})
+	checkRequests(t, requests, map[string]string{
+		"outgoing": payments.StatusCanceled, "incoming": payments.StatusCanceled,
+		"paid": payments.StatusPaid, "between others": payments.StatusPending,
+	})
+
+	if err := h.reopenPaymentRequests(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.resumeSchedules(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	checkSchedules(t, schedules, map[string]string{
+		"active": scheduler.StatusActive, "completed": scheduler.StatusCompleted, "other user": scheduler.StatusActive,
+	})
+	checkRequests(t, requests, map[string]string{
+		"outgoing": payments.StatusPending, "incoming": payments.StatusPending,
+		"paid": payments.StatusPaid, "between others": payments.StatusPending,
+	})
+}
+
+func checkSchedules(t *testing.T, store scheduler.Store, want map[string]string) {
+	t.Helper()
+	for id, status := range want {
+		s, err := store.ScheduleByID(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Status != status {
+			t.Errorf("schedule %q is %s, want %s", id, s.Status, status)
+		}
+	}
+}
+
+func checkRequests(t *testing.T, store payments.Store, want map[string]string) {
+	t.Helper()
+	for id, status := range want {
+		r, err := store.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Status != status {
+			t.Errorf("payment request %q is %s, want %s", id, r.Status, status)
+		}
+	}
+}
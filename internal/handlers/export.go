@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/statements"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/timerange"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+)
+
+// Exports can take longer than the server's write timeout allows.
+const exportWriteTimeout = 2 * time.Minute
+
+// ExportMovements handles GET /me/movements/export
+func (h *TransactionHandler) ExportMovements(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = statements.FormatCSV
+	}
+	rng, err := timerange.Parse(r, "from", "to")
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fromTime, toTime := rng.Unix()
+
+	writer, contentType, err := statements.NewWriter(format, w)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'format', must be csv, ofx or pdf")
+		return
+	}
+
+	log.Println("ExportMovements called with userId:", claims.UserID, "format:", format, "fromTime:", fromTime, "toTime:", toTime)
+	now := uint64(time.Now().Unix())
+	st := &statements.Statement{
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		Currency: h.Currency,
+		From:     rng.From,
+		To:       rng.To,
+	}
+	opening, err := h.balanceAt(r.Context(), claims.UserID, fromTime, now)
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	closing, err := h.balanceAt(r.Context(), claims.UserID, toTime+1, now)
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	st.Opening = money.Money{Amount: opening, Currency: h.Currency}
+	st.Closing = money.Money{Amount: closing, Currency: h.Currency}
+
+	// The first chunk is fetched before anything is written, so backend
+	// failures can still be reported as JSON errors.
+	lower := fromTime
+	batch, upper, err := h.movementChunk(r.Context(), claims.UserID, lower, toTime)
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		log.Println("ExportMovements: could not extend write deadline:", err)
+	}
+	filename := fmt.Sprintf("statement-%s-%s.%s", st.From.UTC().Format("20060102"), st.To.UTC().Format("20060102"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// Past this point the response has started; errors can only cut it short.
+	if err := writer.Begin(st); err != nil {
+		log.Println("ExportMovements: writing statement:", err)
+		return
+	}
+	for {
+		for _, m := range batch {
+			if err := writer.Write(statementEntry(m, claims.Username, h.Currency)); err != nil {
+				log.Println("ExportMovements: writing statement:", err)
+				return
+			}
+		}
+		if upper >= toTime {
+			break
+		}
+		lower = upper + 1
+		if batch, upper, err = h.movementChunk(r.Context(), claims.UserID, lower, toTime); err != nil {
+			log.Println("ExportMovements: fetching movements:", err)
+			return
+		}
+	}
+	if err := writer.End(st); err != nil {
+		log.Println("ExportMovements: writing statement:", err)
+	}
+}
+
+// Fetches the movements in [from, min(from+chunk-1, to)], oldest first, and
+// returns the upper bound used.
+func (h *TransactionHandler) movementChunk(ctx context.Context, userId string, from, to uint64) ([]*pb.Movement, uint64, error) {
+	upper := to
+	if to-from >= movementChunkSize {
+		upper = from + movementChunkSize - 1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp, err := h.TransactionClient.Client.Movements(ctx, &pb.GetMovementsRequest{UserId: userId, FromTime: from, ToTime: upper})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	batch := resp.GetMovements()
+	sort.SliceStable(batch, func(i, j int) bool {
+		ti, tj := movementUnix(batch[i].GetTimestamp()), movementUnix(batch[j].GetTimestamp())
+		if ti != tj {
+			return ti < tj
+		}
+		return batch[i].GetTransferId() < batch[j].GetTransferId()
+	})
+	return batch, upper, nil
+}
+
+// Calls fn with the user's whole movement history, in batches going back in
+// time from now, each newest first. Windows with no movements double the size
+// of the next one, as in scanMovements, so the walk back to the epoch is short.
+func (h *TransactionHandler) eachMovementBatch(ctx context.Context, userID string, fn func([]*pb.Movement) error) error {
+	upper := uint64(time.Now().Unix())
+	size := movementChunkSize
+	for {
+		lower := uint64(0)
+		if upper > size {
+			lower = upper - size
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		resp, err := h.TransactionClient.Client.Movements(callCtx, &pb.GetMovementsRequest{UserId: userID, FromTime: lower, ToTime: upper})
+		cancel()
+		if err != nil {
+			return err
+		}
+		batch := resp.GetMovements()
+		if len(batch) == 0 {
+			size *= 2
+		}
+		sort.SliceStable(batch, func(i, j int) bool {
+			ti, tj := movementUnix(batch[i].GetTimestamp()), movementUnix(batch[j].GetTimestamp())
+			if ti != tj {
+				return ti > tj
+			}
+			return batch[i].GetTransferId() > batch[j].GetTransferId()
+		})
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if lower == 0 {
+			return nil
+		}
+		upper = lower - 1
+	}
+}
+
+// Balance at the start of second at: the current balance minus everything
+// that moved since.
+func (h *TransactionHandler) balanceAt(ctx context.Context, userId string, at, now uint64) (money.Decimal, error) {
+	// Nothing has moved after now; only the current balance is needed.
+	future := at > now
+	if future {
+		at = now
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp, err := h.TransactionClient.Client.Balance(ctx, &pb.GetBalanceRequest{UserId: userId, FromTime: at, ToTime: now})
+	if err != nil {
+		return money.Decimal{}, err
+	}
+
+	balance := parseAmount(resp.GetCurrent())
+	if future {
+		return balance, nil
+	}
+	for _, b := range resp.GetBalances() {
+		balance = balance.Sub(parseAmount(b.GetIncome())).Add(parseAmount(b.GetOutcome()))
+	}
+	return balance, nil
+}
+
+func statementEntry(m *pb.Movement, username string, currency money.Currency) statements.Entry {
+	e := statements.Entry{
+		Movement: transformers.MovementJSON(m, money.Formatter{Currency: currency}),
+		Time:     time.Unix(movementUnix(m.GetTimestamp()), 0),
+		Incoming: m.GetToUsername() == username,
+		Amount:   money.Money{Amount: parseAmount(m.GetAmount()), Currency: currency},
+	}
+	if !e.Incoming {
+		e.Amount.Amount = e.Amount.Amount.Neg()
+	}
+	return e
+}
+
+// Parses a backend amount; malformed or empty amounts count as zero.
+func parseAmount(s string) money.Decimal {
+	d, err := money.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return money.Decimal{}
+	}
+	return d
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/insights"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/timerange"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	upb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+const (
+	// Months covered when the request gives no lower bound, including the current one.
+	defaultInsightMonths = 6
+	topCounterparties    = 5
+	// Time left to write the response once the route timeout has passed.
+	insightsWriteSlack = 5 * time.Second
+)
+
+// GetInsights handles GET /me/insights
+func (h *TransactionHandler) GetInsights(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Long ranges take more than the server's write timeout; allow writing
+	// until the route timeout, plus time for the response itself.
+	if deadline, ok := r.Context().Deadline(); ok {
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline.Add(insightsWriteSlack)); err != nil {
+			log.Println("GetInsights: could not extend write deadline:", err)
+		}
+	}
+
+	query := r.URL.Query()
+	rng, err := timerange.Parse(r, "from", "to")
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.Get("from") == "" && query.Get(timerange.RangeParam) == "" {
+		rng.From = time.Date(rng.To.Year(), rng.To.Month()-defaultInsightMonths+1, 1, 0, 0, 0, 0, rng.To.Location())
+	}
+	fromTime, toTime := rng.Unix()
+
+	log.Println("GetInsights called with userId:", claims.UserID, "fromTime:", fromTime, "toTime:", toTime)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	pockets, err := h.UserProductClient.Client.GetPocketsByUserId(ctx, &upb.GetPocketsByUserIdRequest{UserId: claims.UserID})
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	current, err := h.TransactionClient.Client.Balance(ctx, &pb.GetBalanceRequest{UserId: claims.UserID, FromTime: toTime, ToTime: toTime})
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+
+	report := insights.NewReport(rng.From, rng.To)
+	for lower := fromTime; ; {
+		batch, upper, err := h.movementChunk(r.Context(), claims.UserID, lower, toTime)
+		if err != nil {
+			common.RespondGrpcError(w, err)
+			return
+		}
+		for _, m := range batch {
+			e := statementEntry(m, claims.Username, h.Currency)
+			report.Add(e.Time, e.Counterparty(), e.Amount.Amount)
+		}
+		if upper >= toTime {
+			break
+		}
+		lower = upper + 1
+	}
+
+	httpResp := transformers.InsightsRespJSON(report, insights.Categories(pockets.GetPockets()), rng.From, rng.To,
+		money.NewFormatter(h.Currency, r), current.GetCurrent(), topCounterparties)
+	common.RespondWithJSON(w, http.StatusOK, httpResp)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/limits"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+
+	upb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// Checks a transfer of amount (in the account currency) against the transfer
+// limits. Returns a *limits.Violation when it breaks one, or the error that
+// kept the rules from being evaluated.
+func (h *TransactionHandler) checkLimits(ctx context.Context, fromUserId, toUserId string, amount money.Decimal) error {
+	if h.Limits == nil {
+		return nil
+	}
+	now := time.Now()
+
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	verifications, err := h.UserProductClient.Client.GetVerificationsByUserId(lookupCtx, &upb.GetVerificationsByUserIdRequest{UserId: fromUserId})
+	if err != nil {
+		return err
+	}
+	sender, err := h.UserProductClient.Client.GetUserById(lookupCtx, &upb.GetUserByIdRequest{UserId: fromUserId})
+	if err != nil {
+		return err
+	}
+	recipient, err := h.UserProductClient.Client.GetUserById(lookupCtx, &upb.GetUserByIdRequest{UserId: toUserId})
+	if err != nil {
+		return err
+	}
+
+	t := limits.Transfer{Amount: amount, To: recipient.GetUsername(), Now: now}
+	for _, v := range verifications.GetVerifications() {
+		if h.Limits.IsVerified(v.GetStatus()) {
+			t.Verified = append(t.Verified, v.GetType())
+		}
+	}
+
+	// Movements are keyed by username; the sender's outgoing ones count.
+	from, to := uint64(h.Limits.Since(now).Unix()), uint64(now.Unix())
+	for lower := from; ; {
+		batch, upper, err := h.movementChunk(ctx, fromUserId, lower, to)
+		if err != nil {
+			return err
+		}
+		for _, m := range batch {
+			if m.GetFromUsername() != sender.GetUsername() {
+				continue
+			}
+			t.History = append(t.History, limits.Sent{
+				At:     time.Unix(movementUnix(m.GetTimestamp()), 0),
+				To:     m.GetToUsername(),
+				Amount: parseAmount(m.GetAmount()),
+			})
+		}
+		if upper >= to {
+			break
+		}
+		lower = upper + 1
+	}
+
+	if v := h.Limits.Check(t); v != nil {
+		return v
+	}
+	return nil
+}
+
+func respondLimitError(w http.ResponseWriter, err error) {
+	var v *limits.Violation
+	if errors.As(err, &v) {
+		common.RespondWithErrorCode(w, http.StatusUnprocessableEntity, v.Code, v.Message)
+		return
+	}
+	common.RespondGrpcError(w, err)
+}
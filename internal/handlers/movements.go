@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+)
+
+// Movements are fetched from the backend in windows of this size (seconds),
+// newest first, until a page is filled. Windows with no movements at all double
+// the size of the next one, so sparse histories are crossed quickly.
+const movementChunkSize = uint64(30 * 24 * 60 * 60)
+
+// Upper bound on backend calls per page; the cursor resumes where the scan stopped.
+const maxMovementChunks = 12
+
+const (
+	defaultMovementsPageSize = 50
+	maxMovementsPageSize     = 200
+)
+
+// movementQuery is a parsed GET /movements request.
+type movementQuery struct {
+	UserId       string
+	From, To     uint64
+	Limit        bool
+	PageSize     int
+	Counterparty string
+	Direction    string // "in", "out" or empty for both.
+	MinAmount    *money.Decimal
+	MaxAmount    *money.Decimal
+}
+
+// Kind movement cursors are signed for.
+const movementsCursor = "movements"
+
+// movementCursor marks the last movement returned; the next page starts strictly after it.
+type movementCursor struct {
+	Query string `json:"q"`
+	Time  int64  `json:"t"`
+	ID    string `json:"id"`
+	// Time window of the first page.
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+func parseMovementFilters(query url.Values, q *movementQuery) error {
+	q.PageSize = defaultMovementsPageSize
+	if s := query.Get("page_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxMovementsPageSize {
+			return fmt.Errorf("Invalid 'page_size', must be between 1 and %d", maxMovementsPageSize)
+		}
+		q.PageSize = n
+	}
+
+	q.Counterparty = strings.TrimSpace(query.Get("counterparty"))
+	q.Direction = query.Get("direction")
+	if q.Direction != "" && q.Direction != "in" && q.Direction != "out" {
+		return fmt.Errorf("Invalid 'direction', must be 'in' or 'out'")
+	}
+
+	if s := query.Get("min_amount"); s != "" {
+		d, err := money.Parse(s)
+		if err != nil {
+			return fmt.Errorf("Invalid 'min_amount' format")
+		}
+		q.MinAmount = &d
+	}
+	if s := query.Get("max_amount"); s != "" {
+		d, err := money.Parse(s)
+		if err != nil {
+			return fmt.Errorf("Invalid 'max_amount' format")
+		}
+		q.MaxAmount = &d
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && q.MinAmount.Cmp(*q.MaxAmount) > 0 {
+		return fmt.Errorf("'min_amount' must not be greater than 'max_amount'")
+	}
+	return nil
+}
+
+// Identifies everything but the page size, so a cursor can't be replayed on another query.
+func (q *movementQuery) key() string {
+	amount := func(d *money.Decimal) string {
+		if d == nil {
+			return ""
+		}
+		return d.String()
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		q.UserId, strconv.FormatUint(q.From, 10), strconv.FormatUint(q.To, 10), strconv.FormatBool(q.Limit),
+		strings.ToLower(q.Counterparty), q.Direction, amount(q.MinAmount), amount(q.MaxAmount),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (q *movementQuery) needsUsername() bool {
+	return q.Direction != "" || q.Counterparty != ""
+}
+
+// Reports whether a movement passes the filters; username is the requesting user's.
+func (q *movementQuery) matches(m *pb.Movement, username string) bool {
+	outgoing := username != "" && m.GetFromUsername() == username
+	incoming := username != "" && m.GetToUsername() == username
+	switch q.Direction {
+	case "in":
+		if !incoming {
+			return false
+		}
+	case "out":
+		if !outgoing {
+			return false
+		}
+	}
+
+	if q.Counterparty != "" {
+		var other string
+		switch {
+		case outgoing:
+			other = m.GetToUsername()
+		case incoming:
+			other = m.GetFromUsername()
+		}
+		if !strings.EqualFold(other, q.Counterparty) {
+			return false
+		}
+	}
+
+	if q.MinAmount != nil || q.MaxAmount != nil {
+		amount, err := money.Parse(strings.TrimSpace(m.GetAmount()))
+		if err != nil {
+			return false
+		}
+		if q.MinAmount != nil && amount.Cmp(*q.MinAmount) < 0 {
+			return false
+		}
+		if q.MaxAmount != nil && amount.Cmp(*q.MaxAmount) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Unix seconds of a movement timestamp, which the backend sends as Unix
+// seconds, Unix milliseconds or RFC 3339.
+func movementUnix(ts string) int64 {
+	if n, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		if n > 1e11 {
+			return n / 1000
+		}
+		return n
+	}
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		return t.Unix()
+	}
+	return 0
+}
+
+// Reports whether m sorts strictly after the cursor position (newest first).
+func (c *movementCursor) before(m *pb.Movement) bool {
+	t := movementUnix(m.GetTimestamp())
+	return t < c.Time || (t == c.Time && m.GetTransferId() < c.ID)
+}
+
+// Walks the time window backwards in chunks, applying the filters the backend
+// doesn't support, until a page is filled or the window is exhausted. The
+// returned cursor is nil when there are no more movements. Each backend call
+// gets its own deadline; ctx bounds the whole scan.
+func (h *TransactionHandler) scanMovements(ctx context.Context, q *movementQuery, after *movementCursor, username string) ([]*pb.Movement, *movementCursor, *pb.GetMovementsResponse, error) {
+	upper := q.To
+	pos := after
+	if pos != nil && pos.Time >= 0 && uint64(pos.Time) < upper {
+		upper = uint64(pos.Time)
+	}
+
+	var page []*pb.Movement
+	// Reported as is when the window is already exhausted, e.g. a cursor
+	// older than from, so the client gets an empty but successful page.
+	last := &pb.GetMovementsResponse{Success: true, Message: "No more movements"}
+	size := movementChunkSize
+	for chunk := 0; chunk < maxMovementChunks; chunk++ {
+		if upper < q.From {
+			return page, nil, last, nil
+		}
+		lower := q.From
+		if upper-q.From > size {
+			lower = upper - size
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		resp, err := h.TransactionClient.Client.Movements(callCtx, &pb.GetMovementsRequest{
+			UserId: q.UserId, FromTime: lower, ToTime: upper, Limit: q.Limit,
+		})
+		cancel()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		last = resp
+		if len(resp.GetMovements()) == 0 {
+			size *= 2
+		}
+
+		var batch []*pb.Movement
+		for _, m := range resp.GetMovements() {
+			if (pos == nil || pos.before(m)) && q.matches(m, username) {
+				batch = append(batch, m)
+			}
+		}
+		sort.SliceStable(batch, func(i, j int) bool {
+			ti, tj := movementUnix(batch[i].GetTimestamp()), movementUnix(batch[j].GetTimestamp())
+			if ti != tj {
+				return ti > tj
+			}
+			return batch[i].GetTransferId() > batch[j].GetTransferId()
+		})
+
+		page = append(page, batch...)
+		if len(page) >= q.PageSize {
+			page = page[:q.PageSize]
+			end := page[len(page)-1]
+			return page, &movementCursor{Time: movementUnix(end.GetTimestamp()), ID: end.GetTransferId()}, last, nil
+		}
+		if lower == q.From || lower == 0 {
+			return page, nil, last, nil
+		}
+
+		// Everything at or after lower has been seen.
+		pos = &movementCursor{Time: int64(lower)}
+		upper = lower
+	}
+
+	return page, pos, last, nil
+}
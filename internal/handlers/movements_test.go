@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/pagination"
+)
+
+// No clients: a request that gets past validation panics.
+func getMovements(h *TransactionHandler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.GetMovements(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestGetMovementsRejectsInvalidParameters(t *testing.T) {
+	h := &TransactionHandler{Cursors: pagination.NewSigner("secret")}
+	for _, target := range []string{
+		"/movements?id=u1&page_size=0",
+		"/movements?id=u1&page_size=201",
+		"/movements?id=u1&page_size=ten",
+		"/movements?id=u1&lim=sometimes",
+		"/movements?id=u1&direction=sideways",
+		"/movements?id=u1&min_amount=10&max_amount=5",
+		"/movements?id=u1&cursor=garbage",
+	} {
+		if rec := getMovements(h, target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d (%s)", target, rec.Code, http.StatusBadRequest, rec.Body.String())
+		}
+	}
+}
+
+func TestGetMovementsRejectsCursorOfOtherQuery(t *testing.T) {
+	h := &TransactionHandler{Cursors: pagination.NewSigner("secret")}
+	first := &movementQuery{UserId: "u1", From: 1700000000, To: 1720000000, PageSize: 50, Counterparty: "bob"}
+	cursor, err := h.Cursors.Encode(movementsCursor, &movementCursor{
+		Query: first.key(), Time: 1710000000, ID: "t1", From: first.From, To: first.To,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedElsewhere, err := h.Cursors.Encode("audit", &movementCursor{Query: first.key(), From: first.From, To: first.To})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"other user", "/movements?id=u2&counterparty=bob&cursor=" + cursor},
+		{"other counterparty", "/movements?id=u1&counterparty=carol&cursor=" + cursor},
+		{"filter dropped", "/movements?id=u1&cursor=" + cursor},
+		{"filter added", "/movements?id=u1&counterparty=bob&direction=in&cursor=" + cursor},
+		{"other endpoint", "/movements?id=u1&counterparty=bob&cursor=" + signedElsewhere},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getMovements(h, tt.target)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Invalid cursor") {
+				t.Errorf("status = %d (%s), want %d Invalid cursor", rec.Code, rec.Body.String(), http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestMovementQueryKey(t *testing.T) {
+	base := movementQuery{UserId: "u1", From: 1700000000, To: 1720000000, PageSize: 50, Counterparty: "Bob"}
+	same := base
+	same.PageSize = 10
+	same.Counterparty = "bob"
+	if base.key() != same.key() {
+		t.Error("page size or counterparty case changed the key")
+	}
+	for name, edit := range map[string]func(q *movementQuery){
+		"user":      func(q *movementQuery) { q.UserId = "u2" },
+		"from":      func(q *movementQuery) { q.From++ },
+		"to":        func(q *movementQuery) { q.To-- },
+		"limit":     func(q *movementQuery) { q.Limit = true },
+		"direction": func(q *movementQuery) { q.Direction = "out" },
+	} {
+		q := base
+		edit(&q)
+		if q.key() == base.key() {
+			t.Errorf("changing the %s kept the key", name)
+		}
+	}
+}
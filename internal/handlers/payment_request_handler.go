@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/timerange"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	upb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// CreatePaymentRequestReq is the body of POST /me/payment-requests. The payer
+// is given by username or by one of the requester's favorites.
+type CreatePaymentRequestReq struct {
+	ToUsername string        `json:"to_username,omitempty"`
+	FavoriteId string        `json:"favorite_id,omitempty"`
+	Amount     money.Decimal `json:"amount"`
+	Note       string        `json:"note,omitempty"`
+	// Defaults to a week from now.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+const (
+	defaultPaymentRequestExpiry = 7 * 24 * time.Hour
+	maxPaymentRequestExpiry     = 30 * 24 * time.Hour
+	maxPaymentRequestNote       = 140
+	// Upper bound on accepting a request: the transfer outcome is recorded
+	// even if the client goes away meanwhile.
+	acceptTimeout = 10 * time.Second
+	// An accept still in flight after this, well past acceptTimeout, was cut
+	// off by a crash or restart.
+	staleAccept = time.Minute
+)
+
+var errPayerNotFound = errors.New("Payer not found")
+
+type PaymentRequestHandler struct {
+	Store             payments.Store
+	UserProductClient *clients.UserProductServiceClient
+	Transfers         *TransactionHandler
+}
+
+func NewPaymentRequestHandler(store payments.Store, userClient *clients.UserProductServiceClient, transfers *TransactionHandler) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		Store:             store,
+		UserProductClient: userClient,
+		Transfers:         transfers,
+	}
+}
+
+// CreatePaymentRequest handles POST /me/payment-requests
+func (h *PaymentRequestHandler) CreatePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var reqBody CreatePaymentRequestReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if (reqBody.ToUsername == "") == (reqBody.FavoriteId == "") {
+		common.RespondWithError(w, http.StatusBadRequest, "Exactly one of 'to_username' or 'favorite_id' is required")
+		return
+	}
+	if err := validateRequestedAmount("amount", reqBody.Amount); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(reqBody.Note) > maxPaymentRequestNote {
+		common.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'note': at most %d characters", maxPaymentRequestNote))
+		return
+	}
+
+	now := time.Now()
+	expiresAt, err := paymentRequestExpiry(r, reqBody.ExpiresAt, now)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	payerID, payerUsername, err := h.resolvePayer(ctx, claims.UserID, reqBody.ToUsername, reqBody.FavoriteId)
+	if err != nil {
+		respondPayerError(w, err)
+		return
+	}
+	if payerID == claims.UserID {
+		common.RespondWithError(w, http.StatusBadRequest, "Cannot request money from yourself")
+		return
+	}
+
+	req := &payments.Request{
+		ID:                uuid.NewString(),
+		RequesterID:       claims.UserID,
+		RequesterUsername: claims.Username,
+		PayerID:           payerID,
+		PayerUsername:     payerUsername,
+		Amount:            reqBody.Amount.String(),
+		Currency:          h.Transfers.Currency.Code,
+		Note:              reqBody.Note,
+		Status:            payments.StatusPending,
+		CreatedAt:         now,
+		ExpiresAt:         expiresAt,
+		UpdatedAt:         now,
+	}
+	log.Println("CreatePaymentRequest called with userId:", req.RequesterID, "payerId:", req.PayerID, "amount:", req.Amount)
+	if err := h.Store.Create(ctx, req); err != nil {
+		log.Println("Error storing payment request:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	common.RespondWithJSON(w, http.StatusCreated, transformers.PaymentRequestRespJSON(req, "Payment request created"))
+}
+
+// Amounts are requested in the account currency, in units a transfer can move.
+func validateRequestedAmount(field string, amount money.Decimal) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("Invalid '%s': must be positive", field)
+	}
+	if _, err := amount.Units(transferDecimals, math.MaxInt64); err != nil {
+		return fmt.Errorf("Invalid '%s': %v", field, err)
+	}
+	return nil
+}
+
+// Expiry of new payment requests: expires_at in the request's time zone, or
+// the default.
+func paymentRequestExpiry(r *http.Request, expiresAt string, now time.Time) (time.Time, error) {
+	if expiresAt == "" {
+		return now.Add(defaultPaymentRequestExpiry), nil
+	}
+	loc, err := timerange.Location(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := timerange.ParseTime(expiresAt, loc, true)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid 'expires_at' time format")
+	}
+	if !t.After(now) || t.Sub(now) > maxPaymentRequestExpiry {
+		return time.Time{}, errors.New("Invalid 'expires_at': must be within the next 30 days")
+	}
+	return t, nil
+}
+
+// User id and username of the payer, by username or by one of userID's favorites.
+func (h *PaymentRequestHandler) resolvePayer(ctx context.Context, userID, username, favoriteID string) (string, string, error) {
+	if favoriteID == "" {
+		resp, err := h.UserProductClient.Client.GetUserByUsername(ctx, &upb.GetUserByUsernameRequest{Username: username})
+		if err != nil {
+			return "", "", err
+		}
+		if !resp.GetSuccess() || resp.GetUserId() == "" {
+			return "", "", errPayerNotFound
+		}
+		return resp.GetUserId(), username, nil
+	}
+
+	resp, err := h.UserProductClient.Client.GetFavoritesByUserId(ctx, &upb.GetFavoritesByUserIdRequest{UserId: userID})
+	if err != nil {
+		return "", "", err
+	}
+	for _, fav := range resp.GetFavorites() {
+		if fav.GetId() == favoriteID {
+			return fav.GetFavoriteUserId(), fav.GetFavoriteUsername(), nil
+		}
+	}
+	return "", "", errPayerNotFound
+}
+
+func respondPayerError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errPayerNotFound) {
+		common.RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	common.RespondGrpcError(w, err)
+}
+
+// GetPaymentRequests handles GET /me/payment-requests
+func (h *PaymentRequestHandler) GetPaymentRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	direction := query.Get("direction")
+	status := query.Get("status")
+	switch status {
+	case "", payments.StatusPending, payments.StatusAccepting, payments.StatusPaid, payments.StatusDeclined,
+		payments.StatusCanceled, payments.StatusExpired, payments.StatusUnknown:
+	default:
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'status'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := refreshStatuses(ctx, h.Store, time.Now()); err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	var requests []*payments.Request
+	var err error
+	switch direction {
+	case "", "incoming":
+		requests, err = h.Store.ListIncoming(ctx, claims.UserID, status)
+	case "outgoing":
+		requests, err = h.Store.ListOutgoing(ctx, claims.UserID, status)
+	default:
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'direction': must be incoming or outgoing")
+		return
+	}
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.GetPaymentRequestsRespJSON(requests))
+}
+
+// AcceptPaymentRequest handles POST /me/payment-requests/{request_id}/accept
+func (h *PaymentRequestHandler) AcceptPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	requestID := mux.Vars(r)["request_id"]
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), acceptTimeout)
+	defer cancel()
+
+	req, err := h.Store.Get(ctx, requestID)
+	if err == nil && req.PayerID != claims.UserID {
+		err = payments.ErrNotFound
+	}
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	if req.Status == payments.StatusPaid {
+		// Accepting twice is not an error; the first transfer is the answer.
+		common.RespondWithJSON(w, http.StatusOK, transformers.PaymentRequestRespJSON(req, "Payment request already paid"))
+		return
+	}
+	amount, err := money.Parse(req.Amount)
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	units, err := amount.Units(transferDecimals, math.MaxInt64)
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	if req.Open(time.Now()) {
+		if err := h.Transfers.checkLimits(ctx, req.PayerID, req.RequesterID, amount); err != nil {
+			respondLimitError(w, err)
+			return
+		}
+		if !h.Transfers.requireTransferStepUp(w, r, req.PayerID, amount, "payment_request", req.ID) {
+			return
+		}
+	}
+
+	// Only the caller that moves the request out of pending makes the transfer.
+	claimed, err := h.Store.Transition(ctx, req.ID, payments.StatusPending, payments.StatusAccepting, "", "", time.Now())
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	if !claimed {
+		h.respondNotOpen(ctx, w, req.ID)
+		return
+	}
+
+	log.Println("AcceptPaymentRequest called with requestId:", req.ID, "payerId:", req.PayerID, "requesterId:", req.RequesterID, "amount:", units)
+	// TransferFundsRequest has no idempotency key to carry the request id;
+	// the claim above and never retrying an unknown outcome keep it to one
+	// transfer.
+	grpcReq := &pb.TransferFundsRequest{
+		FromUserId:    req.PayerID,
+		ToUserId:      req.RequesterID,
+		Amount:        uint64(units),
+		FromUserEmail: claims.Email,
+	}
+	grpcResp, err := h.Transfers.TransactionClient.Client.Transfer(ctx, grpcReq)
+	h.Transfers.auditTransfer(ctx, claims.UserID, grpcReq, grpcResp, err, map[string]string{"via": "payment_request", "payment_request_id": req.ID})
+	switch {
+	case err != nil && transferNotMade(err):
+		// Nothing moved: the request can be accepted again.
+		h.finishAccept(ctx, req, payments.StatusPending, "", err.Error())
+		common.RespondGrpcError(w, err)
+	case err != nil:
+		h.finishAccept(ctx, req, payments.StatusUnknown, "", err.Error())
+		common.RespondGrpcError(w, err)
+	case !grpcResp.GetSuccess():
+		h.finishAccept(ctx, req, payments.StatusPending, "", grpcResp.GetMessage())
+		common.RespondWithError(w, http.StatusUnprocessableEntity, "Transfer rejected: "+grpcResp.GetMessage())
+	default:
+		h.finishAccept(ctx, req, payments.StatusPaid, grpcResp.GetTransferId(), "")
+		common.RespondWithJSON(w, http.StatusOK, transformers.PaymentRequestRespJSON(req, "Payment request paid"))
+	}
+}
+
+// Brings stored statuses up to date before they are read: pending requests
+// past their expiry expire, and stale accepts become unknown.
+func refreshStatuses(ctx context.Context, store payments.Store, now time.Time) error {
+	if err := store.ExpirePending(ctx, now); err != nil {
+		return err
+	}
+	n, err := store.AbandonAccepting(ctx, now.Add(-staleAccept), now)
+	if n > 0 {
+		log.Printf("Payment requests: %d accept(s) were interrupted mid-transfer and marked unknown", n)
+	}
+	return err
+}
+
+// Records the outcome of an accept on req; a failure to do so leaves the
+// request accepting, which blocks a second transfer.
+func (h *PaymentRequestHandler) finishAccept(ctx context.Context, req *payments.Request, status, transferID, errMsg string) {
+	now := time.Now()
+	if _, err := h.Store.Transition(ctx, req.ID, payments.StatusAccepting, status, transferID, errMsg, now); err != nil {
+		log.Printf("Error recording outcome %q of payment request %s (transfer %q): %v", status, req.ID, transferID, err)
+	}
+	req.Status, req.TransferID, req.Error, req.UpdatedAt = status, transferID, errMsg, now
+}
+
+// DeclinePaymentRequest handles POST /me/payment-requests/{request_id}/decline
+func (h *PaymentRequestHandler) DeclinePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	h.close(w, r, payments.StatusDeclined, func(req *payments.Request, userID string) bool { return req.PayerID == userID })
+}
+
+// CancelPaymentRequest handles DELETE /me/payment-requests/{request_id}
+func (h *PaymentRequestHandler) CancelPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	h.close(w, r, payments.StatusCanceled, func(req *payments.Request, userID string) bool { return req.RequesterID == userID })
+}
+
+// Moves a pending request the caller may close to status.
+func (h *PaymentRequestHandler) close(w http.ResponseWriter, r *http.Request, status string, allowed func(*payments.Request, string) bool) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	requestID := mux.Vars(r)["request_id"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	req, err := h.Store.Get(ctx, requestID)
+	if err == nil && !allowed(req, claims.UserID) {
+		err = payments.ErrNotFound
+	}
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	now := time.Now()
+	closed, err := h.Store.Transition(ctx, req.ID, payments.StatusPending, status, "", "", now)
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	if !closed {
+		h.respondNotOpen(ctx, w, req.ID)
+		return
+	}
+	req.Status, req.UpdatedAt = status, now
+	common.RespondWithJSON(w, http.StatusOK, transformers.PaymentRequestRespJSON(req, "Payment request "+status))
+}
+
+// Conflict for a request that is no longer pending, naming its status.
+func (h *PaymentRequestHandler) respondNotOpen(ctx context.Context, w http.ResponseWriter, id string) {
+	now := time.Now()
+	if err := refreshStatuses(ctx, h.Store, now); err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	req, err := h.Store.Get(ctx, id)
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	common.RespondWithError(w, http.StatusConflict, "Payment request is "+req.Status)
+}
+
+func respondPaymentRequestError(w http.ResponseWriter, err error) {
+	if errors.Is(err, payments.ErrNotFound) {
+		common.RespondWithError(w, http.StatusNotFound, "Payment request not found")
+		return
+	}
+	log.Println("Error handling payment request:", err)
+	common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/deletion"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/limits"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/scheduler"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/timerange"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+)
+
+// CreateScheduledTransferReq is the body of POST /me/scheduled-transfers.
+type CreateScheduledTransferReq struct {
+	ToUser string        `json:"to_user"`
+	Amount money.Decimal `json:"amount"`
+	// Currency of Amount; defaults to the account currency. Other currencies
+	// are converted at the rate of the day each transfer runs.
+	Currency    string `json:"currency,omitempty"`
+	Frequency   string `json:"frequency"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+const maxScheduleDescription = 140
+
+type ScheduledTransferHandler struct {
+	Store     scheduler.Store
+	Transfers *TransactionHandler
+	// Runs of locked accounts, and of accounts with a pending deletion, are skipped.
+	Locks     locks.Store
+	Deletions deletion.Store
+}
+
+func NewScheduledTransferHandler(store scheduler.Store, transfers *TransactionHandler, accountLocks locks.Store, deletions deletion.Store) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{
+		Store:     store,
+		Transfers: transfers,
+		Locks:     accountLocks,
+		Deletions: deletions,
+	}
+}
+
+// CreateScheduledTransfer handles POST /me/scheduled-transfers
+func (h *ScheduledTransferHandler) CreateScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var reqBody CreateScheduledTransferReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	loc, err := timerange.Location(r)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now()
+	sched, charged, err := h.newSchedule(r.Context(), reqBody, loc, now)
+	if err != nil {
+		respondTransferAmountError(w, err)
+		return
+	}
+	sched.UserID, sched.Email = claims.UserID, claims.Email
+	if sched.ToUserID == sched.UserID {
+		common.RespondWithError(w, http.StatusBadRequest, "Cannot schedule a transfer to yourself")
+		return
+	}
+	// Runs happen unattended, so each amount is confirmed once, up front.
+	endAt := ""
+	if sched.EndAt != nil {
+		endAt = sched.EndAt.UTC().Format(time.RFC3339)
+	}
+	if !h.Transfers.requireTransferStepUp(w, r, sched.UserID, charged, "schedule", sched.ToUserID, sched.Amount, sched.Currency,
+		sched.Frequency, sched.StartAt.UTC().Format(time.RFC3339), endAt) {
+		return
+	}
+
+	log.Println("CreateScheduledTransfer called with userId:", sched.UserID, "toUser:", sched.ToUserID, "frequency:", sched.Frequency)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Store.CreateSchedule(ctx, sched); err != nil {
+		log.Println("Error storing scheduled transfer:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	common.RespondWithJSON(w, http.StatusCreated, transformers.ScheduledTransferRespJSON(sched, "Scheduled transfer created"))
+}
+
+// Validates a create request into an active schedule, without its owner, and
+// returns what each run would charge at the current rates.
+func (h *ScheduledTransferHandler) newSchedule(ctx context.Context, req CreateScheduledTransferReq, loc *time.Location, now time.Time) (*scheduler.Schedule, money.Decimal, error) {
+	if req.ToUser == "" {
+		return nil, money.Decimal{}, errors.New("Missing 'to_user'")
+	}
+	if !scheduler.ValidFrequency(req.Frequency) {
+		return nil, money.Decimal{}, errors.New("Invalid 'frequency': must be once, daily, weekly or monthly")
+	}
+	if len(req.Description) > maxScheduleDescription {
+		return nil, money.Decimal{}, fmt.Errorf("Invalid 'description': at most %d characters", maxScheduleDescription)
+	}
+
+	currency := h.Transfers.Currency
+	if req.Currency != "" {
+		c, err := money.LookupCurrency(req.Currency)
+		if err != nil {
+			return nil, money.Decimal{}, fmt.Errorf("Invalid 'currency': %v", err)
+		}
+		currency = c
+	}
+	if req.Amount.Sign() <= 0 {
+		return nil, money.Decimal{}, errors.New("Invalid 'amount': must be positive")
+	}
+	if _, err := req.Amount.Units(currency.Digits, math.MaxInt64); err != nil {
+		return nil, money.Decimal{}, fmt.Errorf("Invalid 'amount': %v", err)
+	}
+	// Fail now rather than on the first run when the amount can't be converted.
+	charged, _, err := h.Transfers.transferAmount(ctx, TransferReq{Amount: req.Amount, Currency: currency.Code})
+	if err != nil {
+		return nil, money.Decimal{}, err
+	}
+
+	if req.StartAt == "" {
+		return nil, money.Decimal{}, errors.New("Missing 'start_at'")
+	}
+	startAt, err := timerange.ParseTime(req.StartAt, loc, false)
+	if err != nil {
+		return nil, money.Decimal{}, errors.New("Invalid 'start_at' time format")
+	}
+	if startAt.Before(now.Add(-time.Minute)) {
+		return nil, money.Decimal{}, errors.New("Invalid 'start_at': must not be in the past")
+	}
+	var endAt *time.Time
+	if req.EndAt != "" {
+		t, err := timerange.ParseTime(req.EndAt, loc, true)
+		if err != nil {
+			return nil, money.Decimal{}, errors.New("Invalid 'end_at' time format")
+		}
+		if t.Before(startAt) {
+			return nil, money.Decimal{}, errors.New("Invalid 'end_at': must not be before start_at")
+		}
+		endAt = &t
+	}
+
+	return &scheduler.Schedule{
+		ID:          uuid.NewString(),
+		ToUserID:    req.ToUser,
+		Amount:      req.Amount.String(),
+		Currency:    currency.Code,
+		Description: req.Description,
+		Frequency:   req.Frequency,
+		TimeZone:    loc.String(),
+		StartAt:     startAt,
+		EndAt:       endAt,
+		NextRunAt:   startAt,
+		Status:      scheduler.StatusActive,
+		CreatedAt:   now,
+	}, charged, nil
+}
+
+// GetScheduledTransfers handles GET /me/scheduled-transfers
+func (h *ScheduledTransferHandler) GetScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	schedules, err := h.Store.ListSchedules(ctx, claims.UserID)
+	if err != nil {
+		log.Println("Error listing scheduled transfers:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.GetScheduledTransfersRespJSON(schedules))
+}
+
+// CancelScheduledTransfer handles DELETE /me/scheduled-transfers/{schedule_id}
+func (h *ScheduledTransferHandler) CancelScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	scheduleID := mux.Vars(r)["schedule_id"]
+	if scheduleID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing schedule_id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Store.CancelSchedule(ctx, claims.UserID, scheduleID); err != nil {
+		respondScheduleError(w, err)
+		return
+	}
+	// A run already in flight finishes; pending retries are dropped by the scheduler.
+	common.RespondWithJSON(w, http.StatusOK, transformers.StatusResp{Success: true, Message: "Scheduled transfer canceled"})
+}
+
+// GetScheduledTransferRuns handles GET /me/scheduled-transfers/{schedule_id}/runs
+func (h *ScheduledTransferHandler) GetScheduledTransferRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	scheduleID := mux.Vars(r)["schedule_id"]
+	if scheduleID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing schedule_id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sched, err := h.Store.GetSchedule(ctx, claims.UserID, scheduleID)
+	if err != nil {
+		respondScheduleError(w, err)
+		return
+	}
+	runs, err := h.Store.ListRuns(ctx, sched.ID)
+	if err != nil {
+		respondScheduleError(w, err)
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.GetScheduledRunsRespJSON(sched, runs))
+}
+
+func respondScheduleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, scheduler.ErrNotFound) {
+		common.RespondWithError(w, http.StatusNotFound, "Scheduled transfer not found")
+		return
+	}
+	log.Println("Error reading scheduled transfers:", err)
+	common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+}
+
+// Execute is the scheduler's executor: it skips the run when the account is
+// locked or being deleted and otherwise makes the transfer.
+func (h *ScheduledTransferHandler) Execute(ctx context.Context, s *scheduler.Schedule) (string, error) {
+	locked, err := locks.IsLocked(ctx, h.Locks, s.UserID)
+	if err != nil {
+		return "", scheduler.Retry(fmt.Errorf("checking account lock: %w", err))
+	}
+	if locked {
+		return "", scheduler.Skip(errors.New("account locked"))
+	}
+	req, err := h.Deletions.Get(ctx, s.UserID)
+	if err != nil && !errors.Is(err, deletion.ErrNotFound) {
+		return "", scheduler.Retry(fmt.Errorf("checking account deletion: %w", err))
+	}
+	if err == nil && req.Pending() {
+		return "", scheduler.Skip(errors.New("account deletion pending"))
+	}
+	return h.Transfers.ExecuteScheduled(ctx, s)
+}
+
+// ExecuteScheduled makes the transfer of a due schedule; it is the
+// scheduler's executor. Errors tell the scheduler whether the transfer
+// certainly didn't happen (retry), was rejected (permanent) or may have
+// happened (anything else).
+func (h *TransactionHandler) ExecuteScheduled(ctx context.Context, s *scheduler.Schedule) (string, error) {
+	amount, err := money.Parse(s.Amount)
+	if err != nil {
+		return "", scheduler.Permanent(fmt.Errorf("invalid stored amount %q: %w", s.Amount, err))
+	}
+	charged, _, err := h.transferAmount(ctx, TransferReq{Amount: amount, Currency: s.Currency})
+	if errors.Is(err, fx.ErrUnsupported) {
+		return "", scheduler.Permanent(err)
+	}
+	// Stale rates may be refreshed before the run runs out of retries.
+	if err != nil {
+		return "", scheduler.Retry(err)
+	}
+	units, err := charged.Units(transferDecimals, math.MaxInt64)
+	if err != nil {
+		return "", scheduler.Permanent(err)
+	}
+	var violation *limits.Violation
+	if err := h.checkLimits(ctx, s.UserID, s.ToUserID, charged); errors.As(err, &violation) {
+		// Retrying within the day could sneak under a daily cap later, but a
+		// run is for its due date: over the limit then means it doesn't happen.
+		return "", scheduler.Permanent(err)
+	} else if err != nil {
+		return "", scheduler.Retry(err)
+	}
+
+	log.Println("ExecuteScheduled called with scheduleId:", s.ID, "userId:", s.UserID, "toUser:", s.ToUserID, "amount:", units)
+	// TransferFundsRequest has no idempotency key, so the run id can't be
+	// passed on: the transaction service can't tell a repeat from a new
+	// transfer. Runs are made at most once by never retrying a call whose
+	// outcome is unknown instead.
+	grpcReq := &pb.TransferFundsRequest{
+		FromUserId:    s.UserID,
+		ToUserId:      s.ToUserID,
+		Amount:        uint64(units),
+		FromUserEmail: s.Email,
+	}
+	grpcResp, err := h.TransactionClient.Client.Transfer(ctx, grpcReq)
+	// Made by the scheduler on behalf of the user who scheduled it.
+	h.auditTransfer(ctx, s.UserID, grpcReq, grpcResp, err, map[string]string{"via": "schedule", "schedule_id": s.ID})
+	if err != nil {
+		if !transferNotMade(err) {
+			return "", err
+		}
+		switch status.Code(err) {
+		case codes.Unavailable, codes.ResourceExhausted:
+			return "", scheduler.Retry(err)
+		}
+		return "", scheduler.Permanent(err)
+	}
+	if !grpcResp.GetSuccess() {
+		// Rejected (e.g. insufficient funds): nothing moved, so try again later.
+		return "", scheduler.Retry(errors.New(strings.TrimSpace("transfer rejected: " + grpcResp.GetMessage())))
+	}
+	return grpcResp.GetTransferId(), nil
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+)
+
+// GetSessions handles GET /me/sessions
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.Sessions.List(ctx, claims.UserID, time.Now())
+	if err != nil {
+		log.Println("GetSessions: listing sessions:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.SessionsRespJSON(list, middleware.SessionIDFromContext(r.Context())))
+}
+
+// RevokeSession handles DELETE /me/sessions/{session_id}
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	sessionID := mux.Vars(r)["session_id"]
+	if sessionID == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing session_id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := h.Sessions.Revoke(ctx, claims.UserID, sessionID, time.Now())
+	if err != nil {
+		log.Println("RevokeSession: revoking session:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !revoked {
+		common.RespondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	audit.Record(ctx, h.Audit, audit.Event{
+		Actor:   claims.UserID,
+		Action:  "session.revoke",
+		Target:  claims.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"session_id": sessionID},
+	})
+	if sessionID == middleware.SessionIDFromContext(r.Context()) {
+		clearAccessTokenCookie(w)
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.RevokeSessionsRespJSON(1, "Session ended"))
+}
+
+// RevokeAllSessions handles DELETE /me/sessions: logs the user out everywhere,
+// including the session making the request.
+func (h *AuthHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	n, err := h.Sessions.RevokeAll(ctx, claims.UserID, time.Now())
+	if err != nil {
+		log.Println("RevokeAllSessions: revoking sessions:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	audit.Record(ctx, h.Audit, audit.Event{
+		Actor:   claims.UserID,
+		Action:  "session.revoke_all",
+		Target:  claims.UserID,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"revoked": strconv.Itoa(n)},
+	})
+	clearAccessTokenCookie(w)
+	common.RespondWithJSON(w, http.StatusOK, transformers.RevokeSessionsRespJSON(n, "Logged out everywhere"))
+}
+
+// Access token of r, from the accessToken cookie or a Bearer Authorization
+// header, as AuthToken reads it; "" when there is none.
+func requestToken(r *http.Request) string {
+	if cookie, err := r.Cookie("accessToken"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
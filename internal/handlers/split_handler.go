@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+
+	upb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// CreateSplitReq is the body of POST /me/splits.
+type CreateSplitReq struct {
+	Total       money.Decimal `json:"total"`
+	Description string        `json:"description,omitempty"`
+	// even (default) or custom.
+	Mode string `json:"mode,omitempty"`
+	// Whether the requester pays a share too (default true).
+	IncludeSelf  *bool                 `json:"include_self,omitempty"`
+	Participants []SplitParticipantReq `json:"participants"`
+	ExpiresAt    string                `json:"expires_at,omitempty"`
+}
+
+type SplitParticipantReq struct {
+	FavoriteId string `json:"favorite_id"`
+	// Share of the total; required in custom mode, ignored otherwise.
+	Amount *money.Decimal `json:"amount,omitempty"`
+}
+
+const maxSplitParticipants = 20
+
+// CreateSplit handles POST /me/splits
+func (h *PaymentRequestHandler) CreateSplit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var reqBody CreateSplitReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if reqBody.Mode == "" {
+		reqBody.Mode = payments.SplitEven
+	}
+	includeSelf := reqBody.IncludeSelf == nil || *reqBody.IncludeSelf
+	if err := validateRequestedAmount("total", reqBody.Total); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(reqBody.Description) > maxPaymentRequestNote {
+		common.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'description': at most %d characters", maxPaymentRequestNote))
+		return
+	}
+	if n := len(reqBody.Participants); n == 0 || n > maxSplitParticipants {
+		common.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid 'participants': between 1 and %d favorites", maxSplitParticipants))
+		return
+	}
+	seen := make(map[string]bool, len(reqBody.Participants))
+	for _, p := range reqBody.Participants {
+		if p.FavoriteId == "" || seen[p.FavoriteId] {
+			common.RespondWithError(w, http.StatusBadRequest, "Invalid 'participants': each needs a distinct favorite_id")
+			return
+		}
+		seen[p.FavoriteId] = true
+	}
+
+	own, shares, err := splitShares(reqBody, includeSelf)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	expiresAt, err := paymentRequestExpiry(r, reqBody.ExpiresAt, now)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	favResp, err := h.UserProductClient.Client.GetFavoritesByUserId(ctx, &upb.GetFavoritesByUserIdRequest{UserId: claims.UserID})
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	favorites := make(map[string]*upb.Favorite, len(favResp.GetFavorites()))
+	for _, fav := range favResp.GetFavorites() {
+		favorites[fav.GetId()] = fav
+	}
+
+	split := &payments.Split{
+		ID:                uuid.NewString(),
+		RequesterID:       claims.UserID,
+		RequesterUsername: claims.Username,
+		Total:             reqBody.Total.String(),
+		OwnShare:          own.String(),
+		Currency:          h.Transfers.Currency.Code,
+		Description:       reqBody.Description,
+		Mode:              reqBody.Mode,
+		CreatedAt:         now,
+	}
+	requests := make([]*payments.Request, len(reqBody.Participants))
+	for i, p := range reqBody.Participants {
+		fav, ok := favorites[p.FavoriteId]
+		if !ok {
+			common.RespondWithError(w, http.StatusNotFound, "Favorite not found: "+p.FavoriteId)
+			return
+		}
+		if fav.GetFavoriteUserId() == claims.UserID {
+			common.RespondWithError(w, http.StatusBadRequest, "Cannot request money from yourself")
+			return
+		}
+		requests[i] = &payments.Request{
+			ID:                uuid.NewString(),
+			RequesterID:       claims.UserID,
+			RequesterUsername: claims.Username,
+			PayerID:           fav.GetFavoriteUserId(),
+			PayerUsername:     fav.GetFavoriteUsername(),
+			Amount:            shares[i].String(),
+			Currency:          split.Currency,
+			Note:              reqBody.Description,
+			Status:            payments.StatusPending,
+			CreatedAt:         now,
+			ExpiresAt:         expiresAt,
+			UpdatedAt:         now,
+			SplitID:           split.ID,
+		}
+	}
+
+	log.Println("CreateSplit called with userId:", claims.UserID, "total:", split.Total, "mode:", split.Mode, "participants:", len(requests))
+	if err := h.Store.CreateSplit(ctx, split, requests); err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	common.RespondWithJSON(w, http.StatusCreated, transformers.SplitRespJSON(split, requests, "Split created"))
+}
+
+// The requester's own share and each participant's share, in request order.
+//
+// Even splits divide the total in whole units between the participants and,
+// when included, the requester. The remainder units go one each in order:
+// the requester first, then the participants as listed. Custom splits take
+// each participant's amount; the requester's share is what remains, which
+// must be zero unless they are included.
+func splitShares(req CreateSplitReq, includeSelf bool) (money.Decimal, []money.Decimal, error) {
+	switch req.Mode {
+	case payments.SplitEven:
+		parts := len(req.Participants)
+		if includeSelf {
+			parts++
+		}
+		shares, err := payments.EvenShares(req.Total, parts, transferDecimals)
+		if err != nil {
+			return money.Decimal{}, nil, fmt.Errorf("Invalid 'total': %v", err)
+		}
+		if includeSelf {
+			return shares[0], shares[1:], nil
+		}
+		return money.FromInt(0), shares, nil
+
+	case payments.SplitCustom:
+		shares := make([]money.Decimal, len(req.Participants))
+		rest := req.Total
+		for i, p := range req.Participants {
+			if p.Amount == nil {
+				return money.Decimal{}, nil, errors.New("Invalid 'participants': custom splits need an amount for each")
+			}
+			if err := validateRequestedAmount(fmt.Sprintf("participants[%d].amount", i), *p.Amount); err != nil {
+				return money.Decimal{}, nil, err
+			}
+			shares[i] = *p.Amount
+			rest = rest.Sub(*p.Amount)
+		}
+		if rest.Sign() < 0 {
+			return money.Decimal{}, nil, errors.New("Invalid 'participants': shares add up to more than the total")
+		}
+		if rest.Sign() > 0 && !includeSelf {
+			return money.Decimal{}, nil, errors.New("Invalid 'participants': shares must add up to the total")
+		}
+		return rest, shares, nil
+	}
+	return money.Decimal{}, nil, errors.New("Invalid 'mode': must be even or custom")
+}
+
+// GetSplits handles GET /me/splits
+func (h *PaymentRequestHandler) GetSplits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := refreshStatuses(ctx, h.Store, time.Now()); err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	splits, err := h.Store.ListSplits(ctx, claims.UserID)
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	requests := make([][]*payments.Request, len(splits))
+	for i, s := range splits {
+		if requests[i], err = h.Store.SplitRequests(ctx, s.ID); err != nil {
+			respondPaymentRequestError(w, err)
+			return
+		}
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.GetSplitsRespJSON(splits, requests))
+}
+
+// GetSplit handles GET /me/splits/{split_id}
+func (h *PaymentRequestHandler) GetSplit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := refreshStatuses(ctx, h.Store, time.Now()); err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	split, requests, err := h.Store.GetSplit(ctx, claims.UserID, mux.Vars(r)["split_id"])
+	if errors.Is(err, payments.ErrNotFound) {
+		common.RespondWithError(w, http.StatusNotFound, "Split not found")
+		return
+	}
+	if err != nil {
+		respondPaymentRequestError(w, err)
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.SplitRespJSON(split, requests, "Split retrieved"))
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+)
+
+func TestSplitSharesCustom(t *testing.T) {
+	amount := func(s string) *money.Decimal {
+		d := money.MustParse(s)
+		return &d
+	}
+	participants := func(amounts ...*money.Decimal) []SplitParticipantReq {
+		ps := make([]SplitParticipantReq, len(amounts))
+		for i, a := range amounts {
+			ps[i] = SplitParticipantReq{FavoriteId: "f", Amount: a}
+		}
+		return ps
+	}
+
+	tests := []struct {
+		name         string
+		total        string
+		participants []SplitParticipantReq
+		includeSelf  bool
+		wantOwn      string
+		wantErr      bool
+	}{
+		{name: "exact", total: "100", participants: participants(amount("60"), amount("40")), wantOwn: "0"},
+		{name: "requester pays the rest", total: "100", participants: participants(amount("30"), amount("30")), includeSelf: true, wantOwn: "40"},
+		{name: "rest without the requester", total: "100", participants: participants(amount("30"), amount("30")), wantErr: true},
+		{name: "more than the total", total: "100", participants: participants(amount("70"), amount("40")), includeSelf: true, wantErr: true},
+		{name: "missing amount", total: "100", participants: participants(amount("100"), nil), wantErr: true},
+		{name: "zero share", total: "100", participants: participants(amount("100"), amount("0")), wantErr: true},
+		{name: "negative share", total: "100", participants: participants(amount("110"), amount("-10")), wantErr: true},
+		{name: "fractional share", total: "100", participants: participants(amount("50.5"), amount("49.5")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CreateSplitReq{Total: money.MustParse(tt.total), Mode: payments.SplitCustom, Participants: tt.participants}
+			own, shares, err := splitShares(req, tt.includeSelf)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("splitShares = %s, %v, want an error", own, shares)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitShares: %v", err)
+			}
+			if own.Cmp(money.MustParse(tt.wantOwn)) != 0 {
+				t.Errorf("own share = %s, want %s", own, tt.wantOwn)
+			}
+			sum := own
+			for i, share := range shares {
+				if share.Cmp(*tt.participants[i].Amount) != 0 {
+					t.Errorf("share %d = %s, want %s", i, share, tt.participants[i].Amount)
+				}
+				sum = sum.Add(share)
+			}
+			if sum.Cmp(req.Total) != 0 {
+				t.Errorf("shares add up to %s, want %s", sum, req.Total)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+)
+
+// Header carrying the grant returned by POST /auth/step-up.
+const StepUpHeader = "X-Step-Up-Token"
+
+// StepUpReq is the body of POST /auth/step-up.
+type StepUpReq struct {
+	ChallengeId string `json:"challenge_id"`
+	Code        string `json:"code"`
+}
+
+// Reports whether the request may go on with action. operation, made by
+// stepup.Operation, says what exactly is approved, so a grant covers this one
+// request and nothing else. Without an unused grant for it in the
+// X-Step-Up-Token header, a code is sent to the user and the response is a 401
+// with the challenge to answer. A nil service disables step-up.
+func requireStepUp(w http.ResponseWriter, r *http.Request, svc *stepup.Service, action, operation string) bool {
+	if svc == nil {
+		return true
+	}
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+	now := time.Now()
+	if svc.Redeem(r.Header.Get(StepUpHeader), claims.UserID, action, operation, now) {
+		return true
+	}
+	if claims.Email == "" {
+		common.RespondWithErrorCode(w, http.StatusForbidden, "step_up_unavailable", "No address to send a confirmation code to")
+		return false
+	}
+
+	challenge, err := svc.Begin(r.Context(), claims.UserID, claims.Email, action, operation, now)
+	if err != nil {
+		log.Println("Error starting step-up challenge:", err)
+		common.RespondWithError(w, http.StatusServiceUnavailable, "Could not send a confirmation code")
+		return false
+	}
+	log.Println("Step-up challenge", challenge.ID, "started for userId:", claims.UserID, "action:", action)
+	common.RespondWithJSON(w, http.StatusUnauthorized, transformers.StepUpChallengeRespJSON(challenge))
+	return false
+}
+
+// PostStepUp handles POST /auth/step-up
+func (h *AuthHandler) PostStepUp(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var reqBody StepUpReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if reqBody.ChallengeId == "" || reqBody.Code == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing 'challenge_id' or 'code'")
+		return
+	}
+	if h.StepUp == nil {
+		common.RespondWithError(w, http.StatusNotFound, "Step-up authentication is disabled")
+		return
+	}
+
+	grant, err := h.StepUp.Verify(r.Context(), reqBody.ChallengeId, claims.UserID, reqBody.Code, time.Now())
+	switch {
+	case errors.Is(err, stepup.ErrNotFound):
+		common.RespondWithError(w, http.StatusNotFound, "Challenge not found or expired")
+	case errors.Is(err, stepup.ErrInvalidCode):
+		common.RespondWithErrorCode(w, http.StatusUnauthorized, "step_up_invalid_code", "Invalid code")
+	case errors.Is(err, stepup.ErrTooManyAttempts):
+		common.RespondWithErrorCode(w, http.StatusTooManyRequests, "step_up_too_many_attempts", "Too many attempts; start a new challenge")
+	case err != nil:
+		log.Println("Error verifying step-up challenge:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+	default:
+		log.Println("Step-up challenge", reqBody.ChallengeId, "passed by userId:", claims.UserID)
+		common.RespondWithJSON(w, http.StatusOK, transformers.StepUpRespJSON(grant))
+	}
+}
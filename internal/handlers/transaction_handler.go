@@ -4,27 +4,78 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/limits"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/pagination"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/timerange"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
 
 	// Import from common-protos
 	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	upb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
 )
 
+// CreateAccountReq is the body of POST /accounts.
+type CreateAccountReq struct {
+	Username string `json:"username"`
+	Bank     bool   `json:"bank"`
+	UserId   string `json:"user_id"`
+}
+
+// TransferReq is the body of POST /transfers.
+type TransferReq struct {
+	FromUser string        `json:"from_user"`
+	ToUser   string        `json:"to_user"`
+	Amount   money.Decimal `json:"amount"`
+	Email    string        `json:"email"`
+	// Currency of Amount; defaults to the account currency. Other currencies
+	// are converted before the transfer.
+	Currency string `json:"currency,omitempty"`
+}
+
+// The transaction service moves whole currency units.
+const transferDecimals = 0
+
 type TransactionHandler struct {
 	TransactionClient *clients.TransactionServiceClient
 	UserProductClient *clients.UserProductServiceClient
+	Cursors           *pagination.Signer
+	Currency          money.Currency
+	Rates             fx.Provider
+	// Oldest exchange rates a cross-currency transfer may use.
+	MaxRateAge time.Duration
+	// Checked before every transfer; nil disables the checks.
+	Limits *limits.Engine
+	// Confirms transfers above its threshold; nil disables step-up.
+	StepUp *stepup.Service
+	Audit  audit.Sink
 }
 
-func NewTransactionHandler(TransactionClient *clients.TransactionServiceClient, userClient *clients.UserProductServiceClient) *TransactionHandler {
+func NewTransactionHandler(TransactionClient *clients.TransactionServiceClient, userClient *clients.UserProductServiceClient, cursors *pagination.Signer, currency money.Currency, rates fx.Provider, maxRateAge time.Duration, limits *limits.Engine, stepUp *stepup.Service, auditSink audit.Sink) *TransactionHandler {
 	return &TransactionHandler{
 		TransactionClient: TransactionClient,
 		UserProductClient: userClient,
+		Cursors:           cursors,
+		Currency:          currency,
+		Rates:             rates,
+		MaxRateAge:        maxRateAge,
+		Limits:            limits,
+		StepUp:            stepUp,
+		Audit:             auditSink,
 	}
 }
 
@@ -37,8 +88,6 @@ func (h *TransactionHandler) GetMovements(w http.ResponseWriter, r *http.Request
 
 	query := r.URL.Query()
 	userId := query.Get("id")
-	fromTimeStr := query.Get("from")
-	toTimeStr := query.Get("to")
 	limitStr := query.Get("lim")
 
 	if userId == "" {
@@ -46,23 +95,12 @@ func (h *TransactionHandler) GetMovements(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var fromTime, toTime uint64
-	var err error
-
-	if fromTimeStr == "" && toTimeStr == "" {
+	rng, err := timerange.Parse(r, "from", "to")
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
-	} else {
-		fromTime, err = strconv.ParseUint(fromTimeStr, 10, 64)
-		if err != nil {
-			common.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' time format")
-			return
-		}
-		toTime, err = strconv.ParseUint(toTimeStr, 10, 64)
-		if err != nil {
-			common.RespondWithError(w, http.StatusBadRequest, "Invalid 'to' time format")
-			return
-		}
 	}
+	fromTime, toTime := rng.Unix()
 
 	limit := false
 	if limitStr != "" {
@@ -73,20 +111,71 @@ func (h *TransactionHandler) GetMovements(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	grpcReq := &pb.GetMovementsRequest{UserId: userId, FromTime: fromTime, ToTime: toTime, Limit: limit}
-	log.Println("GetMovements called with userId:", userId, "fromTime:", fromTime, "toTime:", toTime, "limit:", limit)
+	q := &movementQuery{UserId: userId, From: fromTime, To: toTime, Limit: limit}
+	if err := parseMovementFilters(query, q); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var after *movementCursor
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		after = &movementCursor{}
+		if err := h.Cursors.Decode(movementsCursor, cursorStr, after); err != nil {
+			common.RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		// Default and relative ranges move with the clock; later pages keep the first page's.
+		q.From, q.To = after.From, after.To
+		if after.Query != q.key() {
+			common.RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+	}
+
+	formatter, rate, err := h.responseFormatter(r.Context(), r)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	log.Println("GetMovements called with userId:", userId, "fromTime:", fromTime, "toTime:", toTime, "limit:", limit, "pageSize:", q.PageSize)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
 
-	grpcResp, err := h.TransactionClient.Client.Movements(ctx, grpcReq)
+	// Direction and counterparty filters are relative to the user's username.
+	username := ""
+	if q.needsUsername() {
+		userResp, err := h.UserProductClient.Client.GetUserById(ctx, &upb.GetUserByIdRequest{UserId: userId})
+		if err != nil {
+			common.RespondGrpcError(w, err)
+			return
+		}
+		username = userResp.GetUsername()
+	}
+
+	page, next, grpcResp, err := h.scanMovements(r.Context(), q, after, username)
 	if err != nil {
-		defer cancel()
 		common.RespondGrpcError(w, err)
 		return
 	}
 
-	httpResp := transformers.GetMovementsRespJSON(grpcResp)
+	httpResp := transformers.GetMovementsRespJSON(&pb.GetMovementsResponse{
+		Success:   grpcResp.GetSuccess(),
+		Message:   grpcResp.GetMessage(),
+		Movements: page,
+	}, formatter)
+	httpResp.FX = transformers.FXRateJSON(rate)
+	if next != nil {
+		next.Query = q.key()
+		next.From, next.To = q.From, q.To
+		httpResp.NextCursor, err = h.Cursors.Encode(movementsCursor, next)
+		if err != nil {
+			common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		pagination.SetNextLink(w, r, httpResp.NextCursor)
+	}
 	common.RespondWithJSON(w, http.StatusOK, httpResp)
-	defer cancel()
 }
 
 // PostAccount handles POST /account
@@ -96,11 +185,7 @@ func (h *TransactionHandler) PostAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var reqBody struct {
-		Username string `json:"username"`
-		Bank     bool   `json:"bank"`
-		UserId   string `json:"user_id"`
-	}
+	var reqBody CreateAccountReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -135,22 +220,22 @@ func (h *TransactionHandler) GetBalance(w http.ResponseWriter, r *http.Request)
 
 	query := r.URL.Query()
 	userId := query.Get("user_id")
-	fromTimeStr := query.Get("from_time")
-	toTimeStr := query.Get("to_time")
 
 	if userId == "" {
 		common.RespondWithError(w, http.StatusBadRequest, "Missing user_id")
 		return
 	}
 
-	fromTime, err := strconv.ParseUint(fromTimeStr, 10, 64)
+	rng, err := timerange.Parse(r, "from_time", "to_time")
 	if err != nil {
-		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' time format")
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	toTime, err := strconv.ParseUint(toTimeStr, 10, 64)
+	fromTime, toTime := rng.Unix()
+
+	formatter, rate, err := h.responseFormatter(r.Context(), r)
 	if err != nil {
-		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'to' time format")
+		common.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -164,7 +249,8 @@ func (h *TransactionHandler) GetBalance(w http.ResponseWriter, r *http.Request)
 		common.RespondGrpcError(w, err)
 		return
 	}
-	httpResp := transformers.GetBalanceRespJSON(grpcResp)
+	httpResp := transformers.GetBalanceRespJSON(grpcResp, formatter)
+	httpResp.FX = transformers.FXRateJSON(rate)
 	common.RespondWithJSON(w, http.StatusOK, httpResp)
 	defer cancel()
 }
@@ -176,36 +262,127 @@ func (h *TransactionHandler) PostTransfer(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var reqBody struct {
-		FromUser string `json:"from_user"`
-		ToUser   string `json:"to_user"`
-		Amount   uint64 `json:"amount"`
-		Email    string `json:"email"`
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
 
+	var reqBody TransferReq
+
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	// Limits and step-up apply to the caller, so they can only send their own money.
+	if reqBody.FromUser != claims.UserID {
+		common.RespondWithError(w, http.StatusForbidden, "Users can only transfer from their own account")
+		return
+	}
 
 	// Get user email from user service
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 
+	charged, rate, err := h.transferAmount(ctx, reqBody)
+	if err != nil {
+		defer cancel()
+		respondTransferAmountError(w, err)
+		return
+	}
+	amount, err := charged.Units(transferDecimals, math.MaxInt64)
+	if err != nil {
+		defer cancel()
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'amount': "+err.Error())
+		return
+	}
+	// An omitted amount decodes as zero.
+	if charged.Sign() <= 0 {
+		defer cancel()
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'amount': must be positive")
+		return
+	}
+	if err := h.checkLimits(r.Context(), claims.UserID, reqBody.ToUser, charged); err != nil {
+		defer cancel()
+		respondLimitError(w, err)
+		return
+	}
+	if !h.requireTransferStepUp(w, r, claims.UserID, charged, "transfer", reqBody.ToUser, reqBody.Amount.String(), reqBody.Currency) {
+		defer cancel()
+		return
+	}
+
 	grpcReq := &pb.TransferFundsRequest{
-		FromUserId:    reqBody.FromUser,
+		FromUserId:    claims.UserID,
 		ToUserId:      reqBody.ToUser,
-		Amount:        reqBody.Amount,
+		Amount:        uint64(amount),
 		FromUserEmail: reqBody.Email,
 	}
 
 	grpcResp, err := h.TransactionClient.Client.Transfer(ctx, grpcReq)
+	h.auditTransfer(ctx, claims.UserID, grpcReq, grpcResp, err, map[string]string{"via": "transfer"})
 	if err != nil {
 		defer cancel()
 		common.RespondGrpcError(w, err)
 		return
 	}
 
-	httpResp := transformers.TransferFundsRespJSON(grpcResp)
+	httpResp := transformers.TransferFundsRespJSON(grpcResp, money.Money{Amount: charged, Currency: h.Currency}, rate)
 	common.RespondWithJSON(w, http.StatusCreated, httpResp)
 	defer cancel()
 }
+
+// Asks for step-up when a transfer of amount (in the account currency) from
+// fromUser needs it. Grants belong to the caller, so one never covers a
+// transfer from someone else's account, and to the operation described by
+// parts (recipient, amount as requested, ...), so one never covers another.
+func (h *TransactionHandler) requireTransferStepUp(w http.ResponseWriter, r *http.Request, fromUser string, amount money.Decimal, parts ...string) bool {
+	if h.StepUp == nil || !h.StepUp.TransferNeedsStepUp(amount) {
+		return true
+	}
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); !ok || claims.UserID != fromUser {
+		common.RespondWithError(w, http.StatusForbidden, "Users can only transfer from their own account")
+		return false
+	}
+	return requireStepUp(w, r, h.StepUp, stepup.ActionTransfer, stepup.Operation(parts...))
+}
+
+// Records a Transfer call made for actor, with its outcome and details
+// saying what it was made for.
+func (h *TransactionHandler) auditTransfer(ctx context.Context, actor string, req *pb.TransferFundsRequest, resp *pb.TransferFundsResponse, err error, details map[string]string) {
+	e := audit.Event{
+		Actor:   actor,
+		Action:  "transfer.create",
+		Target:  req.GetFromUserId(),
+		Outcome: audit.OutcomeSuccess,
+		Details: details,
+	}
+	e.Details["to_user_id"] = req.GetToUserId()
+	e.Details["amount"] = strconv.FormatUint(req.GetAmount(), 10)
+	e.Details["currency"] = h.Currency.Code
+	switch {
+	case err != nil:
+		e.Outcome = audit.OutcomeFailure
+		e.Details["error"] = err.Error()
+		if !transferNotMade(err) {
+			// The transaction service may have made it anyway.
+			e.Details["uncertain"] = "true"
+		}
+	case !resp.GetSuccess():
+		e.Outcome = audit.OutcomeFailure
+		e.Details["error"] = resp.GetMessage()
+	default:
+		e.Details["transfer_id"] = resp.GetTransferId()
+	}
+	audit.Record(ctx, h.Audit, e)
+}
+
+// Reports whether a failed Transfer call certainly moved no money. Timeouts
+// and unexpected errors may have reached the transaction service.
+func transferNotMade(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.InvalidArgument, codes.NotFound,
+		codes.FailedPrecondition, codes.PermissionDenied, codes.Unauthenticated:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+func TestPostTransferRejectsZeroAmount(t *testing.T) {
+	usd, err := money.LookupCurrency("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No transaction client: a request that gets past validation panics.
+	h := &TransactionHandler{Currency: usd}
+	for name, body := range map[string]string{
+		"omitted":  `{"from_user": "u1", "to_user": "u2"}`,
+		"zero":     `{"from_user": "u1", "to_user": "u2", "amount": "0"}`,
+		"zero.00":  `{"from_user": "u1", "to_user": "u2", "amount": "0.00"}`,
+		"negative": `{"from_user": "u1", "to_user": "u2", "amount": "-5"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/transfer", strings.NewReader(body))
+			req = req.WithContext(context.WithValue(req.Context(), "tokenClaims", &middleware.TokenClaims{UserID: "u1"}))
+			rec := httptest.NewRecorder()
+			h.PostTransfer(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+		})
+	}
+}
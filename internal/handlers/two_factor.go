@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
+)
+
+// TwoFactorCodeReq is the body of the endpoints checking a TOTP or recovery code.
+type TwoFactorCodeReq struct {
+	Code string `json:"code"`
+}
+
+// LoginTwoFactorReq is the body of POST /login/2fa.
+type LoginTwoFactorReq struct {
+	PartialToken string `json:"partial_token"`
+	// TOTP code or recovery code.
+	Code string `json:"code"`
+}
+
+// PostLoginTwoFactor handles POST /login/2fa
+func (h *AuthHandler) PostLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var reqBody LoginTwoFactorReq
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if reqBody.PartialToken == "" || reqBody.Code == "" {
+		common.RespondWithError(w, http.StatusBadRequest, "Missing 'partial_token' or 'code'")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	token, err := h.TwoFactor.FinishLogin(ctx, reqBody.PartialToken, reqBody.Code, time.Now())
+	if err != nil {
+		h.auditLogin(ctx, "", audit.OutcomeFailure, map[string]string{"second_factor": "failed", "error": err.Error()})
+		respondTwoFactorError(w, err)
+		return
+	}
+	h.auditLogin(ctx, tokenUserID(token), audit.OutcomeSuccess, map[string]string{"second_factor": "passed"})
+	if !h.startSession(r, token) {
+		common.RespondWithError(w, http.StatusServiceUnavailable, "Could not start the session")
+		return
+	}
+	setAccessTokenCookie(w, token)
+	common.RespondWithJSON(w, http.StatusOK, transformers.LoginResp{Success: true, Message: "Login successful", Data: token})
+}
+
+// GetTwoFactor handles GET /me/2fa
+func (h *AuthHandler) GetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	enrollment, err := h.TwoFactor.Status(ctx, claims.UserID)
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.TwoFactorStatusRespJSON(enrollment))
+}
+
+// EnrollTOTP handles POST /me/2fa/totp
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	account := claims.Email
+	if account == "" {
+		account = claims.Username
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	enrollment, uri, err := h.TwoFactor.Enroll(ctx, claims.UserID, account, time.Now())
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+	log.Println("EnrollTOTP called with userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusCreated, transformers.TOTPEnrollRespJSON(enrollment, uri))
+}
+
+// ConfirmTOTP handles POST /me/2fa/totp/confirm
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, reqBody, ok := twoFactorCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.TwoFactor.Confirm(ctx, claims.UserID, reqBody.Code, time.Now())
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+	log.Println("ConfirmTOTP: two-factor authentication enabled for userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusOK, transformers.RecoveryCodesRespJSON(codes, "Two-factor authentication enabled"))
+}
+
+// DisableTOTP handles DELETE /me/2fa/totp
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, reqBody, ok := twoFactorCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.TwoFactor.Disable(ctx, claims.UserID, reqBody.Code, time.Now()); err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+	log.Println("DisableTOTP: two-factor authentication disabled for userId:", claims.UserID)
+	common.RespondWithJSON(w, http.StatusOK, transformers.TwoFactorStatusRespJSON(nil))
+}
+
+// RegenerateRecoveryCodes handles POST /me/2fa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, reqBody, ok := twoFactorCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.TwoFactor.RegenerateRecoveryCodes(ctx, claims.UserID, reqBody.Code, time.Now())
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, transformers.RecoveryCodesRespJSON(codes, "Recovery codes replaced"))
+}
+
+// Reads the caller's claims and a body with a code; false when it answered already.
+func twoFactorCodeRequest(w http.ResponseWriter, r *http.Request) (*middleware.TokenClaims, TwoFactorCodeReq, bool) {
+	var reqBody TwoFactorCodeReq
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, reqBody, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return nil, reqBody, false
+	}
+	return claims, reqBody, true
+}
+
+func respondTwoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		common.RespondWithErrorCode(w, http.StatusUnauthorized, "two_factor_invalid_code", "Invalid code")
+	case errors.Is(err, twofactor.ErrTooManyAttempts):
+		common.RespondWithErrorCode(w, http.StatusTooManyRequests, "two_factor_too_many_attempts", "Too many attempts; log in again")
+	case errors.Is(err, twofactor.ErrLoginNotFound):
+		common.RespondWithError(w, http.StatusUnauthorized, "Login not found or expired; log in again")
+	case errors.Is(err, twofactor.ErrAlreadyEnabled):
+		common.RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+	case errors.Is(err, twofactor.ErrNotEnabled):
+		common.RespondWithError(w, http.StatusConflict, "Two-factor authentication is not enabled")
+	default:
+		log.Println("Two-factor error:", err)
+		common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
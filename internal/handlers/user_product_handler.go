@@ -3,15 +3,22 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
 
 	// Import from common-protos
@@ -20,17 +27,78 @@ import (
 	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
 )
 
+// CreateUserReq is the body of POST /users.
+type CreateUserReq struct {
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	CodeId    string `json:"code_id"`
+	Phone     string `json:"phone"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Birthdate string `json:"birthdate"`
+}
+
+// UpdateUserReq is the body of PUT /users/{user_id}.
+type UpdateUserReq struct {
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Phone     string `json:"phone"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Birthdate string `json:"birthdate"`
+}
+
+// CreateFavoriteReq is the body of POST /users/{user_id}/favorites.
+type CreateFavoriteReq struct {
+	FavoriteUserId string `json:"favorite_user_id"`
+	Alias          string `json:"alias"`
+}
+
+// UpdateFavoriteReq is the body of PUT /users/{user_id}/favorites/{favorite_id}.
+type UpdateFavoriteReq struct {
+	Alias string `json:"alias"`
+}
+
+// CreatePocketReq is the body of POST /users/{user_id}/pockets.
+type CreatePocketReq struct {
+	Username  string        `json:"username"`
+	Name      string        `json:"name"`
+	Category  string        `json:"category"`
+	MaxAmount money.Decimal `json:"max_amount"`
+}
+
+// UpdatePocketReq is the body of PUT /users/{user_id}/pockets/{pocket_id}.
+type UpdatePocketReq struct {
+	Name      string        `json:"name"`
+	Category  string        `json:"category"`
+	MaxAmount money.Decimal `json:"max_amount"`
+}
+
+// Pocket budgets are whole currency units stored as int32.
+const pocketDecimals = 0
+
+// UpdateVerificationReq is the body of PUT /users/{user_id}/verifications.
+type UpdateVerificationReq struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
 type UserProductHandler struct {
 	UserProductClient *clients.UserProductServiceClient
 	TransactionClient *clients.TransactionServiceClient
 	AuthClient        *clients.AuthServiceClient
+	StepUp            *stepup.Service
+	Audit             audit.Sink
 }
 
-func NewUserProductHandler(userClient *clients.UserProductServiceClient, transactionClient *clients.TransactionServiceClient, authClient *clients.AuthServiceClient) *UserProductHandler {
+func NewUserProductHandler(userClient *clients.UserProductServiceClient, transactionClient *clients.TransactionServiceClient, authClient *clients.AuthServiceClient, stepUp *stepup.Service, auditSink audit.Sink) *UserProductHandler {
 	return &UserProductHandler{
 		UserProductClient: userClient,
 		TransactionClient: transactionClient,
 		AuthClient:        authClient,
+		StepUp:            stepUp,
+		Audit:             auditSink,
 	}
 }
 
@@ -60,16 +128,7 @@ func (h *UserProductHandler) CreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var reqBody struct {
-		Email     string `json:"email"`
-		Username  string `json:"username"`
-		Password  string `json:"password"`
-		CodeId    string `json:"code_id"`
-		Phone     string `json:"phone"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Birthdate string `json:"birthdate"`
-	}
+	var reqBody CreateUserReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -219,20 +278,41 @@ func (h *UserProductHandler) UpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var reqBody struct {
-		Email     string `json:"email"`
-		Username  string `json:"username"`
-		Phone     string `json:"phone"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Birthdate string `json:"birthdate"`
-	}
+	var reqBody UpdateUserReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if claims.UserID != userID {
+		common.RespondWithError(w, http.StatusForbidden, "Users can only update their own profile")
+		return
+	}
+
+	// Changing where codes and notices go needs confirming through the current
+	// address, compared with the stored profile rather than the token, which
+	// may be older.
+	if reqBody.Email != "" || reqBody.Phone != "" {
+		lookupCtx, lookupCancel := context.WithTimeout(r.Context(), 5*time.Second)
+		current, err := h.UserProductClient.Client.GetUserById(lookupCtx, &pb.GetUserByIdRequest{UserId: userID})
+		lookupCancel()
+		if err != nil {
+			common.RespondGrpcError(w, err)
+			return
+		}
+		if (reqBody.Email != "" && reqBody.Email != current.GetEmail()) || (reqBody.Phone != "" && reqBody.Phone != current.GetPhone()) {
+			if !requireStepUp(w, r, h.StepUp, stepup.ActionUpdateContact, stepup.Operation(userID, reqBody.Email, reqBody.Phone)) {
+				return
+			}
+		}
+	}
+
 	grpcReq := &pb.UpdateUserByIdRequest{ // Corrected struct name.
 		Id:        userID, // Use the user_id from the path.
 		Email:     reqBody.Email,
@@ -246,6 +326,7 @@ func (h *UserProductHandler) UpdateUser(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 
 	grpcResp, err := h.UserProductClient.Client.UpdateUserById(ctx, grpcReq) // Corrected method name
+	h.auditUserChange(r, "user.update", userID, err, map[string]string{"fields": strings.Join(updatedUserFields(reqBody), ",")})
 	if err != nil {
 		defer cancel()
 		common.RespondGrpcError(w, err)
@@ -257,32 +338,34 @@ func (h *UserProductHandler) UpdateUser(w http.ResponseWriter, r *http.Request)
 	defer cancel()
 }
 
-// DeleteUser handles DELETE /users/{user_id}
-func (h *UserProductHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		common.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+// Records a change made to userID by the caller of r.
+func (h *UserProductHandler) auditUserChange(r *http.Request, action, userID string, err error, details map[string]string) {
+	e := audit.Event{Action: action, Target: userID, Outcome: audit.OutcomeSuccess, Details: details}
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		e.Actor = claims.UserID
 	}
-	vars := mux.Vars(r)
-	userID := vars["user_id"]
-	if userID == "" {
-		common.RespondWithError(w, http.StatusBadRequest, "Missing user_id")
-		return
-	}
-
-	grpcReq := &pb.DeleteUserByIdRequest{Id: userID} // Corrected struct.
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-
-	grpcResp, err := h.UserProductClient.Client.DeleteUserById(ctx, grpcReq) // Corrected method.  Check the return.
 	if err != nil {
-		defer cancel()
-		common.RespondGrpcError(w, err)
-		return
+		e.Outcome = audit.OutcomeFailure
+		if e.Details == nil {
+			e.Details = make(map[string]string)
+		}
+		e.Details["error"] = err.Error()
 	}
+	audit.Record(r.Context(), h.Audit, e)
+}
 
-	httpResp := transformers.DeleteUserRespJSON(grpcResp) // Create this function in transformers.go
-	common.RespondWithJSON(w, http.StatusOK, httpResp)
-	defer cancel()
+// Names of the fields an update sets; values stay out of the audit trail.
+func updatedUserFields(req UpdateUserReq) []string {
+	var fields []string
+	for _, f := range []struct{ name, value string }{
+		{"email", req.Email}, {"username", req.Username}, {"phone", req.Phone},
+		{"first_name", req.FirstName}, {"last_name", req.LastName}, {"birthdate", req.Birthdate},
+	} {
+		if f.value != "" {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
 }
 
 // GetFavoritesByUserId handles GET /users/{user_id}/favorites
@@ -326,10 +409,7 @@ func (h *UserProductHandler) CreateFavorite(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var reqBody struct {
-		FavoriteUserId string `json:"favorite_user_id"`
-		Alias          string `json:"alias"`
-	}
+	var reqBody CreateFavoriteReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -370,9 +450,7 @@ func (h *UserProductHandler) UpdateFavorite(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var reqBody struct {
-		Alias string `json:"alias"`
-	}
+	var reqBody UpdateFavoriteReq
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
@@ -470,23 +548,23 @@ func (h *UserProductHandler) CreatePocket(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var reqBody struct {
-		Username  string `json:"username"`
-		Name      string `json:"name"`
-		Category  string `json:"category"`
-		MaxAmount int32  `json:"max_amount"`
-	}
+	var reqBody CreatePocketReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	maxAmount, err := reqBody.MaxAmount.Units(pocketDecimals, math.MaxInt32)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'max_amount': "+err.Error())
+		return
+	}
 
 	grpcReqUS := &pb.CreatePocketRequest{
 		UserId:    userID,
 		Name:      reqBody.Name,
 		Category:  reqBody.Category,
-		MaxAmount: reqBody.MaxAmount,
+		MaxAmount: int32(maxAmount),
 	}
 
 	grpcReqTB := &tb.CreateAccountRequest{
@@ -554,21 +632,22 @@ func (h *UserProductHandler) UpdatePocket(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var reqBody struct {
-		Name      string `json:"name"`
-		Category  string `json:"category"`
-		MaxAmount int32  `json:"max_amount"`
-	}
+	var reqBody UpdatePocketReq
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	maxAmount, err := reqBody.MaxAmount.Units(pocketDecimals, math.MaxInt32)
+	if err != nil {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'max_amount': "+err.Error())
+		return
+	}
 
 	grpcReq := &pb.UpdatePocketByIdRequest{
 		Id:        pocketID,
 		Name:      reqBody.Name,
 		Category:  reqBody.Category,
-		MaxAmount: reqBody.MaxAmount,
+		MaxAmount: int32(maxAmount),
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -665,34 +744,82 @@ func (h *UserProductHandler) UpdateVerificationByUserId(w http.ResponseWriter, r
 		return
 	}
 
-	var reqBody struct {
-		Type   string `json:"type"`
-		Status string `json:"status"`
-	}
+	var reqBody UpdateVerificationReq
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		common.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	verificationType := strings.ToLower(strings.TrimSpace(reqBody.Type))
+	newStatus := normalizeVerificationStatus(reqBody.Status)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// The route requires the verifications:write scope; users reach COMPLETE
+	// through the flows that prove it (e.g. the emailed link).
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok || claims.UserID == "" {
+		common.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	event := audit.Event{
+		Time:    time.Now(),
+		Actor:   claims.UserID,
+		Action:  "verification.update",
+		Target:  userID,
+		Details: map[string]string{"type": verificationType, "status": newStatus},
+	}
+	if !verificationTypes[verificationType] {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'type': must be email, phone or identity")
+		return
+	}
+	if !verificationStatuses[newStatus] {
+		common.RespondWithError(w, http.StatusBadRequest, "Invalid 'status': must be PENDING, COMPLETE or REJECTED")
+		return
+	}
+
+	current, err := h.UserProductClient.Client.GetVerificationsByUserId(ctx, &pb.GetVerificationsByUserIdRequest{UserId: userID})
+	if err != nil {
+		common.RespondGrpcError(w, err)
+		return
+	}
+	oldStatus := ""
+	for _, v := range current.GetVerifications() {
+		if strings.EqualFold(v.GetType(), verificationType) {
+			oldStatus = normalizeVerificationStatus(v.GetStatus())
+		}
+	}
+	event.Details["from"] = oldStatus
+	if !verificationTransitionAllowed(oldStatus, newStatus) {
+		event.Outcome = audit.OutcomeDenied
+		audit.Record(ctx, h.Audit, event)
+		from := oldStatus
+		if from == "" {
+			from = "none"
+		}
+		common.RespondWithErrorCode(w, http.StatusConflict, "verification_transition_invalid",
+			fmt.Sprintf("Cannot change a %s verification from %s to %s", verificationType, from, newStatus))
+		return
+	}
 
 	grpcReq := &pb.UpdateVerificationByUserIdRequest{
 		UserId: userID,
-		Type:   reqBody.Type,
-		Status: reqBody.Status,
+		Type:   verificationType,
+		Status: newStatus,
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-
 	grpcResp, err := h.UserProductClient.Client.UpdateVerificationByUserId(ctx, grpcReq)
 	if err != nil {
-		defer cancel()
-
+		event.Outcome = audit.OutcomeFailure
+		audit.Record(ctx, h.Audit, event)
 		common.RespondGrpcError(w, err)
 		return
 	}
+	event.Outcome = audit.OutcomeSuccess
+	audit.Record(ctx, h.Audit, event)
+	log.Println("UpdateVerificationByUserId: userId:", userID, "type:", verificationType, oldStatus, "->", newStatus, "by:", claims.UserID)
 
 	httpResp := transformers.UpdateVerificationRespJSON(grpcResp)
 	common.RespondWithJSON(w, http.StatusOK, httpResp)
-	defer cancel()
-
 }
@@ -0,0 +1,53 @@
+package handlers
+
+import "strings"
+
+// Verification types the user-product service keeps.
+const (
+	verificationEmail    = "email"
+	verificationPhone    = "phone"
+	verificationIdentity = "identity"
+)
+
+// Verification statuses as the user-product service stores them. COMPLETE is
+// what counts as verified.
+const (
+	verificationPending  = "PENDING"
+	verificationComplete = "COMPLETE"
+	verificationRejected = "REJECTED"
+)
+
+var verificationTypes = map[string]bool{
+	verificationEmail:    true,
+	verificationPhone:    true,
+	verificationIdentity: true,
+}
+
+var verificationStatuses = map[string]bool{
+	verificationPending:  true,
+	verificationComplete: true,
+	verificationRejected: true,
+}
+
+// Statuses a verification can move to from each status; "" is a
+// verification that doesn't exist yet. A rejected one can be resubmitted;
+// a complete one is final.
+var verificationTransitions = map[string][]string{
+	"":                   {verificationPending},
+	verificationPending:  {verificationComplete, verificationRejected},
+	verificationRejected: {verificationPending},
+}
+
+func verificationTransitionAllowed(from, to string) bool {
+	for _, next := range verificationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Canonical form of a status as read from the user-product service.
+func normalizeVerificationStatus(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
@@ -0,0 +1,147 @@
+package insights
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+
+	upb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// Income and outcome of one calendar month.
+type Month struct {
+	Start   time.Time
+	Income  money.Decimal
+	Outcome money.Decimal
+}
+
+// Totals exchanged with one other user.
+type Counterparty struct {
+	Username string
+	Income   money.Decimal
+	Outcome  money.Decimal
+	Count    int
+}
+
+// Volume is what was exchanged in either direction.
+func (c *Counterparty) Volume() money.Decimal {
+	return c.Income.Add(c.Outcome)
+}
+
+// Pocket budgets sharing a category.
+type Category struct {
+	Name    string
+	Pockets int
+	Budget  money.Decimal
+}
+
+// Aggregates a user's movements over a period, month by month in the
+// period's time zone.
+type Report struct {
+	Income  money.Decimal
+	Outcome money.Decimal
+	Months  []*Month
+
+	counterparties map[string]*Counterparty
+}
+
+// Returns an empty report with one entry per month touched by [from, to].
+func NewReport(from, to time.Time) *Report {
+	r := &Report{counterparties: make(map[string]*Counterparty)}
+	loc := from.Location()
+	to = to.In(loc)
+	for m := monthStart(from); !m.After(to); m = m.AddDate(0, 1, 0) {
+		r.Months = append(r.Months, &Month{Start: m})
+	}
+	return r
+}
+
+// Records a movement; amount is signed, negative for outgoing movements.
+// Movements outside the report's months are ignored.
+func (r *Report) Add(at time.Time, counterparty string, amount money.Decimal) {
+	month := r.month(at)
+	if month == nil {
+		return
+	}
+
+	key := strings.ToLower(counterparty)
+	c, ok := r.counterparties[key]
+	if !ok {
+		c = &Counterparty{Username: counterparty}
+		r.counterparties[key] = c
+	}
+	c.Count++
+
+	if amount.Sign() >= 0 {
+		month.Income = month.Income.Add(amount)
+		r.Income = r.Income.Add(amount)
+		c.Income = c.Income.Add(amount)
+		return
+	}
+	spent := amount.Neg()
+	month.Outcome = month.Outcome.Add(spent)
+	r.Outcome = r.Outcome.Add(spent)
+	c.Outcome = c.Outcome.Add(spent)
+}
+
+func (r *Report) month(at time.Time) *Month {
+	if len(r.Months) == 0 {
+		return nil
+	}
+	start := monthStart(at.In(r.Months[0].Start.Location()))
+	for _, m := range r.Months {
+		if m.Start.Equal(start) {
+			return m
+		}
+	}
+	return nil
+}
+
+// The n counterparties with the largest volume, largest first.
+func (r *Report) TopCounterparties(n int) []*Counterparty {
+	all := make([]*Counterparty, 0, len(r.counterparties))
+	for _, c := range r.counterparties {
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if cmp := all[i].Volume().Cmp(all[j].Volume()); cmp != 0 {
+			return cmp > 0
+		}
+		return all[i].Username < all[j].Username
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+// Groups pockets by category, summing their budgets; categories are sorted by name.
+func Categories(pockets []*upb.Pocket) []*Category {
+	byName := make(map[string]*Category)
+	for _, p := range pockets {
+		name := p.GetCategory()
+		if name == "" {
+			name = "uncategorized"
+		}
+		c, ok := byName[name]
+		if !ok {
+			c = &Category{Name: name}
+			byName[name] = c
+		}
+		c.Pockets++
+		c.Budget = c.Budget.Add(money.FromInt(int64(p.GetMaxAmount())))
+	}
+
+	categories := make([]*Category, 0, len(byName))
+	for _, c := range byName {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,270 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+// Codes of the rule a transfer broke.
+const (
+	CodePerTransaction = "limit_per_transaction"
+	CodeDaily          = "limit_daily"
+	CodeMonthly        = "limit_monthly"
+	CodeNewRecipient   = "limit_new_recipient"
+)
+
+// A transfer rejected by a rule. Remaining is what could still be sent under it.
+type Violation struct {
+	Code      string
+	Tier      string
+	Limit     money.Decimal
+	Remaining money.Decimal
+	Message   string
+}
+
+func (v *Violation) Error() string { return v.Message }
+
+// Limits of a verification tier; nil means unlimited.
+type Tier struct {
+	Name           string
+	Requires       []string
+	PerTransaction *money.Decimal
+	Daily          *money.Decimal
+	Monthly        *money.Decimal
+}
+
+// Evaluates transfers against the configured limits.
+type Engine struct {
+	Location       *time.Location
+	VerifiedStatus string
+	Tiers          []Tier
+	// New recipient rule; disabled when MaxAmount is nil.
+	Lookback   time.Duration
+	CoolingOff time.Duration
+	MaxAmount  *money.Decimal
+}
+
+type rulesFile struct {
+	TimeZone       string `yaml:"time_zone"`
+	VerifiedStatus string `yaml:"verified_status"`
+	Tiers          []struct {
+		Name           string   `yaml:"name"`
+		Requires       []string `yaml:"requires"`
+		PerTransaction string   `yaml:"per_transaction"`
+		Daily          string   `yaml:"daily"`
+		Monthly        string   `yaml:"monthly"`
+	} `yaml:"tiers"`
+	NewRecipient struct {
+		Lookback   string `yaml:"lookback"`
+		CoolingOff string `yaml:"cooling_off"`
+		MaxAmount  string `yaml:"max_amount"`
+	} `yaml:"new_recipient"`
+}
+
+// Loads the rules from a YAML or JSON file; see config/transfer_limits.yaml.
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Engine, error) {
+	var f rulesFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing transfer limits: %w", err)
+	}
+
+	var problems []error
+	amount := func(where, s string) *money.Decimal {
+		if s == "" {
+			return nil
+		}
+		d, err := money.Parse(s)
+		if err == nil && d.Sign() <= 0 {
+			err = errors.New("must be positive")
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("transfer limits: %s: %w", where, err))
+			return nil
+		}
+		return &d
+	}
+	duration := func(where, s string) time.Duration {
+		if s == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("transfer limits: %s: %w", where, err))
+		}
+		return d
+	}
+
+	e := &Engine{Location: time.UTC, VerifiedStatus: f.VerifiedStatus}
+	if f.TimeZone != "" {
+		loc, err := time.LoadLocation(f.TimeZone)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("transfer limits: time_zone: %w", err))
+		} else {
+			e.Location = loc
+		}
+	}
+	for i, t := range f.Tiers {
+		where := fmt.Sprintf("tiers[%d]", i)
+		if t.Name == "" {
+			problems = append(problems, fmt.Errorf("transfer limits: %s: missing name", where))
+		}
+		e.Tiers = append(e.Tiers, Tier{
+			Name:           t.Name,
+			Requires:       t.Requires,
+			PerTransaction: amount(where+".per_transaction", t.PerTransaction),
+			Daily:          amount(where+".daily", t.Daily),
+			Monthly:        amount(where+".monthly", t.Monthly),
+		})
+	}
+	e.Lookback = duration("new_recipient.lookback", f.NewRecipient.Lookback)
+	e.CoolingOff = duration("new_recipient.cooling_off", f.NewRecipient.CoolingOff)
+	e.MaxAmount = amount("new_recipient.max_amount", f.NewRecipient.MaxAmount)
+	if e.MaxAmount != nil && (e.Lookback <= 0 || e.CoolingOff <= 0) {
+		problems = append(problems, errors.New("transfer limits: new_recipient needs a positive lookback and cooling_off"))
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A past outgoing transfer of the sender.
+type Sent struct {
+	At     time.Time
+	To     string
+	Amount money.Decimal
+}
+
+// What the rules need to know about a transfer.
+type Transfer struct {
+	Amount money.Decimal
+	// Recipient, as it appears in History.
+	To string
+	// Types of the sender's verifications that have the verified status.
+	Verified []string
+	// Sender's outgoing transfers since Since(Now), in any order.
+	History []Sent
+	Now     time.Time
+}
+
+// Earliest transfer the rules look at for a transfer made at now.
+func (e *Engine) Since(now time.Time) time.Time {
+	local := now.In(e.Location)
+	since := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, e.Location)
+	if e.MaxAmount != nil && now.Add(-e.Lookback).Before(since) {
+		since = now.Add(-e.Lookback)
+	}
+	return since
+}
+
+// Whether a verification status counts as verified.
+func (e *Engine) IsVerified(status string) bool {
+	return strings.EqualFold(status, e.VerifiedStatus)
+}
+
+// Tier of a sender with the given verified types; nil when no tier applies.
+func (e *Engine) Tier(verified []string) *Tier {
+	done := make(map[string]bool, len(verified))
+	for _, v := range verified {
+		done[strings.ToLower(v)] = true
+	}
+	var tier *Tier
+	for i := range e.Tiers {
+		ok := true
+		for _, req := range e.Tiers[i].Requires {
+			if !done[strings.ToLower(req)] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			tier = &e.Tiers[i]
+		}
+	}
+	return tier
+}
+
+// First rule t breaks, checked in order: per transaction, daily, monthly,
+// new recipient. Nil when the transfer is allowed.
+func (e *Engine) Check(t Transfer) *Violation {
+	local := t.Now.In(e.Location)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, e.Location)
+	monthStart := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, e.Location)
+
+	var today, month, toRecipient money.Decimal
+	var firstToRecipient time.Time
+	for _, s := range t.History {
+		if !s.At.Before(dayStart) {
+			today = today.Add(s.Amount)
+		}
+		if !s.At.Before(monthStart) {
+			month = month.Add(s.Amount)
+		}
+		if s.To == t.To && t.Now.Sub(s.At) <= e.Lookback {
+			toRecipient = toRecipient.Add(s.Amount)
+			if firstToRecipient.IsZero() || s.At.Before(firstToRecipient) {
+				firstToRecipient = s.At
+			}
+		}
+	}
+
+	if tier := e.Tier(t.Verified); tier != nil {
+		if v := exceeds(CodePerTransaction, tier, tier.PerTransaction, money.Decimal{}, t.Amount, "Amount above the per-transfer limit"); v != nil {
+			return v
+		}
+		if v := exceeds(CodeDaily, tier, tier.Daily, today, t.Amount, "Daily transfer limit reached"); v != nil {
+			return v
+		}
+		if v := exceeds(CodeMonthly, tier, tier.Monthly, month, t.Amount, "Monthly transfer limit reached"); v != nil {
+			return v
+		}
+	}
+
+	if e.MaxAmount != nil {
+		// New recipient, or still within the cooling-off of the first transfer to them.
+		if firstToRecipient.IsZero() || t.Now.Sub(firstToRecipient) < e.CoolingOff {
+			if v := exceeds(CodeNewRecipient, nil, e.MaxAmount, toRecipient, t.Amount, "Limit for a new recipient reached; try again after the cooling-off period"); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// Violation when used plus amount goes over limit.
+func exceeds(code string, tier *Tier, limit *money.Decimal, used, amount money.Decimal, message string) *Violation {
+	if limit == nil || used.Add(amount).Cmp(*limit) <= 0 {
+		return nil
+	}
+	remaining := limit.Sub(used)
+	if remaining.Sign() < 0 {
+		remaining = money.FromInt(0)
+	}
+	v := &Violation{Code: code, Limit: *limit, Remaining: remaining}
+	if tier != nil {
+		v.Tier = tier.Name
+		message += " for " + tier.Name + " accounts"
+	}
+	if code == CodePerTransaction {
+		v.Message = fmt.Sprintf("%s (limit %s)", message, limit)
+	} else {
+		v.Message = fmt.Sprintf("%s (limit %s, %s left)", message, limit, remaining)
+	}
+	return v
+}
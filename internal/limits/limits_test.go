@@ -0,0 +1,176 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+const testRules = `
+time_zone: America/Bogota
+verified_status: COMPLETE
+tiers:
+  - name: unverified
+    requires: []
+    per_transaction: "500"
+    daily: "1000"
+    monthly: "3000"
+  - name: email_verified
+    requires: [email]
+    per_transaction: "2000"
+    daily: "5000"
+  - name: fully_verified
+    requires: [email, phone]
+new_recipient:
+  lookback: 720h
+  cooling_off: 24h
+  max_amount: "300"
+`
+
+func testEngine(t *testing.T) *Engine {
+	t.Helper()
+	e, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return e
+}
+
+func TestCheck(t *testing.T) {
+	e := testEngine(t)
+	// 15:00 on March 14 in Bogota (UTC-5).
+	now := time.Date(2025, time.March, 14, 20, 0, 0, 0, time.UTC)
+	sent := func(ago time.Duration, to, amount string) Sent {
+		return Sent{At: now.Add(-ago), To: to, Amount: money.MustParse(amount)}
+	}
+	// Sent to "known" long enough ago to be past the cooling-off.
+	known := sent(10*24*time.Hour, "known", "1")
+
+	tests := []struct {
+		name      string
+		amount    string
+		to        string
+		verified  []string
+		history   []Sent
+		code      string
+		tier      string
+		remaining string
+	}{
+		{name: "within every limit", amount: "500", to: "known", history: []Sent{known}},
+		{name: "per transaction", amount: "501", to: "known", history: []Sent{known}, code: CodePerTransaction, tier: "unverified", remaining: "500"},
+		{name: "per transaction of a higher tier", amount: "2000", to: "known", verified: []string{"email"}, history: []Sent{known}},
+		{name: "above the higher tier", amount: "2001", to: "known", verified: []string{"EMAIL"}, history: []Sent{known}, code: CodePerTransaction, tier: "email_verified", remaining: "2000"},
+		{name: "unlimited tier", amount: "1000000", to: "known", verified: []string{"email", "phone"}, history: []Sent{known}},
+		{
+			name: "daily cap", amount: "200", to: "known",
+			history: []Sent{known, sent(time.Hour, "known", "450"), sent(2*time.Hour, "known", "400")},
+			code:    CodeDaily, tier: "unverified", remaining: "150",
+		},
+		{
+			// 16 hours ago is before midnight in Bogota, so it counts for the
+			// month but not for the day.
+			name: "daily cap starts at local midnight", amount: "200", to: "known",
+			history: []Sent{known, sent(16*time.Hour, "known", "850")},
+		},
+		{
+			name: "daily cap used up", amount: "1", to: "known",
+			history: []Sent{known, sent(time.Hour, "known", "999"), sent(2*time.Hour, "known", "1")},
+			code:    CodeDaily, tier: "unverified", remaining: "0",
+		},
+		{
+			name: "monthly cap", amount: "100", to: "known",
+			history: []Sent{known, sent(2*24*time.Hour, "known", "1000"), sent(3*24*time.Hour, "known", "1000"), sent(4*24*time.Hour, "known", "950")},
+			code:    CodeMonthly, tier: "unverified", remaining: "49",
+		},
+		{
+			// Sent in February, before the month started.
+			name: "monthly cap resets", amount: "100", to: "known",
+			history: []Sent{known, sent(20*24*time.Hour, "known", "2950")},
+		},
+		{name: "new recipient", amount: "300", to: "new"},
+		{name: "new recipient above the cap", amount: "301", to: "new", code: CodeNewRecipient, remaining: "300"},
+		{
+			name: "cooling-off counts earlier transfers", amount: "150", to: "new",
+			history: []Sent{sent(time.Hour, "new", "200")},
+			code:    CodeNewRecipient, remaining: "100",
+		},
+		{
+			name: "after the cooling-off", amount: "450", to: "new",
+			history: []Sent{sent(25*time.Hour, "new", "300")},
+		},
+		{
+			// Last sent to outside the lookback, so the recipient is new again.
+			name: "outside the lookback", amount: "301", to: "old",
+			history: []Sent{sent(31*24*time.Hour, "old", "300")},
+			code:    CodeNewRecipient, remaining: "300",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := e.Check(Transfer{
+				Amount:   money.MustParse(tt.amount),
+				To:       tt.to,
+				Verified: tt.verified,
+				History:  tt.history,
+				Now:      now,
+			})
+			if tt.code == "" {
+				if v != nil {
+					t.Fatalf("Check = %q, want no violation", v.Message)
+				}
+				return
+			}
+			if v == nil {
+				t.Fatalf("Check = nil, want %s", tt.code)
+			}
+			if v.Code != tt.code || v.Tier != tt.tier {
+				t.Errorf("Check = %s (tier %q), want %s (tier %q)", v.Code, v.Tier, tt.code, tt.tier)
+			}
+			if v.Remaining.Cmp(money.MustParse(tt.remaining)) != 0 {
+				t.Errorf("Remaining = %s, want %s", v.Remaining, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestTier(t *testing.T) {
+	e := testEngine(t)
+	tests := []struct {
+		verified []string
+		want     string
+	}{
+		{nil, "unverified"},
+		{[]string{"phone"}, "unverified"},
+		{[]string{"email"}, "email_verified"},
+		{[]string{"phone", "Email"}, "fully_verified"},
+	}
+	for _, tt := range tests {
+		if got := e.Tier(tt.verified); got == nil || got.Name != tt.want {
+			t.Errorf("Tier(%v) = %v, want %s", tt.verified, got, tt.want)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	e := testEngine(t)
+	now := time.Date(2025, time.March, 14, 20, 0, 0, 0, time.UTC)
+	// The 720h lookback reaches further back than the start of the month.
+	if got, want := e.Since(now), now.Add(-720*time.Hour); !got.Equal(want) {
+		t.Errorf("Since = %s, want %s", got, want)
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	for _, rules := range []string{
+		`tiers: [{name: a, per_transaction: "-1"}]`,
+		`tiers: [{per_transaction: "1"}]`,
+		`time_zone: Nowhere/Special`,
+		`new_recipient: {max_amount: "10"}`,
+		`new_recipient: {lookback: soon, cooling_off: 1h, max_amount: "10"}`,
+	} {
+		if _, err := Parse([]byte(rules)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", rules)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("account lock not found")
+
+// An account support has locked: its tokens are refused and it can't log in
+// until unlocked.
+type Lock struct {
+	UserID string
+	Reason string
+	// Id of the staff member who locked it.
+	LockedBy string
+	LockedAt time.Time
+}
+
+// Persists account locks. The user service has no notion of them, so the
+// gateway keeps its own.
+type Store interface {
+	// ErrNotFound when the account is not locked.
+	Get(ctx context.Context, userID string) (*Lock, error)
+	// Locks an account. False when it was already locked; the existing lock is kept.
+	Lock(ctx context.Context, l *Lock) (bool, error)
+	// False when the account was not locked.
+	Unlock(ctx context.Context, userID string) (bool, error)
+
+	Close() error
+}
+
+// Whether userID is locked.
+func IsLocked(ctx context.Context, s Store, userID string) (bool, error) {
+	_, err := s.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,79 @@
+package locks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS account_locks (
+	user_id   TEXT PRIMARY KEY,
+	reason    TEXT NOT NULL,
+	locked_by TEXT NOT NULL,
+	locked_at INTEGER NOT NULL
+);
+`
+
+// Store backed by a SQLite file. Times are stored as Unix seconds.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating account lock table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+func (st *SQLiteStore) Get(ctx context.Context, userID string) (*Lock, error) {
+	l := &Lock{UserID: userID}
+	var lockedAt int64
+	err := st.db.QueryRowContext(ctx, `SELECT reason, locked_by, locked_at FROM account_locks WHERE user_id = ?`, userID).
+		Scan(&l.Reason, &l.LockedBy, &lockedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.LockedAt = time.Unix(lockedAt, 0)
+	return l, nil
+}
+
+func (st *SQLiteStore) Lock(ctx context.Context, l *Lock) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `INSERT INTO account_locks (user_id, reason, locked_by, locked_at) VALUES (?, ?, ?, ?) ON CONFLICT (user_id) DO NOTHING`,
+		l.UserID, l.Reason, l.LockedBy, l.LockedAt.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Unlock(ctx context.Context, userID string) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `DELETE FROM account_locks WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+var _ Store = (*SQLiteStore)(nil)
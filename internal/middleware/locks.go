@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+)
+
+// Rejects requests from locked accounts with a 403. Runs after AuthToken,
+// which puts the claims in the context.
+//
+// A locked user keeps read-only access (GET, HEAD and OPTIONS) to their own
+// data, e.g. to see their balance or export it while support looks into the
+// lock. Staff tokens, those granted roles, are refused outright so a locked
+// staff member can't keep reading other users' data.
+func RejectLocked(store locks.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Authorization is required", http.StatusUnauthorized)
+				return
+			}
+			if readOnly(r.Method) && len(claims.Roles) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			locked, err := locks.IsLocked(r.Context(), store, claims.UserID)
+			if err != nil {
+				log.Printf("checking account lock of %s: %v", claims.UserID, err)
+				common.RespondWithError(w, http.StatusServiceUnavailable, "Could not check the account status")
+				return
+			}
+			if locked {
+				common.RespondWithErrorCode(w, http.StatusForbidden, "account_locked", "The account is locked; contact support")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func readOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+)
+
+// Locks of the users in locked; every lookup fails when err is set.
+type fakeLocks struct {
+	locks.Store
+	locked map[string]bool
+	err    error
+}
+
+func (f fakeLocks) Get(_ context.Context, userID string) (*locks.Lock, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.locked[userID] {
+		return &locks.Lock{UserID: userID}, nil
+	}
+	return nil, locks.ErrNotFound
+}
+
+func TestRejectLocked(t *testing.T) {
+	store := fakeLocks{locked: map[string]bool{"locked": true}}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		roles  []string
+		want   int
+	}{
+		{"locked reads insights", http.MethodGet, "/api/me/insights", "locked", nil, http.StatusOK},
+		{"locked reads payment requests", http.MethodGet, "/api/me/payment-requests", "locked", nil, http.StatusOK},
+		{"locked HEAD", http.MethodHead, "/api/me/export/e1/download", "locked", nil, http.StatusOK},
+		{"locked transfers", http.MethodPost, "/api/transfers", "locked", nil, http.StatusForbidden},
+		{"locked updates profile", http.MethodPut, "/api/users/locked", "locked", nil, http.StatusForbidden},
+		{"locked cancels a schedule", http.MethodDelete, "/api/me/scheduled-transfers/s1", "locked", nil, http.StatusForbidden},
+		{"locked accepts a request", http.MethodPost, "/api/me/payment-requests/r1/accept", "locked", nil, http.StatusForbidden},
+		{"locked patches", http.MethodPatch, "/api/users/locked", "locked", nil, http.StatusForbidden},
+		{"locked staff reads", http.MethodGet, "/api/admin/users/u2", "locked", []string{"support"}, http.StatusForbidden},
+		{"unlocked transfers", http.MethodPost, "/api/transfers", "u1", nil, http.StatusOK},
+		{"unlocked staff reads", http.MethodGet, "/api/admin/users/u2", "u1", []string{"support"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RejectLocked(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "tokenClaims", &TokenClaims{UserID: tt.user, Roles: tt.roles}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRejectLockedStoreFailure(t *testing.T) {
+	handler := RejectLocked(fakeLocks{err: errors.New("database is locked")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for method, want := range map[string]int{
+		// Reads don't need the lock store.
+		http.MethodGet:  http.StatusOK,
+		http.MethodPost: http.StatusServiceUnavailable,
+	} {
+		req := httptest.NewRequest(method, "/api/transfers", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "tokenClaims", &TokenClaims{UserID: "u1"}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", method, rec.Code, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/me/insights", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without claims: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
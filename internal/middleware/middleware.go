@@ -2,12 +2,18 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	// Import from common-protos
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
 )
 
 type TokenClaims struct {
@@ -21,17 +27,28 @@ type TokenClaims struct {
 	Type     string `json:"type"`
 	HttpOnly bool   `json:"httpOnly"`
 	Secure   bool   `json:"secure"`
+	// Optional: roles and scopes granted by the issuer; see Policy.
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 type MiddlewareInterface interface {
 	AuthToken(handler http.Handler) http.Handler
 }
 
-func NewMiddleware() MiddlewareInterface {
-	return &TokenClaims{}
+// Checks access tokens, recording rejected ones in the audit trail.
+type Authenticator struct {
+	Audit audit.Sink
+	// Tokens of revoked sessions, and tokens issued since tracking started
+	// that have none, are rejected; nil disables the check.
+	Sessions sessions.Store
+}
+
+func NewMiddleware(auditSink audit.Sink, sessionStore sessions.Store) MiddlewareInterface {
+	return &Authenticator{Audit: auditSink, Sessions: sessionStore}
 }
 
-func (m *TokenClaims) AuthToken(next http.Handler) http.Handler {
+func (m *Authenticator) AuthToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Try to get token from cookie first
 		tokenString, err := r.Cookie("accessToken")
@@ -42,14 +59,14 @@ func (m *TokenClaims) AuthToken(next http.Handler) http.Handler {
 			// If no cookie, try Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization is required", http.StatusUnauthorized)
+				m.reject(w, r, "Authorization is required", "missing token")
 				return
 			}
 			// Check if it's a Bearer token
 			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
 				tokenValue = authHeader[7:]
 			} else {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				m.reject(w, r, "Invalid authorization header format", "invalid authorization header")
 				return
 			}
 		}
@@ -57,19 +74,73 @@ func (m *TokenClaims) AuthToken(next http.Handler) http.Handler {
 		// Validate and decode the token
 		tokenClaims, err := validateToken(tokenValue)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+			m.reject(w, r, fmt.Sprintf("Invalid token: %v", err), err.Error())
 			return
 		}
 
 		// Store claims in context for downstream handlers
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, "tokenClaims", tokenClaims)
+
+		if m.Sessions != nil {
+			session, err := m.Sessions.ByToken(ctx, sessions.HashToken(tokenValue))
+			switch {
+			case errors.Is(err, sessions.ErrNotFound) && tokenClaims.Iat > m.Sessions.TrackingSince().Unix():
+				// Every login since tracking started has a session, so this
+				// token wasn't issued by one, or its session is gone.
+				m.reject(w, r, "Invalid token: unknown session", "unknown session")
+				return
+			case errors.Is(err, sessions.ErrNotFound):
+				// Issued before the gateway tracked sessions.
+			case err != nil:
+				log.Printf("looking up session of %s: %v", tokenClaims.UserID, err)
+				http.Error(w, "Could not check the session", http.StatusServiceUnavailable)
+				return
+			case session.RevokedAt != nil:
+				m.reject(w, r, "Invalid token: session revoked", "session revoked")
+				return
+			default:
+				if err := m.Sessions.Touch(ctx, session.ID, time.Now()); err != nil {
+					log.Printf("recording use of session %s: %v", session.ID, err)
+				}
+				ctx = context.WithValue(ctx, sessionIDKey{}, session.ID)
+			}
+		}
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r) // Call the next handler
 	})
 }
 
+// Answers a request without a valid token with a 401 and records why.
+func (m *Authenticator) reject(w http.ResponseWriter, r *http.Request, message, reason string) {
+	audit.Record(r.Context(), m.Audit, audit.Event{
+		Action:  "auth.token.rejected",
+		Outcome: audit.OutcomeDenied,
+		Details: map[string]string{"reason": reason, "route": r.Method + " " + r.URL.Path},
+	})
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+type sessionIDKey struct{}
+
+// Id of the session the request's token belongs to, or "" when it isn't tracked.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
+
+// Returns the token claims stored by AuthToken, if any.
+func ClaimsFromContext(ctx context.Context) (*TokenClaims, bool) {
+	claims, ok := ctx.Value("tokenClaims").(*TokenClaims)
+	return claims, ok
+}
+
+// Validates and decodes an access token issued by the auth service.
+func ParseToken(tokenString string) (*TokenClaims, error) {
+	return validateToken(tokenString)
+}
+
 // Validates and decodes JWT tokens from the auth service
 func validateToken(tokenString string) (*TokenClaims, error) {
 	// Parse and validate
@@ -132,6 +203,34 @@ func validateToken(tokenString string) (*TokenClaims, error) {
 		HttpOnly: claims["httpOnly"].(bool),
 		Secure:   claims["secure"].(bool),
 	}
+	if tokenClaims.Roles, err = stringListClaim(claims, "roles"); err != nil {
+		return nil, err
+	}
+	if tokenClaims.Scopes, err = stringListClaim(claims, "scopes"); err != nil {
+		return nil, err
+	}
 
 	return tokenClaims, nil
 }
+
+// Reads an optional claim holding either a list of strings or a
+// space-separated string, as in OAuth's "scope".
+func stringListClaim(claims jwt.MapClaims, name string) ([]string, error) {
+	switch v := claims[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(v), nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s claim", name)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("invalid %s claim", name)
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+)
+
+func signedToken(t *testing.T, iat time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userID": "u1", "email": "u1@example.com", "username": "u1", "phone": "",
+		"lastLog": "", "iat": iat.Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+		"type": "access", "httpOnly": true, "secure": true,
+	}).SignedString([]byte("Thunderbolts*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// Sessions looked up by token hash, with tracking started at since.
+type fakeSessions struct {
+	sessions.Store
+	since   time.Time
+	byToken map[string]*sessions.Session
+}
+
+func (f *fakeSessions) TrackingSince() time.Time { return f.since }
+
+func (f *fakeSessions) ByToken(_ context.Context, tokenHash string) (*sessions.Session, error) {
+	if s, ok := f.byToken[tokenHash]; ok {
+		return s, nil
+	}
+	return nil, sessions.ErrNotFound
+}
+
+func (f *fakeSessions) Touch(context.Context, string, time.Time) error { return nil }
+
+func TestAuthTokenSessions(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	tracked := signedToken(t, now.Add(-time.Minute))
+	revoked := signedToken(t, now.Add(-2*time.Minute))
+	store := &fakeSessions{since: since, byToken: map[string]*sessions.Session{
+		sessions.HashToken(tracked): {ID: "tracked", UserID: "u1", ExpiresAt: now.Add(time.Hour)},
+		sessions.HashToken(revoked): {ID: "revoked", UserID: "u1", ExpiresAt: now.Add(time.Hour), RevokedAt: &now},
+	}}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"tracked session", tracked, http.StatusOK},
+		{"revoked session", revoked, http.StatusUnauthorized},
+		{"issued before tracking started", signedToken(t, since.Add(-time.Hour)), http.StatusOK},
+		{"issued since tracking started without a session", signedToken(t, now.Add(-time.Hour)), http.StatusUnauthorized},
+	}
+	handler := NewMiddleware(nil, store).AuthToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
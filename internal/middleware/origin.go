@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
+)
+
+// Header carrying the id of a request, taken from the client or assigned by
+// the gateway, and echoed in the response.
+const RequestIDHeader = "X-Request-Id"
+
+// Tags each request with an id and puts its origin (client IP, user agent,
+// request id) in the context for the audit trail.
+func RequestOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := audit.WithOrigin(r.Context(), audit.Origin{
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+			RequestID: id,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Client-supplied ids end up in logs: only short, plain ones are kept.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Address of the client the connection came from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fixed-window rate limiter keyed by authenticated user, or client IP for anonymous requests.
+type RateLimiter struct {
+	requests int
+	window   time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	sweep   time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(requests int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		requests: requests,
+		window:   window,
+		windows:  make(map[string]*rateWindow),
+	}
+}
+
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := l.allow(rateLimitKey(r), time.Now())
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Counts a request for key and reports whether it fits in the current window.
+func (l *RateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop expired windows from time to time so idle clients don't pile up.
+	if now.Sub(l.sweep) > l.window {
+		for k, win := range l.windows {
+			if now.Sub(win.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.sweep = now
+	}
+
+	win, ok := l.windows[key]
+	if !ok || now.Sub(win.start) >= l.window {
+		win = &rateWindow{start: now}
+		l.windows[key] = win
+	}
+	if win.count >= l.requests {
+		return false, win.start.Add(l.window).Sub(now)
+	}
+	win.count++
+	return true, 0
+}
+
+func rateLimitKey(r *http.Request) string {
+	if claims, ok := ClaimsFromContext(r.Context()); ok {
+		return "user:" + claims.UserID
+	}
+	return "ip:" + clientIP(r)
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Bounds the request context, so backend calls derived from it give up after d.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ISO 4217 currency.
+type Currency struct {
+	Code   string
+	Digits int // Minor unit: decimals used when amounts are shown.
+	Symbol string
+}
+
+// Currencies the gateway knows how to format.
+var currencies = map[string]Currency{
+	"ARS": {Code: "ARS", Digits: 2, Symbol: "$"},
+	"BRL": {Code: "BRL", Digits: 2, Symbol: "R$"},
+	"CAD": {Code: "CAD", Digits: 2, Symbol: "$"},
+	"CLP": {Code: "CLP", Digits: 0, Symbol: "$"},
+	"COP": {Code: "COP", Digits: 2, Symbol: "$"},
+	"EUR": {Code: "EUR", Digits: 2, Symbol: "€"},
+	"GBP": {Code: "GBP", Digits: 2, Symbol: "£"},
+	"JPY": {Code: "JPY", Digits: 0, Symbol: "¥"},
+	"MXN": {Code: "MXN", Digits: 2, Symbol: "$"},
+	"PEN": {Code: "PEN", Digits: 2, Symbol: "S/"},
+	"USD": {Code: "USD", Digits: 2, Symbol: "$"},
+}
+
+// Looks up a currency by its ISO 4217 code, case-insensitively.
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("unknown currency %q", code)
+	}
+	return c, nil
+}
+
+// An amount in a currency.
+type Money struct {
+	Amount   Decimal
+	Currency Currency
+}
+
+// Parses a backend amount string in currency c.
+func ParseMoney(s string, c Currency) (Money, error) {
+	d, err := Parse(strings.TrimSpace(s))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: d, Currency: c}, nil
+}
+
+// The amount with the currency's number of decimals, e.g. "1234.50".
+func (m Money) String() string {
+	return m.Amount.StringFixed(m.Currency.Digits)
+}
+
+// Normalizes a backend amount string to the currency's decimals. Values that
+// don't parse are returned unchanged rather than hidden.
+func Format(s string, c Currency) string {
+	m, err := ParseMoney(s, c)
+	if err != nil {
+		return s
+	}
+	return m.String()
+}
@@ -0,0 +1,227 @@
+package money
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrSyntax    = errors.New("not a plain decimal number")
+	ErrNegative  = errors.New("must not be negative")
+	ErrPrecision = errors.New("too many decimal places")
+	ErrOverflow  = errors.New("too large")
+)
+
+// Limits on what Parse accepts, so a request can't make the gateway do
+// arbitrarily large arithmetic.
+const (
+	maxDigits   = 40
+	maxDecimals = 18
+)
+
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Exact decimal number: coef × 10^-exp. The zero value is 0.
+//
+// In JSON it decodes from a number or a string, but never from exponent
+// notation, and encodes as a string so no precision is lost in clients.
+type Decimal struct {
+	coef *big.Int
+	exp  int
+}
+
+// Parses a plain decimal such as "12", "-3.50" or "0.001".
+func Parse(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, ErrSyntax
+	}
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if len(frac) > maxDecimals {
+		return Decimal{}, ErrPrecision
+	}
+	if len(whole)+len(frac) > maxDigits {
+		return Decimal{}, ErrOverflow
+	}
+	coef, _ := new(big.Int).SetString(whole+frac, 10)
+	if strings.HasPrefix(s, "-") {
+		coef.Neg(coef)
+	}
+	return Decimal{coef: coef, exp: len(frac)}, nil
+}
+
+// Like Parse, for constants.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("money: %q: %v", s, err))
+	}
+	return d
+}
+
+func FromInt(n int64) Decimal {
+	return Decimal{coef: big.NewInt(n)}
+}
+
+// Decimal of an amount in minor units, e.g. FromMinor(1050, 2) is 10.50.
+func FromMinor(minor int64, digits int) Decimal {
+	return Decimal{coef: big.NewInt(minor), exp: digits}
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Both coefficients at the larger of the two exponents.
+func align(a, b Decimal) (*big.Int, *big.Int, int) {
+	x, y := new(big.Int).Set(a.int()), new(big.Int).Set(b.int())
+	switch {
+	case a.exp < b.exp:
+		x.Mul(x, pow10(b.exp-a.exp))
+		return x, y, b.exp
+	case b.exp < a.exp:
+		y.Mul(y, pow10(a.exp-b.exp))
+	}
+	return x, y, a.exp
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	x, y, exp := align(d, o)
+	return Decimal{coef: x.Add(x, y), exp: exp}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	x, y, exp := align(d, o)
+	return Decimal{coef: x.Sub(x, y), exp: exp}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), o.int()), exp: d.exp + o.exp}
+}
+
+// d / o rounded to the given number of decimals, half away from zero.
+// Dividing by zero panics, as with integers.
+func (d Decimal) Quo(o Decimal, decimals int) Decimal {
+	// Compute one extra digit and let Round settle it.
+	num := new(big.Int).Mul(d.int(), pow10(decimals+1+o.exp))
+	den := new(big.Int).Mul(o.int(), pow10(d.exp))
+	q := new(big.Int).Quo(num, den)
+	return Decimal{coef: q, exp: decimals + 1}.Round(decimals)
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), exp: d.exp}
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	x, y, _ := align(d, o)
+	return x.Cmp(y)
+}
+
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Number of decimal places needed to write d exactly, ignoring trailing zeros.
+func (d Decimal) Decimals() int {
+	coef, exp := new(big.Int).Set(d.int()), d.exp
+	ten, rem := big.NewInt(10), new(big.Int)
+	for exp > 0 {
+		q, r := new(big.Int).QuoRem(coef, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		coef, exp = q, exp-1
+	}
+	return exp
+}
+
+// Value in units of 10^-digits, e.g. cents for digits 2. Amounts that are
+// negative, need more decimals or don't fit in [0, max] are rejected.
+func (d Decimal) Units(digits int, max int64) (int64, error) {
+	if d.Sign() < 0 {
+		return 0, ErrNegative
+	}
+	if d.Decimals() > digits {
+		return 0, ErrPrecision
+	}
+	units := d.Round(digits).coef
+	if !units.IsInt64() || units.Int64() > max {
+		return 0, ErrOverflow
+	}
+	return units.Int64(), nil
+}
+
+// Rounds to the given number of decimals, half away from zero.
+func (d Decimal) Round(digits int) Decimal {
+	coef := new(big.Int).Set(d.int())
+	if digits >= d.exp {
+		return Decimal{coef: coef.Mul(coef, pow10(digits-d.exp)), exp: digits}
+	}
+
+	div := pow10(d.exp - digits)
+	q, r := new(big.Int).QuoRem(coef, div, new(big.Int))
+	r.Abs(r).Lsh(r, 1)
+	if r.Cmp(div) >= 0 {
+		if coef.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{coef: q, exp: digits}
+}
+
+// Exact decimal representation, keeping the scale it was written with.
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.int()).String()
+	if d.exp > 0 {
+		if len(s) <= d.exp {
+			s = strings.Repeat("0", d.exp-len(s)+1) + s
+		}
+		s = s[:len(s)-d.exp] + "." + s[len(s)-d.exp:]
+	}
+	if d.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Rounded to digits decimals and written with exactly that many.
+func (d Decimal) StringFixed(digits int) string {
+	return d.Round(digits).String()
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		data = bytes.Trim(data, `"`)
+	}
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("amount %s: %w", data, err)
+	}
+	*d = parsed
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
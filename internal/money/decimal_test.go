@@ -0,0 +1,169 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{in: "12", want: "12"},
+		{in: "-3.50", want: "-3.50"},
+		{in: "0.001", want: "0.001"},
+		{in: "1e3", err: ErrSyntax},
+		{in: ".5", err: ErrSyntax},
+		{in: "0." + strings.Repeat("1", maxDecimals), want: "0." + strings.Repeat("1", maxDecimals)},
+		{in: "0." + strings.Repeat("1", maxDecimals+1), err: ErrPrecision},
+		{in: "1" + strings.Repeat("0", maxDigits), err: ErrOverflow},
+		// Too many decimals is reported as such even when the number is also too long.
+		{in: strings.Repeat("9", maxDigits) + "." + strings.Repeat("1", maxDecimals+1), err: ErrPrecision},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	tests := []struct {
+		in     string
+		digits int
+		max    int64
+		want   int64
+		err    error
+	}{
+		{in: "10.50", digits: 2, max: 1 << 62, want: 1050},
+		{in: "7", digits: 2, max: 1 << 62, want: 700},
+		{in: "0", digits: 2, max: 1 << 62, want: 0},
+		// Trailing zeros don't count as decimals.
+		{in: "3.000", digits: 0, max: 1 << 62, want: 3},
+		{in: "0.001", digits: 2, max: 1 << 62, err: ErrPrecision},
+		{in: "-1", digits: 2, max: 1 << 62, err: ErrNegative},
+		{in: "100", digits: 0, max: 100, want: 100},
+		{in: "101", digits: 0, max: 100, err: ErrOverflow},
+		// Fits as a decimal but not in an int64 once scaled.
+		{in: "92233720368547758.08", digits: 2, max: 1<<63 - 1, err: ErrOverflow},
+		{in: "92233720368547758.07", digits: 2, max: 1<<63 - 1, want: 1<<63 - 1},
+	}
+	for _, tt := range tests {
+		got, err := MustParse(tt.in).Units(tt.digits, tt.max)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Units(%s, %d) error = %v, want %v", tt.in, tt.digits, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Units(%s, %d) = %d, want %d", tt.in, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		digits int
+		want   string
+	}{
+		{"1.234", 2, "1.23"},
+		{"1.235", 2, "1.24"},
+		{"-1.235", 2, "-1.24"},
+		{"-1.234", 2, "-1.23"},
+		{"0.5", 0, "1"},
+		{"-0.5", 0, "-1"},
+		{"0.49", 0, "0"},
+		// Rounding to more decimals pads.
+		{"2.5", 3, "2.500"},
+		{"9.995", 2, "10.00"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Round(tt.digits).String(); got != tt.want {
+			t.Errorf("Round(%s, %d) = %s, want %s", tt.in, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestQuo(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		decimals int
+		want     string
+	}{
+		{"1", "3", 2, "0.33"},
+		{"2", "3", 2, "0.67"},
+		{"-2", "3", 2, "-0.67"},
+		{"1", "8", 2, "0.13"},
+		{"10.50", "2", 2, "5.25"},
+		{"1", "0.25", 0, "4"},
+		{"4050", "0.85", 4, "4764.7059"},
+		{"0", "7", 2, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.a).Quo(MustParse(tt.b), tt.decimals).String(); got != tt.want {
+			t.Errorf("%s / %s = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("dividing by zero did not panic")
+		}
+	}()
+	MustParse("1").Quo(Decimal{}, 2)
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{in: `"12.50"`, want: "12.50"},
+		{in: `12.50`, want: "12.50"},
+		{in: ` -3 `, want: "-3"},
+		{in: `null`, want: "0"},
+		{in: `1e3`, err: ErrSyntax},
+		{in: `"1e3"`, err: ErrSyntax},
+		{in: `""`, err: ErrSyntax},
+		{in: `"0.` + strings.Repeat("1", maxDecimals+1) + `"`, err: ErrPrecision},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		err := d.UnmarshalJSON([]byte(tt.in))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("UnmarshalJSON(%s) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if tt.err == nil && d.String() != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %s, want %s", tt.in, d, tt.want)
+		}
+	}
+
+	// Through encoding/json, as request bodies are decoded.
+	var body struct {
+		Amount Decimal `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": "0.10"}`), &body); err != nil || body.Amount.String() != "0.10" {
+		t.Errorf("decoded %s, %v; want 0.10", body.Amount, err)
+	}
+	out, err := json.Marshal(body)
+	if err != nil || string(out) != `{"amount":"0.10"}` {
+		t.Errorf("encoded %s, %v", out, err)
+	}
+}
@@ -0,0 +1,73 @@
+package money
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Formats backend amount strings of one currency for responses, adding
+// display strings when the client asked for a locale and converted amounts
+// when it asked for another currency.
+type Formatter struct {
+	Currency   Currency
+	Locale     *Locale
+	Conversion *Conversion
+}
+
+// Converts amounts into another currency at a fixed rate.
+type Conversion struct {
+	To   Currency
+	Rate Decimal
+}
+
+// d in the target currency, rounded to its decimals.
+func (c Conversion) Apply(d Decimal) Money {
+	return Money{Amount: d.Mul(c.Rate).Round(c.To.Digits), Currency: c.To}
+}
+
+func NewFormatter(c Currency, r *http.Request) Formatter {
+	f := Formatter{Currency: c}
+	if l, ok := LocaleFromRequest(r); ok {
+		f.Locale = &l
+	}
+	return f
+}
+
+// Amount normalized to the currency's decimals.
+func (f Formatter) Amount(s string) string {
+	return Format(s, f.Currency)
+}
+
+// Display string for the client's locale; empty without one or when s doesn't parse.
+func (f Formatter) Display(s string) string {
+	if f.Locale == nil {
+		return ""
+	}
+	m, err := ParseMoney(s, f.Currency)
+	if err != nil {
+		return ""
+	}
+	return f.Locale.Display(m)
+}
+
+// Amount converted into the requested currency; empty without a conversion or
+// when s doesn't parse.
+func (f Formatter) Converted(s string) string {
+	if f.Conversion == nil {
+		return ""
+	}
+	d, err := Parse(strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+	return f.Conversion.Apply(d).String()
+}
+
+// Like Amount and Display for a computed value.
+func (f Formatter) Decimal(d Decimal) (string, string) {
+	m := Money{Amount: d, Currency: f.Currency}
+	if f.Locale == nil {
+		return m.String(), ""
+	}
+	return m.String(), f.Locale.Display(m)
+}
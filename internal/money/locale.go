@@ -0,0 +1,91 @@
+package money
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// How a locale writes amounts.
+type Locale struct {
+	Tag      string
+	Group    string // Thousands separator.
+	Decimal  string
+	Suffix   bool // Symbol after the number, as in "1.234,50 €".
+	Spaced   bool // Space between symbol and number.
+	UseCodes bool // Disambiguate "$" currencies with their code, as in "US$".
+}
+
+var locales = map[string]Locale{
+	"en": {Tag: "en", Group: ",", Decimal: ".", UseCodes: true},
+	"es": {Tag: "es", Group: ".", Decimal: ",", Spaced: true},
+	"pt": {Tag: "pt", Group: ".", Decimal: ",", Spaced: true},
+	"de": {Tag: "de", Group: ".", Decimal: ",", Suffix: true, Spaced: true},
+	"fr": {Tag: "fr", Group: " ", Decimal: ",", Suffix: true, Spaced: true},
+}
+
+// Locale requested through the locale query parameter or Accept-Language, if
+// any is supported.
+func LocaleFromRequest(r *http.Request) (Locale, bool) {
+	accept := r.URL.Query().Get("locale")
+	if accept == "" {
+		accept = r.Header.Get("Accept-Language")
+	}
+	if accept == "" {
+		return Locale{}, false
+	}
+	tags, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil {
+		return Locale{}, false
+	}
+	for _, tag := range tags {
+		base, _ := tag.Base()
+		if l, ok := locales[base.String()]; ok {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// Human-readable amount, e.g. "$ 1.234,50" for es or "US$1,234.50" for en.
+func (l Locale) Display(m Money) string {
+	s := m.String()
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(digit)
+	}
+	if frac != "" {
+		b.WriteString(l.Decimal)
+		b.WriteString(frac)
+	}
+	number := b.String()
+
+	symbol := m.Currency.Symbol
+	if symbol == "" || (l.UseCodes && symbol == "$" && m.Currency.Code != "USD") {
+		symbol = m.Currency.Code
+		if m.Currency.Symbol == "$" {
+			symbol = m.Currency.Code[:2] + "$"
+		}
+	}
+	sep := ""
+	if l.Spaced {
+		sep = " "
+	}
+	if l.Suffix {
+		number = number + sep + symbol
+	} else {
+		number = symbol + sep + number
+	}
+	if negative {
+		number = "-" + number
+	}
+	return number
+}
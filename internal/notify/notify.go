@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A message for a user, delivered out of band.
+type Message struct {
+	// Address of the recipient, e.g. their email.
+	To      string
+	Subject string
+	Body    string
+}
+
+// Delivers messages to users (email, SMS, ...).
+type Notifier interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Writes messages to the log instead of delivering them. A stand-in for
+// development: whatever the messages carry (one-time codes included) ends up
+// in the logs.
+type Log struct{}
+
+func (Log) Send(_ context.Context, m Message) error {
+	log.Printf("notify: to %s: %s: %s", m.To, m.Subject, m.Body)
+	return nil
+}
+
+// Writes each message to a file in Dir, a local outbox to read messages
+// from during development.
+type File struct {
+	Dir string
+}
+
+func (f File) Send(_ context.Context, m Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405Z"), uuid.NewString())
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		m.To, m.Subject, time.Now().Format(time.RFC1123Z), strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return os.WriteFile(filepath.Join(f.Dir, name), []byte(content), 0o600)
+}
+
+// Returns the notifier described by spec, as set by NOTIFIER: "log", or
+// "file:<dir>" to write messages to dir.
+func New(spec string) (Notifier, error) {
+	if dir, ok := strings.CutPrefix(spec, "file:"); ok {
+		if dir == "" {
+			return nil, fmt.Errorf("notifier %q: missing directory", spec)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+		return File{Dir: dir}, nil
+	}
+	switch spec {
+	case "", "log":
+		return Log{}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q (supported: log, file:<dir>)", spec)
+}
@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/routes"
+)
+
+// Resolves the request and response messages of a "package.Service/Method" gRPC method.
+type MethodDescriber func(fullMethod string) (input, output protoreflect.MessageDescriptor, err error)
+
+// Matches mux path variables, with or without a pattern: {name} or {name:[0-9]+}.
+var pathVarPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Generates the OpenAPI document for every route in the table.
+func Build(table *routes.Table, handlers routes.Handlers, info Info, describe MethodDescriber) (*Document, error) {
+	schemas := newSchemaRegistry()
+	schemas.schemas["Error"] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": {Type: "string"}, "code": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: schemas.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "accessToken"},
+			},
+		},
+	}
+
+	operationIDs := make(map[string]bool)
+	for _, route := range table.Routes {
+		path := pathVarPattern.ReplaceAllString(table.Prefix+route.Path, "{$1}")
+		op := &Operation{
+			Tags:      []string{tagFor(route.Path)},
+			Responses: make(map[string]*Response),
+		}
+
+		for _, match := range pathVarPattern.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+
+		if route.GRPC != "" {
+			if describe == nil {
+				return nil, fmt.Errorf("route %s %s: no describer for gRPC routes", route.Method, route.Path)
+			}
+			input, output, err := describe(route.GRPC)
+			if err != nil {
+				return nil, fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+			}
+			useProtoNames := route.FieldNaming != "camelCase"
+			op.OperationID = strings.ReplaceAll(route.GRPC, "/", ".")
+			op.Summary = "Transcoded to " + route.GRPC
+			if hasBody(route.Method) {
+				op.RequestBody = jsonBody(schemas.protoSchema(input, true))
+			} else {
+				op.Parameters = append(op.Parameters, protoQueryParams(input, op.Parameters)...)
+			}
+			op.Responses["200"] = &Response{Description: "Success", Content: jsonContent(schemas.protoSchema(output, useProtoNames))}
+		} else {
+			handler := handlers[route.Handler]
+			op.OperationID = route.Handler
+			op.Summary = handler.Summary
+			for _, p := range handler.Query {
+				op.Parameters = append(op.Parameters, Parameter{
+					Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: &Schema{Type: "string"},
+				})
+			}
+			if handler.Request != nil {
+				op.RequestBody = jsonBody(schemas.goSchema(reflect.TypeOf(handler.Request), false))
+			}
+			status := handler.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			resp := &Response{Description: http.StatusText(status)}
+			if handler.Response != nil {
+				resp.Content = jsonContent(schemas.goSchema(reflect.TypeOf(handler.Response), true))
+			}
+			op.Responses[strconv.Itoa(status)] = resp
+		}
+
+		if operationIDs[op.OperationID] {
+			op.OperationID += "_" + strings.ToLower(route.Method)
+		}
+		operationIDs[op.OperationID] = true
+
+		if route.Access == routes.AccessProtected {
+			op.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+			op.Responses["401"] = errorResponse("Missing or invalid access token")
+		}
+		if len(route.Scopes) > 0 {
+			op.Responses["403"] = errorResponse("Missing scope: " + strings.Join(route.Scopes, ", "))
+		}
+		if route.RateLimit != "" {
+			op.Responses["429"] = &Response{Description: "Too many requests"}
+		}
+		op.Responses["default"] = errorResponse("Error")
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = op
+	}
+
+	return doc, nil
+}
+
+func hasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: jsonContent(schema)}
+}
+
+func errorResponse(description string) *Response {
+	return &Response{Description: description, Content: jsonContent(ref("Error"))}
+}
+
+// Groups operations by the first path segment, e.g. /users/{user_id}/pockets -> users.
+func tagFor(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return segment
+}
+
+// Scalar fields of a message that can be bound from the query string.
+func protoQueryParams(md protoreflect.MessageDescriptor, pathParams []Parameter) []Parameter {
+	inPath := make(map[string]bool, len(pathParams))
+	for _, p := range pathParams {
+		inPath[p.Name] = true
+	}
+
+	var params []Parameter
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := string(fd.Name())
+		if inPath[name] || inPath[fd.JSONName()] || fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			continue
+		}
+		schema := &Schema{Type: "string"}
+		switch fd.Kind() {
+		case protoreflect.BoolKind:
+			schema = &Schema{Type: "boolean"}
+		case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			schema = &Schema{Type: "number"}
+		default:
+			schema = &Schema{Type: "integer"}
+		}
+		if fd.IsList() {
+			schema = &Schema{Type: "array", Items: schema}
+		}
+		params = append(params, Parameter{Name: name, In: "query", Schema: schema})
+	}
+	return params
+}
@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/config"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/routes"
+)
+
+// Every route of the shipped table must be documented under its method and path.
+func TestBuildDocumentsDefaultRoutes(t *testing.T) {
+	table, err := routes.Parse(config.DefaultRoutes)
+	if err != nil {
+		t.Fatalf("parsing default routes: %v", err)
+	}
+	handlers := make(routes.Handlers)
+	for _, route := range table.Routes {
+		if route.Handler != "" {
+			handlers[route.Handler] = routes.Handler{Summary: route.Handler}
+		}
+	}
+	empty := (&emptypb.Empty{}).ProtoReflect().Descriptor()
+	describe := func(string) (protoreflect.MessageDescriptor, protoreflect.MessageDescriptor, error) {
+		return empty, empty, nil
+	}
+
+	doc, err := Build(table, handlers, Info{Title: "test", Version: "test"}, describe)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("marshaling document: %v", err)
+	}
+
+	operations := 0
+	operationIDs := make(map[string]string)
+	for _, route := range table.Routes {
+		path := pathVarPattern.ReplaceAllString(table.Prefix+route.Path, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("%s %s: path %s missing from the document", route.Method, route.Path, path)
+			continue
+		}
+		op, ok := (*item)[strings.ToLower(route.Method)]
+		if !ok {
+			t.Errorf("%s %s: no %s operation under %s", route.Method, route.Path, strings.ToLower(route.Method), path)
+			continue
+		}
+		operations++
+		if other, dup := operationIDs[op.OperationID]; dup {
+			t.Errorf("%s %s: operationId %q already used by %s", route.Method, route.Path, op.OperationID, other)
+		}
+		operationIDs[op.OperationID] = route.Method + " " + route.Path
+	}
+
+	documented := 0
+	for _, item := range doc.Paths {
+		documented += len(*item)
+	}
+	if documented != operations {
+		t.Errorf("document has %d operations, want one per route (%d)", documented, operations)
+	}
+}
@@ -0,0 +1,32 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+)
+
+//go:embed assets/swagger.html
+var swaggerUI []byte
+
+// Serves the generated document and the Swagger UI pointing at it.
+type Docs struct {
+	Spec *Document
+}
+
+// ServeSpec handles GET /openapi.json
+func (d *Docs) ServeSpec(w http.ResponseWriter, r *http.Request) {
+	if d.Spec == nil {
+		common.RespondWithError(w, http.StatusServiceUnavailable, "API specification not available")
+		return
+	}
+	common.RespondWithJSON(w, http.StatusOK, d.Spec)
+}
+
+// ServeUI handles GET /docs
+func (d *Docs) ServeUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(swaggerUI)
+}
@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+// Builds component schemas from Go types and protobuf descriptors.
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	goNames map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		goNames: make(map[reflect.Type]string),
+	}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(money.Decimal{})
+)
+
+// Plain decimal as money.Decimal accepts it: no exponent, no leading '+'.
+const decimalPattern = `^-?[0-9]+(\.[0-9]+)?$`
+
+// Schema for a Go value as encoding/json renders it. Named structs become
+// components; when required is set every field without omitempty is required.
+func (s *schemaRegistry) goSchema(t reflect.Type, required bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == decimalType {
+		// Always encoded as a string; requests may also send a JSON number.
+		str := &Schema{Type: "string", Format: "decimal", Pattern: decimalPattern}
+		if required {
+			return str
+		}
+		return &Schema{OneOf: []*Schema{str, {Type: "number"}}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.goSchema(t.Elem(), required)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.goSchema(t.Elem(), required)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.goStruct(t, required)
+		}
+		if name, ok := s.goNames[t]; ok {
+			return ref(name)
+		}
+		name := t.Name()
+		if _, taken := s.schemas[name]; taken {
+			name = pathBase(t.PkgPath()) + "." + name
+		}
+		s.goNames[t] = name
+		s.schemas[name] = &Schema{} // Placeholder for recursive types.
+		*s.schemas[name] = *s.goStruct(t, required)
+		return ref(name)
+	}
+	return &Schema{}
+}
+
+func (s *schemaRegistry) goStruct(t reflect.Type, required bool) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := s.goStruct(field.Type, required)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.goSchema(field.Type, required)
+		if strings.Contains(opts, "string") {
+			prop = &Schema{Type: "string"}
+		}
+		schema.Properties[name] = prop
+		if required && !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// Schema for a protobuf message as protojson renders it.
+func (s *schemaRegistry) protoSchema(md protoreflect.MessageDescriptor, useProtoNames bool) *Schema {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string"}
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.Any":
+		return &Schema{}
+	}
+
+	name := string(md.FullName())
+	if !useProtoNames {
+		name += ".camelCase"
+	}
+	if _, ok := s.schemas[name]; ok {
+		return ref(name)
+	}
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.schemas[name] = schema
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		key := fd.JSONName()
+		if useProtoNames {
+			key = string(fd.Name())
+		}
+		schema.Properties[key] = s.protoField(fd, useProtoNames)
+		schema.Required = append(schema.Required, key) // Rendered with EmitUnpopulated.
+	}
+	return ref(name)
+}
+
+func (s *schemaRegistry) protoField(fd protoreflect.FieldDescriptor, useProtoNames bool) *Schema {
+	if fd.IsMap() {
+		return &Schema{Type: "object", AdditionalProperties: s.protoValue(fd.MapValue(), useProtoNames)}
+	}
+	if fd.IsList() {
+		return &Schema{Type: "array", Items: s.protoValue(fd, useProtoNames)}
+	}
+	return s.protoValue(fd, useProtoNames)
+}
+
+func (s *schemaRegistry) protoValue(fd protoreflect.FieldDescriptor, useProtoNames bool) *Schema {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson renders 64-bit integers as strings.
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return &Schema{Type: "number"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		enum := make([]string, values.Len())
+		for i := range enum {
+			enum[i] = string(values.Get(i).Name())
+		}
+		return &Schema{Type: "string", Enum: enum}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.protoSchema(fd.Message(), useProtoNames)
+	}
+	panic(fmt.Sprintf("openapi: unhandled protobuf kind %s", fd.Kind()))
+}
+
+func pathBase(pkgPath string) string {
+	return pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+}
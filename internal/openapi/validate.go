@@ -0,0 +1,358 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+)
+
+// Schema validation modes.
+const (
+	ValidationOff = "off"
+	// Rejects invalid requests and logs responses that break the contract.
+	ValidationDev = "dev"
+	// Like dev, but contract-breaking responses are replaced with a 500 so tests fail.
+	ValidationTest = "test"
+)
+
+// Checks requests and responses against the OpenAPI document.
+type Validator struct {
+	doc  *Document
+	mode string
+}
+
+func NewValidator(doc *Document, mode string) (*Validator, error) {
+	switch mode {
+	case ValidationDev, ValidationTest:
+	default:
+		return nil, fmt.Errorf("unknown schema validation mode %q", mode)
+	}
+	return &Validator{doc: doc, mode: mode}, nil
+}
+
+// Middleware for the router; routes missing from the document are passed through.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := v.operation(r)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if problems := v.checkRequest(op, r); len(problems) > 0 {
+			common.RespondWithError(w, http.StatusBadRequest, "Request does not match the API specification: "+strings.Join(problems, "; "))
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if !rec.buffering {
+			return
+		}
+
+		problems := v.checkResponse(op, rec.status, rec.body.Bytes())
+		if len(problems) > 0 {
+			log.Printf("Response of %s %s violates the API specification: %s", r.Method, r.URL.Path, strings.Join(problems, "; "))
+			if v.mode == ValidationTest {
+				w.Header().Del("Content-Length")
+				common.RespondWithError(w, http.StatusInternalServerError, "Response does not match the API specification: "+strings.Join(problems, "; "))
+				return
+			}
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+func (v *Validator) operation(r *http.Request) *Operation {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	tmpl, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+	item, ok := v.doc.Paths[pathVarPattern.ReplaceAllString(tmpl, "{$1}")]
+	if !ok {
+		return nil
+	}
+	return (*item)[strings.ToLower(r.Method)]
+}
+
+func (v *Validator) checkRequest(op *Operation, r *http.Request) []string {
+	var problems []string
+
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		values, ok := query[p.Name]
+		if !ok {
+			if p.Required {
+				problems = append(problems, fmt.Sprintf("missing query parameter '%s'", p.Name))
+			}
+			continue
+		}
+		for _, raw := range values {
+			if !queryValueMatches(p.Schema, raw) {
+				problems = append(problems, fmt.Sprintf("query parameter '%s' must be %s", p.Name, p.Schema.Type))
+			}
+		}
+	}
+
+	if op.RequestBody == nil {
+		return problems
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return problems
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return append(problems, "unreadable body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			problems = append(problems, "missing body")
+		}
+		return problems
+	}
+	value, err := decodeJSON(body)
+	if err != nil {
+		return append(problems, "body is not valid JSON")
+	}
+	return append(problems, v.checkValue(media.Schema, value, "body")...)
+}
+
+func (v *Validator) checkResponse(op *Operation, status int, body []byte) []string {
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = op.Responses["default"]
+		if !ok || status < 400 {
+			return []string{fmt.Sprintf("undocumented status %d", status)}
+		}
+	}
+	media, ok := resp.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	value, err := decodeJSON(body)
+	if err != nil {
+		return []string{"response is not valid JSON"}
+	}
+	return v.checkValue(media.Schema, value, "response")
+}
+
+// Validates a decoded JSON value and returns every mismatch found.
+func (v *Validator) checkValue(schema *Schema, value any, at string) []string {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		target, ok := v.doc.Components.Schemas[name]
+		if !ok {
+			return []string{fmt.Sprintf("%s: unresolved schema %s", at, schema.Ref)}
+		}
+		return v.checkValue(target, value, at)
+	}
+	if len(schema.OneOf) > 0 {
+		var first []string
+		for i, option := range schema.OneOf {
+			problems := v.checkValue(option, value, at)
+			if len(problems) == 0 {
+				return nil
+			}
+			if i == 0 {
+				first = problems
+			}
+		}
+		return first
+	}
+	if schema.Type == "" {
+		return nil
+	}
+
+	var problems []string
+	mismatch := func() []string {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", at, schema.Type, jsonType(value))}
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing field '%s'", at, name))
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := schema.Properties[k]; ok {
+				problems = append(problems, v.checkValue(prop, obj[k], at+"."+k)...)
+			} else if schema.AdditionalProperties != nil {
+				problems = append(problems, v.checkValue(schema.AdditionalProperties, obj[k], at+"."+k)...)
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return mismatch()
+		}
+		for i, item := range arr {
+			problems = append(problems, v.checkValue(schema.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			problems = append(problems, fmt.Sprintf("%s: '%s' is not one of %s", at, s, strings.Join(schema.Enum, ", ")))
+		}
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+				problems = append(problems, fmt.Sprintf("%s: '%s' does not match %s", at, s, schema.Pattern))
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return mismatch()
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		if schema.Type == "integer" && strings.ContainsAny(n.String(), ".eE") {
+			return mismatch()
+		}
+		if f, err := n.Float64(); err == nil && schema.Minimum != nil && f < *schema.Minimum {
+			problems = append(problems, fmt.Sprintf("%s: must be at least %v", at, *schema.Minimum))
+		}
+	}
+	return problems
+}
+
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func queryValueMatches(schema *Schema, raw string) bool {
+	if schema == nil {
+		return true
+	}
+	switch schema.Type {
+	case "boolean":
+		_, err := strconv.ParseBool(raw)
+		return err == nil
+	case "integer":
+		_, err := strconv.ParseInt(raw, 10, 64)
+		return err == nil
+	case "number":
+		_, err := strconv.ParseFloat(raw, 64)
+		return err == nil
+	case "string":
+		return len(schema.Enum) == 0 || contains(schema.Enum, raw)
+	}
+	return true
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Buffers JSON responses so they can be checked before reaching the client;
+// anything else (files, streams) is passed straight through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	buffering bool
+	decided   bool
+}
+
+// Lets http.ResponseController reach the connection, e.g. to extend the write deadline.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.decided {
+		return
+	}
+	rec.decided = true
+	rec.status = status
+	mediaType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	rec.buffering = mediaType == "application/json"
+	if !rec.buffering {
+		rec.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if !rec.decided {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.buffering {
+		return rec.body.Write(p)
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *responseRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok && !rec.buffering {
+		f.Flush()
+	}
+}
@@ -0,0 +1,91 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type position struct {
+	Time int64  `json:"t"`
+	ID   string `json:"id"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := NewSigner("secret")
+	cursor, err := s.Encode("movements", position{Time: 1720000000, ID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got position
+	if err := s.Decode("movements", cursor, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (position{Time: 1720000000, ID: "t1"}) {
+		t.Errorf("decoded %+v", got)
+	}
+	// Another signer with the same secret, e.g. after a restart.
+	if err := NewSigner("secret").Decode("movements", cursor, &got); err != nil {
+		t.Errorf("same secret: %v", err)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	s := NewSigner("secret")
+	cursor, err := s.Encode("movements", position{Time: 1720000000, ID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(cursor, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"t":1720000000,"id":"t2"}`))
+	flipped := []byte(sig)
+	flipped[0] ^= 1
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		kind   string
+		cursor string
+	}{
+		{"payload changed", s, "movements", forged + "." + sig},
+		{"signature changed", s, "movements", payload + "." + string(flipped)},
+		{"signature missing", s, "movements", payload},
+		{"other endpoint", s, "audit", cursor},
+		{"other key", NewSigner("other secret"), "movements", cursor},
+		// Random per-process key.
+		{"no secret", NewSigner(""), "movements", cursor},
+		{"not base64", s, "movements", "!!." + sig},
+		{"empty", s, "movements", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got position
+			if err := tt.signer.Decode(tt.kind, tt.cursor, &got); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	// Signed, but not what the caller expects.
+	other, err := s.Encode("movements", []string{"t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got position
+	if err := s.Decode("movements", other, &got); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other payload type: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestSetNextLink(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/movements?id=u1&cursor=old&page_size=10", nil)
+	rec := httptest.NewRecorder()
+	SetNextLink(rec, r, "abc.def")
+	want := `</movements?cursor=abc.def&id=u1&page_size=10>; rel="next"`
+	if got := rec.Header().Get("Link"); got != want {
+		t.Errorf("Link = %s, want %s", got, want)
+	}
+}
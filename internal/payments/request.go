@@ -0,0 +1,84 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Payment request statuses.
+const (
+	// Waiting for the payer to accept or decline.
+	StatusPending = "pending"
+	// Accepted; the transfer is in flight. Left there by a crash, it becomes
+	// unknown once stale.
+	StatusAccepting = "accepting"
+	StatusPaid      = "paid"
+	StatusDeclined  = "declined"
+	// Withdrawn by the requester.
+	StatusCanceled = "canceled"
+	StatusExpired  = "expired"
+	// The transfer call failed in a way that may or may not have moved the
+	// money. Never retried; needs reconciliation against the movements.
+	StatusUnknown = "unknown"
+)
+
+var ErrNotFound = errors.New("payment request not found")
+
+// A request from RequesterID for PayerID to send them Amount. It is paid at
+// most once: only the accept that moves it out of pending makes the transfer.
+// The transaction service takes no idempotency key, so its id is not sent.
+type Request struct {
+	ID                string
+	RequesterID       string
+	RequesterUsername string
+	PayerID           string
+	PayerUsername     string
+	Amount            string // Decimal, in Currency.
+	Currency          string
+	Note              string
+	Status            string
+	TransferID        string
+	Error             string
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+	UpdatedAt         time.Time
+	// Split the request is a share of, if any.
+	SplitID string
+}
+
+// Open reports whether the request can still be accepted, declined or canceled.
+func (r *Request) Open(now time.Time) bool {
+	return r.Status == StatusPending && now.Before(r.ExpiresAt)
+}
+
+// Persists payment requests.
+type Store interface {
+	Create(ctx context.Context, r *Request) error
+	Get(ctx context.Context, id string) (*Request, error)
+	// Requests addressed to payerID, newest first; status filters when not empty.
+	ListIncoming(ctx context.Context, payerID, status string) ([]*Request, error)
+	// Requests made by requesterID, newest first; status filters when not empty.
+	ListOutgoing(ctx context.Context, requesterID, status string) ([]*Request, error)
+	// Marks pending requests past their expiry as expired.
+	ExpirePending(ctx context.Context, now time.Time) error
+	// Marks requests accepting since before staleBefore as unknown: the accept
+	// was cut off by a crash or restart mid-transfer. Returns how many.
+	AbandonAccepting(ctx context.Context, staleBefore, now time.Time) (int, error)
+	// Moves a request from one status to another, recording the transfer id
+	// and error. False when the request was no longer in status from (or, for
+	// pending requests, already expired).
+	Transition(ctx context.Context, id, from, to, transferID, errMsg string, now time.Time) (bool, error)
+
+	// Stores a split together with the payment requests for its shares.
+	CreateSplit(ctx context.Context, s *Split, requests []*Request) error
+	// A split of requesterID with its payment requests in share order;
+	// ErrNotFound when it doesn't exist or belongs to someone else.
+	GetSplit(ctx context.Context, requesterID, id string) (*Split, []*Request, error)
+	// Splits of requesterID, newest first.
+	ListSplits(ctx context.Context, requesterID string) ([]*Split, error)
+	// Payment requests of a split in share order.
+	SplitRequests(ctx context.Context, splitID string) ([]*Request, error)
+
+	Close() error
+}
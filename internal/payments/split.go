@@ -0,0 +1,104 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+// How a split's total is shared.
+const (
+	SplitEven   = "even"
+	SplitCustom = "custom"
+)
+
+// Split statuses, derived from its payment requests.
+const (
+	// Some requests are still pending or being paid.
+	SplitOpen = "open"
+	// Every request was paid.
+	SplitSettled = "settled"
+	// Nothing left to pay, but some requests were declined, canceled, expired or unknown.
+	SplitClosed = "closed"
+)
+
+var errNoParts = errors.New("a split needs at least one participant")
+
+// A bill of Total shared between the requester and some of their favorites.
+// Each participant owes their share through a payment request with SplitID set.
+type Split struct {
+	ID                string
+	RequesterID       string
+	RequesterUsername string
+	Total             string // Decimal, in Currency.
+	// Part of Total the requester pays themselves; not requested from anyone.
+	OwnShare    string
+	Currency    string
+	Description string
+	Mode        string
+	CreatedAt   time.Time
+}
+
+// Shares total into parts equal shares with the given decimals. The
+// remainder, in units of the last decimal, goes one unit each to the first
+// shares, so shares differ by at most one unit and always add up to total.
+func EvenShares(total money.Decimal, parts, decimals int) ([]money.Decimal, error) {
+	if parts < 1 {
+		return nil, errNoParts
+	}
+	units, err := total.Units(decimals, math.MaxInt64)
+	if err != nil {
+		return nil, err
+	}
+	if units < int64(parts) {
+		return nil, fmt.Errorf("%s can't be shared by %d", total, parts)
+	}
+	base, rem := units/int64(parts), units%int64(parts)
+	shares := make([]money.Decimal, parts)
+	for i := range shares {
+		share := base
+		if int64(i) < rem {
+			share++
+		}
+		shares[i] = money.FromMinor(share, decimals)
+	}
+	return shares, nil
+}
+
+// Status of a split from the statuses of its payment requests.
+func SplitStatus(requests []*Request) string {
+	settled := true
+	for _, r := range requests {
+		switch r.Status {
+		case StatusPending, StatusAccepting:
+			return SplitOpen
+		case StatusPaid:
+		default:
+			settled = false
+		}
+	}
+	if settled {
+		return SplitSettled
+	}
+	return SplitClosed
+}
+
+// Amounts paid and still pending across a split's payment requests.
+func SplitTotals(requests []*Request) (collected, outstanding money.Decimal) {
+	for _, r := range requests {
+		amount, err := money.Parse(r.Amount)
+		if err != nil {
+			continue
+		}
+		switch r.Status {
+		case StatusPaid:
+			collected = collected.Add(amount)
+		case StatusPending, StatusAccepting:
+			outstanding = outstanding.Add(amount)
+		}
+	}
+	return collected, outstanding
+}
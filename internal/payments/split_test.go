@@ -0,0 +1,57 @@
+package payments
+
+import (
+	"testing"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+func TestEvenShares(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    string
+		parts    int
+		decimals int
+		want     []string
+		wantErr  bool
+	}{
+		{name: "exact", total: "90", parts: 3, want: []string{"30", "30", "30"}},
+		{name: "remainder to the first shares", total: "100", parts: 3, want: []string{"34", "33", "33"}},
+		{name: "remainder of two", total: "11", parts: 3, want: []string{"4", "4", "3"}},
+		{name: "cents", total: "100", parts: 3, decimals: 2, want: []string{"33.34", "33.33", "33.33"}},
+		{name: "single part", total: "57", parts: 1, want: []string{"57"}},
+		{name: "one unit each", total: "3", parts: 3, want: []string{"1", "1", "1"}},
+		{name: "fewer units than parts", total: "2", parts: 3, wantErr: true},
+		{name: "fewer cents than parts", total: "0.02", parts: 3, decimals: 2, wantErr: true},
+		{name: "no parts", total: "10", parts: 0, wantErr: true},
+		{name: "more decimals than the currency", total: "10.5", parts: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := money.MustParse(tt.total)
+			shares, err := EvenShares(total, tt.parts, tt.decimals)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("EvenShares(%s, %d) = %v, want an error", tt.total, tt.parts, shares)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvenShares(%s, %d): %v", tt.total, tt.parts, err)
+			}
+			if len(shares) != len(tt.want) {
+				t.Fatalf("got %d shares, want %d", len(shares), len(tt.want))
+			}
+			var sum money.Decimal
+			for i, share := range shares {
+				if share.Cmp(money.MustParse(tt.want[i])) != 0 {
+					t.Errorf("share %d = %s, want %s", i, share, tt.want[i])
+				}
+				sum = sum.Add(share)
+			}
+			if sum.Cmp(total) != 0 {
+				t.Errorf("shares add up to %s, want %s", sum, total)
+			}
+		})
+	}
+}
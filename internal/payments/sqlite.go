@@ -0,0 +1,262 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS payment_requests (
+	id                 TEXT PRIMARY KEY,
+	requester_id       TEXT NOT NULL,
+	requester_username TEXT NOT NULL,
+	payer_id           TEXT NOT NULL,
+	payer_username     TEXT NOT NULL,
+	amount             TEXT NOT NULL,
+	currency           TEXT NOT NULL,
+	note               TEXT NOT NULL,
+	status             TEXT NOT NULL,
+	transfer_id        TEXT NOT NULL,
+	error              TEXT NOT NULL,
+	created_at         INTEGER NOT NULL,
+	expires_at         INTEGER NOT NULL,
+	updated_at         INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS payment_requests_payer ON payment_requests (payer_id, created_at);
+CREATE INDEX IF NOT EXISTS payment_requests_requester ON payment_requests (requester_id, created_at);
+CREATE INDEX IF NOT EXISTS payment_requests_expiry ON payment_requests (status, expires_at);
+`
+
+// Schema changes after the first release, applied in order; PRAGMA
+// user_version counts the ones already applied.
+var sqliteMigrations = []string{
+	`ALTER TABLE payment_requests ADD COLUMN split_id TEXT NOT NULL DEFAULT '';
+	CREATE INDEX payment_requests_split ON payment_requests (split_id);
+	CREATE TABLE splits (
+		id                 TEXT PRIMARY KEY,
+		requester_id       TEXT NOT NULL,
+		requester_username TEXT NOT NULL,
+		total              TEXT NOT NULL,
+		own_share          TEXT NOT NULL,
+		currency           TEXT NOT NULL,
+		description        TEXT NOT NULL,
+		mode               TEXT NOT NULL,
+		created_at         INTEGER NOT NULL
+	);
+	CREATE INDEX splits_requester ON splits (requester_id, created_at);`,
+}
+
+// Store backed by a SQLite file. Times are stored as Unix seconds.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating payment request tables: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating payment request tables: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[version]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+const requestColumns = `id, requester_id, requester_username, payer_id, payer_username, amount, currency, note, status, transfer_id, error, created_at, expires_at, updated_at, split_id`
+
+// Runs INSERT statements with either the database or a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (st *SQLiteStore) Create(ctx context.Context, r *Request) error {
+	return insertRequest(ctx, st.db, r)
+}
+
+func insertRequest(ctx context.Context, db execer, r *Request) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO payment_requests (`+requestColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.RequesterID, r.RequesterUsername, r.PayerID, r.PayerUsername, r.Amount, r.Currency, r.Note, r.Status,
+		r.TransferID, r.Error, r.CreatedAt.Unix(), r.ExpiresAt.Unix(), r.UpdatedAt.Unix(), r.SplitID)
+	return err
+}
+
+const splitColumns = `id, requester_id, requester_username, total, own_share, currency, description, mode, created_at`
+
+func (st *SQLiteStore) CreateSplit(ctx context.Context, s *Split, requests []*Request) error {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO splits (`+splitColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.RequesterID, s.RequesterUsername, s.Total, s.OwnShare, s.Currency, s.Description, s.Mode, s.CreatedAt.Unix())
+	if err != nil {
+		return err
+	}
+	for _, r := range requests {
+		if err := insertRequest(ctx, tx, r); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (st *SQLiteStore) GetSplit(ctx context.Context, requesterID, id string) (*Split, []*Request, error) {
+	splits, err := st.querySplits(ctx, `SELECT `+splitColumns+` FROM splits WHERE id = ? AND requester_id = ?`, id, requesterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(splits) == 0 {
+		return nil, nil, ErrNotFound
+	}
+	requests, err := st.SplitRequests(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return splits[0], requests, nil
+}
+
+func (st *SQLiteStore) ListSplits(ctx context.Context, requesterID string) ([]*Split, error) {
+	return st.querySplits(ctx, `SELECT `+splitColumns+` FROM splits WHERE requester_id = ? ORDER BY created_at DESC, id`, requesterID)
+}
+
+func (st *SQLiteStore) SplitRequests(ctx context.Context, splitID string) ([]*Request, error) {
+	return st.query(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE split_id = ? ORDER BY rowid`, splitID)
+}
+
+func (st *SQLiteStore) querySplits(ctx context.Context, query string, args ...any) ([]*Split, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var splits []*Split
+	for rows.Next() {
+		var s Split
+		var createdAt int64
+		if err := rows.Scan(&s.ID, &s.RequesterID, &s.RequesterUsername, &s.Total, &s.OwnShare, &s.Currency, &s.Description, &s.Mode, &createdAt); err != nil {
+			return nil, err
+		}
+		s.CreatedAt = time.Unix(createdAt, 0)
+		splits = append(splits, &s)
+	}
+	return splits, rows.Err()
+}
+
+func (st *SQLiteStore) Get(ctx context.Context, id string) (*Request, error) {
+	requests, err := st.query(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, ErrNotFound
+	}
+	return requests[0], nil
+}
+
+func (st *SQLiteStore) ListIncoming(ctx context.Context, payerID, status string) ([]*Request, error) {
+	return st.query(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE payer_id = ? AND (? = '' OR status = ?) ORDER BY created_at DESC, id`,
+		payerID, status, status)
+}
+
+func (st *SQLiteStore) ListOutgoing(ctx context.Context, requesterID, status string) ([]*Request, error) {
+	return st.query(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE requester_id = ? AND (? = '' OR status = ?) ORDER BY created_at DESC, id`,
+		requesterID, status, status)
+}
+
+func (st *SQLiteStore) ExpirePending(ctx context.Context, now time.Time) error {
+	_, err := st.db.ExecContext(ctx, `UPDATE payment_requests SET status = ?, updated_at = ? WHERE status = ? AND expires_at <= ?`,
+		StatusExpired, now.Unix(), StatusPending, now.Unix())
+	return err
+}
+
+func (st *SQLiteStore) AbandonAccepting(ctx context.Context, staleBefore, now time.Time) (int, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE payment_requests SET status = ?, error = ?, updated_at = ? WHERE status = ? AND updated_at < ?`,
+		StatusUnknown, "interrupted while the transfer was in flight", now.Unix(), StatusAccepting, staleBefore.Unix())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (st *SQLiteStore) Transition(ctx context.Context, id, from, to, transferID, errMsg string, now time.Time) (bool, error) {
+	query := `UPDATE payment_requests SET status = ?, transfer_id = ?, error = ?, updated_at = ? WHERE id = ? AND status = ?`
+	args := []any{to, transferID, errMsg, now.Unix(), id, from}
+	if from == StatusPending {
+		query += ` AND expires_at > ?`
+		args = append(args, now.Unix())
+	}
+	res, err := st.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) query(ctx context.Context, query string, args ...any) ([]*Request, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*Request
+	for rows.Next() {
+		var r Request
+		var createdAt, expiresAt, updatedAt int64
+		err := rows.Scan(&r.ID, &r.RequesterID, &r.RequesterUsername, &r.PayerID, &r.PayerUsername, &r.Amount, &r.Currency, &r.Note,
+			&r.Status, &r.TransferID, &r.Error, &createdAt, &expiresAt, &updatedAt, &r.SplitID)
+		if err != nil {
+			return nil, err
+		}
+		r.CreatedAt, r.ExpiresAt, r.UpdatedAt = time.Unix(createdAt, 0), time.Unix(expiresAt, 0), time.Unix(updatedAt, 0)
+		requests = append(requests, &r)
+	}
+	return requests, rows.Err()
+}
+
+var _ Store = (*SQLiteStore)(nil)
@@ -0,0 +1,57 @@
+package payments
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAbandonAccepting(t *testing.T) {
+	ctx := context.Background()
+	st, err := OpenSQLite(filepath.Join(t.TempDir(), "payment_requests.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	t0 := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	for _, r := range []*Request{
+		{ID: "stale", Status: StatusPending},
+		{ID: "in flight", Status: StatusPending},
+		{ID: "pending", Status: StatusPending},
+	} {
+		r.RequesterID, r.PayerID, r.Amount, r.Currency = "u1", "u2", "10", "USD"
+		r.CreatedAt, r.UpdatedAt, r.ExpiresAt = t0, t0, t0.Add(24*time.Hour)
+		if err := st.Create(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Claimed by accepts at t0 and two minutes later.
+	if ok, err := st.Transition(ctx, "stale", StatusPending, StatusAccepting, "", "", t0); err != nil || !ok {
+		t.Fatalf("claiming stale = %v, %v", ok, err)
+	}
+	if ok, err := st.Transition(ctx, "in flight", StatusPending, StatusAccepting, "", "", t0.Add(2*time.Minute)); err != nil || !ok {
+		t.Fatalf("claiming in flight = %v, %v", ok, err)
+	}
+
+	now := t0.Add(2*time.Minute + 10*time.Second)
+	n, err := st.AbandonAccepting(ctx, now.Add(-time.Minute), now)
+	if err != nil || n != 1 {
+		t.Fatalf("AbandonAccepting = %d, %v; want 1", n, err)
+	}
+	for id, want := range map[string]string{"stale": StatusUnknown, "in flight": StatusAccepting, "pending": StatusPending} {
+		r, err := st.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Status != want {
+			t.Errorf("%s is %s, want %s", id, r.Status, want)
+		}
+	}
+
+	// The interrupted accept can't record an outcome any more.
+	if ok, err := st.Transition(ctx, "stale", StatusAccepting, StatusPaid, "t1", "", now); err != nil || ok {
+		t.Errorf("finishing an abandoned accept = %v, %v; want false", ok, err)
+	}
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+)
+
+// Middleware the route table wraps around its handlers.
+type Options struct {
+	// Authenticates protected routes.
+	Auth func(http.Handler) http.Handler
+	// Builds the middleware enforcing a route's scopes. Required when any route declares scopes.
+	RequireScopes func(scopes ...string) func(http.Handler) http.Handler
+	// Builds handlers for routes bound to a gRPC method. Required when any route declares one.
+	Transcode func(method, naming string) (http.Handler, error)
+}
+
+// Validates the table and registers every route on the router.
+//
+// Each handler is wrapped, from the outside in, with the route timeout,
+// authentication, rate limiting and scope checks.
+func (t *Table) Mount(router *mux.Router, handlers Handlers, opts Options) error {
+	if err := t.Validate(handlers); err != nil {
+		return fmt.Errorf("invalid route table:\n%w", err)
+	}
+
+	limiters := make(map[string]*middleware.RateLimiter, len(t.RateLimits))
+	for name, policy := range t.RateLimits {
+		limiters[name] = middleware.NewRateLimiter(policy.Requests, policy.Window)
+	}
+
+	base := router
+	if t.Prefix != "" {
+		base = router.PathPrefix(t.Prefix).Subrouter()
+	}
+
+	for _, route := range t.Routes {
+		var handler http.Handler = handlers[route.Handler].Func
+		if route.GRPC != "" {
+			if opts.Transcode == nil {
+				return fmt.Errorf("route %s %s is bound to gRPC but no transcoder is configured", route.Method, route.Path)
+			}
+			transcoded, err := opts.Transcode(route.GRPC, route.FieldNaming)
+			if err != nil {
+				return fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+			}
+			handler = transcoded
+		}
+
+		if len(route.Scopes) > 0 {
+			if opts.RequireScopes == nil {
+				return fmt.Errorf("route %s %s requires scopes but no scope middleware is configured", route.Method, route.Path)
+			}
+			handler = opts.RequireScopes(route.Scopes...)(handler)
+		}
+		if route.RateLimit != "" {
+			handler = limiters[route.RateLimit].Limit(handler)
+		}
+		if route.Access == AccessProtected {
+			if opts.Auth == nil {
+				return fmt.Errorf("route %s %s is protected but no auth middleware is configured", route.Method, route.Path)
+			}
+			handler = opts.Auth(handler)
+		}
+		if timeout := t.TimeoutFor(route); timeout > 0 {
+			handler = middleware.Timeout(timeout)(handler)
+		}
+
+		base.Handle(route.Path, handler).Methods(route.Method)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3" // Also reads JSON, which is a subset of YAML.
+)
+
+// Access levels a route can declare.
+const (
+	AccessPublic    = "public"
+	AccessProtected = "protected"
+)
+
+// Declarative description of every route exposed by the gateway.
+type Table struct {
+	Prefix         string                     `yaml:"prefix"`
+	DefaultTimeout time.Duration              `yaml:"default_timeout"`
+	RateLimits     map[string]RateLimitPolicy `yaml:"rate_limits"`
+	Routes         []Route                    `yaml:"routes"`
+}
+
+// Maximum number of requests a client can make within a window.
+type RateLimitPolicy struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// A single HTTP endpoint bound to a registered handler or transcoded to a gRPC method.
+type Route struct {
+	Method      string        `yaml:"method"`
+	Path        string        `yaml:"path"`
+	Access      string        `yaml:"access"`
+	Scopes      []string      `yaml:"scopes"`
+	RateLimit   string        `yaml:"rate_limit"`
+	Timeout     time.Duration `yaml:"timeout"`
+	Handler     string        `yaml:"handler"`
+	GRPC        string        `yaml:"grpc"`
+	FieldNaming string        `yaml:"field_naming"`
+}
+
+// A handler the route table can bind to, described for the API documentation.
+type Handler struct {
+	Func    http.HandlerFunc
+	Summary string
+	Query   []Param
+	// Zero values of the JSON request and response bodies; nil when there is none.
+	Request  any
+	Response any
+	// Status written on success, 200 when zero.
+	Status int
+}
+
+// A query parameter accepted by a handler.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Handlers available to the route table, by name.
+type Handlers map[string]Handler
+
+// Reads and parses a route table from a YAML or JSON file.
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading route table: %w", err)
+	}
+	table, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+// Parses a route table. Unknown fields are rejected so typos surface at startup.
+func Parse(data []byte) (*Table, error) {
+	var table Table
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&table); err != nil {
+		return nil, fmt.Errorf("parsing route table: %w", err)
+	}
+	for i := range table.Routes {
+		table.Routes[i].Method = strings.ToUpper(table.Routes[i].Method)
+	}
+	return &table, nil
+}
+
+// Returns the route's timeout, falling back to the table default.
+func (t *Table) TimeoutFor(route Route) time.Duration {
+	if route.Timeout > 0 {
+		return route.Timeout
+	}
+	return t.DefaultTimeout
+}
+
+// Checks the table against the registered handlers and reports every problem found.
+func (t *Table) Validate(handlers Handlers) error {
+	var errs []error
+
+	if t.Prefix != "" && (!strings.HasPrefix(t.Prefix, "/") || strings.HasSuffix(t.Prefix, "/")) {
+		errs = append(errs, fmt.Errorf("prefix %q must start with '/' and not end with one", t.Prefix))
+	}
+	if t.DefaultTimeout < 0 {
+		errs = append(errs, fmt.Errorf("default_timeout must not be negative"))
+	}
+	for name, policy := range t.RateLimits {
+		if policy.Requests <= 0 || policy.Window <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %q: requests and window must be positive", name))
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, route := range t.Routes {
+		where := fmt.Sprintf("route #%d (%s %s)", i+1, route.Method, route.Path)
+
+		if route.Method == "" {
+			errs = append(errs, fmt.Errorf("%s: missing method", where))
+		}
+		if !strings.HasPrefix(route.Path, "/") {
+			errs = append(errs, fmt.Errorf("%s: path must start with '/'", where))
+		}
+		key := route.Method + " " + route.Path
+		if first, dup := seen[key]; dup {
+			errs = append(errs, fmt.Errorf("%s: duplicates route #%d", where, first))
+		} else {
+			seen[key] = i + 1
+		}
+
+		switch route.Access {
+		case AccessPublic:
+			if len(route.Scopes) > 0 {
+				errs = append(errs, fmt.Errorf("%s: public routes cannot require scopes", where))
+			}
+		case AccessProtected:
+		default:
+			errs = append(errs, fmt.Errorf("%s: access must be %q or %q, got %q", where, AccessPublic, AccessProtected, route.Access))
+		}
+
+		if route.RateLimit != "" {
+			if _, ok := t.RateLimits[route.RateLimit]; !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown rate limit policy %q", where, route.RateLimit))
+			}
+		}
+		if route.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("%s: timeout must not be negative", where))
+		}
+
+		switch {
+		case route.Handler != "" && route.GRPC != "":
+			errs = append(errs, fmt.Errorf("%s: handler and grpc are mutually exclusive", where))
+		case route.GRPC != "":
+		case route.Handler == "":
+			errs = append(errs, fmt.Errorf("%s: missing handler or grpc method", where))
+		default:
+			if _, ok := handlers[route.Handler]; !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown handler %q", where, route.Handler))
+			}
+			if route.FieldNaming != "" {
+				errs = append(errs, fmt.Errorf("%s: field_naming only applies to grpc routes", where))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Recurrence frequencies.
+const (
+	FrequencyOnce    = "once"
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+// Schedule statuses.
+const (
+	StatusActive    = "active"
+	StatusCanceled  = "canceled"
+	StatusCompleted = "completed"
+)
+
+// Run statuses.
+const (
+	// Waiting for its first or next attempt.
+	RunPending = "pending"
+	// Transfer call in flight.
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	// Gave up: the transfer was rejected or kept failing.
+	RunFailed = "failed"
+	// Not made on purpose, e.g. the account was locked when it was due.
+	RunSkipped = "skipped"
+	// Interrupted or timed out mid-call, so the transfer may or may not have
+	// happened. Never retried; needs reconciliation against the movements.
+	RunUnknown = "unknown"
+)
+
+// A standing order: Amount from UserID to ToUserID, once or on a recurrence
+// anchored on StartAt in TimeZone.
+type Schedule struct {
+	ID          string
+	UserID      string
+	ToUserID    string
+	Email       string
+	Amount      string // Decimal, in Currency.
+	Currency    string
+	Description string
+	Frequency   string
+	TimeZone    string
+	StartAt     time.Time
+	EndAt       *time.Time
+	NextRunAt   time.Time
+	Status      string
+	CreatedAt   time.Time
+}
+
+func ValidFrequency(f string) bool {
+	switch f {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+// First occurrence strictly after t; false when the schedule has no more.
+func (s *Schedule) NextAfter(t time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start := s.StartAt.In(loc)
+
+	var next time.Time
+	switch s.Frequency {
+	case FrequencyOnce:
+		if !start.After(t) {
+			return time.Time{}, false
+		}
+		next = start
+	case FrequencyDaily, FrequencyWeekly:
+		days := 1
+		if s.Frequency == FrequencyWeekly {
+			days = 7
+		}
+		next = start
+		// Skip whole periods first so long-stopped schedules don't loop.
+		if t.After(start) {
+			periods := int(t.Sub(start) / (time.Duration(days) * 24 * time.Hour))
+			next = start.AddDate(0, 0, periods*days)
+		}
+		for !next.After(t) {
+			next = next.AddDate(0, 0, days)
+		}
+	case FrequencyMonthly:
+		next = start
+		for n := 1; !next.After(t); n++ {
+			next = addMonthsClamped(start, n)
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if s.EndAt != nil && next.After(*s.EndAt) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// start plus n months, on the same day or the month's last day when shorter
+// (a schedule on the 31st runs on the 30th in April).
+func addMonthsClamped(start time.Time, n int) time.Time {
+	first := time.Date(start.Year(), start.Month()+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(start.Day(), lastDay)-1)
+}
+
+// One execution of a schedule, covering the occurrence due at DueAt.
+type Run struct {
+	// Execution id, unique per schedule and occurrence, so an occurrence is
+	// never executed twice.
+	ID            string
+	ScheduleID    string
+	DueAt         time.Time
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	TransferID    string
+	Error         string
+	UpdatedAt     time.Time
+}
+
+func ExecutionID(scheduleID string, due time.Time) string {
+	return fmt.Sprintf("%s-%d", scheduleID, due.Unix())
+}
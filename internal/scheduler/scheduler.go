@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	// Attempts per run before it is marked failed.
+	maxAttempts = 5
+	// Delay before the first retry; doubles with every attempt up to maxBackoff.
+	baseBackoff = 5 * time.Minute
+	maxBackoff  = 6 * time.Hour
+	// Schedules and runs handled per tick.
+	batchSize = 100
+	// Limit on a single transfer call.
+	executeTimeout = 15 * time.Second
+)
+
+// Executes the transfer of a schedule and returns its transfer id. Errors
+// wrapped with Retry are retried later, errors wrapped with Permanent fail the
+// run and errors wrapped with Skip skip it; any other error leaves the outcome
+// unknown and is never retried.
+type Executor func(ctx context.Context, s *Schedule) (transferID string, err error)
+
+type retryError struct{ err error }
+
+func (e retryError) Error() string { return e.err.Error() }
+func (e retryError) Unwrap() error { return e.err }
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+type skipError struct{ err error }
+
+func (e skipError) Error() string { return e.err.Error() }
+func (e skipError) Unwrap() error { return e.err }
+
+// Marks an executor error as safe to retry: the transfer certainly did not happen.
+func Retry(err error) error { return retryError{err} }
+
+// Marks an executor error as final: the transfer was rejected.
+func Permanent(err error) error { return permanentError{err} }
+
+// Marks an executor error as a reason not to make the transfer at all, e.g. the
+// account is locked. The run is skipped; later occurrences still run.
+func Skip(err error) error { return skipError{err} }
+
+// Executes due schedules in the background.
+type Scheduler struct {
+	store   Store
+	execute Executor
+	now     func() time.Time
+}
+
+func New(store Store, execute Executor) *Scheduler {
+	return &Scheduler{store: store, execute: execute, now: time.Now}
+}
+
+// Checks for due work every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	// A run still marked running was cut off by a crash or restart; its
+	// transfer may have gone through, so it must not be retried.
+	if n, err := s.store.AbandonRunning(ctx, s.now()); err != nil {
+		log.Printf("Scheduler: marking interrupted runs: %v", err)
+	} else if n > 0 {
+		log.Printf("Scheduler: %d run(s) were interrupted mid-transfer and marked unknown", n)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.now()
+
+	due, err := s.store.DueSchedules(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("Scheduler: listing due schedules: %v", err)
+		return
+	}
+	for _, sched := range due {
+		if err := s.claim(ctx, sched, now); err != nil {
+			log.Printf("Scheduler: claiming schedule %s: %v", sched.ID, err)
+		}
+	}
+
+	runs, err := s.store.PendingRuns(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("Scheduler: listing pending runs: %v", err)
+		return
+	}
+	for _, run := range runs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.attempt(ctx, run); err != nil {
+			log.Printf("Scheduler: run %s: %v", run.ID, err)
+		}
+	}
+}
+
+// Creates the run for a due schedule and moves the schedule to its next
+// occurrence. Occurrences missed while the gateway was down are executed
+// once, not once each.
+func (s *Scheduler) claim(ctx context.Context, sched *Schedule, now time.Time) error {
+	run := &Run{
+		ID:            ExecutionID(sched.ID, sched.NextRunAt),
+		ScheduleID:    sched.ID,
+		DueAt:         sched.NextRunAt,
+		Status:        RunPending,
+		NextAttemptAt: now,
+		UpdatedAt:     now,
+	}
+	if next, ok := sched.NextAfter(now); ok {
+		sched.NextRunAt = next
+	} else {
+		sched.Status = StatusCompleted
+	}
+	_, err := s.store.ClaimOccurrence(ctx, sched, run)
+	return err
+}
+
+func (s *Scheduler) attempt(ctx context.Context, run *Run) error {
+	started, err := s.store.StartRun(ctx, run.ID, s.now())
+	if err != nil || !started {
+		return err
+	}
+	run.Attempts++
+
+	sched, err := s.store.ScheduleByID(ctx, run.ScheduleID)
+	if err != nil {
+		run.Status, run.Error = RunFailed, err.Error()
+		return s.finish(run)
+	}
+	if sched.Status == StatusCanceled {
+		run.Status, run.Error = RunFailed, "schedule canceled before the transfer was made"
+		return s.finish(run)
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, executeTimeout)
+	transferID, err := s.execute(execCtx, sched)
+	cancel()
+
+	var retry retryError
+	var permanent permanentError
+	var skip skipError
+	switch {
+	case err == nil:
+		run.Status, run.TransferID, run.Error = RunSucceeded, transferID, ""
+	case errors.As(err, &skip):
+		run.Status, run.Error = RunSkipped, err.Error()
+	case errors.As(err, &permanent):
+		run.Status, run.Error = RunFailed, err.Error()
+	case errors.As(err, &retry) && run.Attempts < maxAttempts:
+		run.Status, run.Error = RunPending, err.Error()
+		run.NextAttemptAt = s.now().Add(backoff(run.Attempts))
+	case errors.As(err, &retry):
+		run.Status, run.Error = RunFailed, err.Error()
+	default:
+		run.Status, run.Error = RunUnknown, err.Error()
+	}
+	return s.finish(run)
+}
+
+// Outcomes are recorded even when the scheduler is stopping, so a finished
+// transfer is never left looking in flight.
+func (s *Scheduler) finish(run *Run) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	run.UpdatedAt = s.now()
+	return s.store.FinishRun(ctx, run)
+}
+
+func backoff(attempts int) time.Duration {
+	d := baseBackoff << (attempts - 1)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+
+func TestAttemptOutcomes(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status string
+	}{
+		{"succeeded", nil, RunSucceeded},
+		{"permanent", Permanent(errors.New("rejected")), RunFailed},
+		{"skipped", Skip(errors.New("account locked")), RunSkipped},
+		{"retried", Retry(errors.New("unavailable")), RunPending},
+		{"unknown", errors.New("deadline exceeded"), RunUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := OpenSQLite(filepath.Join(t.TempDir(), "scheduler.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			sched := &Schedule{
+				ID: "s1", UserID: "u1", ToUserID: "u2", Amount: "10", Currency: "USD",
+				Frequency: FrequencyDaily, TimeZone: "UTC",
+				StartAt: t0, NextRunAt: t0, Status: StatusActive, CreatedAt: t0,
+			}
+			if err := store.CreateSchedule(ctx, sched); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := 0
+			s := New(store, func(context.Context, *Schedule) (string, error) {
+				calls++
+				if tt.err != nil {
+					return "", tt.err
+				}
+				return "t1", nil
+			})
+			s.now = func() time.Time { return t0 }
+			s.tick(ctx)
+
+			runs, err := store.ListRuns(ctx, sched.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(runs) != 1 || calls != 1 {
+				t.Fatalf("got %d runs and %d calls, want 1 each", len(runs), calls)
+			}
+			if got := runs[0]; got.Status != tt.status {
+				t.Errorf("status = %q, want %q (error %q)", got.Status, tt.status, got.Error)
+			}
+
+			// The schedule moved on, so the next tick doesn't touch the run
+			// unless it is pending a retry.
+			s.tick(ctx)
+			if calls != 1 {
+				t.Errorf("executed %d times on the second tick, want 1", calls)
+			}
+		})
+	}
+}
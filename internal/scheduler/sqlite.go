@@ -0,0 +1,251 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS schedules (
+	id          TEXT PRIMARY KEY,
+	user_id     TEXT NOT NULL,
+	to_user_id  TEXT NOT NULL,
+	email       TEXT NOT NULL,
+	amount      TEXT NOT NULL,
+	currency    TEXT NOT NULL,
+	description TEXT NOT NULL,
+	frequency   TEXT NOT NULL,
+	time_zone   TEXT NOT NULL,
+	start_at    INTEGER NOT NULL,
+	end_at      INTEGER,
+	next_run_at INTEGER NOT NULL,
+	status      TEXT NOT NULL,
+	created_at  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS schedules_user ON schedules (user_id);
+CREATE INDEX IF NOT EXISTS schedules_due ON schedules (status, next_run_at);
+
+CREATE TABLE IF NOT EXISTS runs (
+	id              TEXT PRIMARY KEY,
+	schedule_id     TEXT NOT NULL REFERENCES schedules (id),
+	due_at          INTEGER NOT NULL,
+	status          TEXT NOT NULL,
+	attempts        INTEGER NOT NULL,
+	next_attempt_at INTEGER NOT NULL,
+	transfer_id     TEXT NOT NULL,
+	error           TEXT NOT NULL,
+	updated_at      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_schedule ON runs (schedule_id);
+CREATE INDEX IF NOT EXISTS runs_pending ON runs (status, next_attempt_at);
+`
+
+// Store backed by a SQLite file. Times are stored as Unix seconds.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating scheduler tables: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+const scheduleColumns = `id, user_id, to_user_id, email, amount, currency, description, frequency, time_zone, start_at, end_at, next_run_at, status, created_at`
+
+func (st *SQLiteStore) CreateSchedule(ctx context.Context, s *Schedule) error {
+	_, err := st.db.ExecContext(ctx, `INSERT INTO schedules (`+scheduleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.UserID, s.ToUserID, s.Email, s.Amount, s.Currency, s.Description, s.Frequency, s.TimeZone,
+		s.StartAt.Unix(), nullableUnix(s.EndAt), s.NextRunAt.Unix(), s.Status, s.CreatedAt.Unix())
+	return err
+}
+
+func (st *SQLiteStore) ListSchedules(ctx context.Context, userID string) ([]*Schedule, error) {
+	return st.querySchedules(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE user_id = ? ORDER BY created_at DESC, id`, userID)
+}
+
+func (st *SQLiteStore) GetSchedule(ctx context.Context, userID, id string) (*Schedule, error) {
+	schedules, err := st.querySchedules(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrNotFound
+	}
+	return schedules[0], nil
+}
+
+func (st *SQLiteStore) ScheduleByID(ctx context.Context, id string) (*Schedule, error) {
+	schedules, err := st.querySchedules(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrNotFound
+	}
+	return schedules[0], nil
+}
+
+func (st *SQLiteStore) CancelSchedule(ctx context.Context, userID, id string) error {
+	res, err := st.db.ExecContext(ctx, `UPDATE schedules SET status = ? WHERE id = ? AND user_id = ? AND status = ?`,
+		StatusCanceled, id, userID, StatusActive)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// Already canceled or completed is fine; unknown is not.
+		if _, err := st.GetSchedule(ctx, userID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *SQLiteStore) ResumeSchedule(ctx context.Context, userID, id string) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE schedules SET status = ? WHERE id = ? AND user_id = ? AND status = ?`,
+		StatusActive, id, userID, StatusCanceled)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) DueSchedules(ctx context.Context, now time.Time, limit int) ([]*Schedule, error) {
+	return st.querySchedules(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE status = ? AND next_run_at <= ? ORDER BY next_run_at LIMIT ?`,
+		StatusActive, now.Unix(), limit)
+}
+
+func (st *SQLiteStore) ClaimOccurrence(ctx context.Context, s *Schedule, run *Run) (bool, error) {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE schedules SET next_run_at = ?, status = ? WHERE id = ? AND status = ? AND next_run_at = ?`,
+		s.NextRunAt.Unix(), s.Status, s.ID, StatusActive, run.DueAt.Unix())
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	// The execution id makes this a no-op if the occurrence already has a run.
+	_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO runs (id, schedule_id, due_at, status, attempts, next_attempt_at, transfer_id, error, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, run.ScheduleID, run.DueAt.Unix(), run.Status, run.Attempts, run.NextAttemptAt.Unix(), run.TransferID, run.Error, run.UpdatedAt.Unix())
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+const runColumns = `id, schedule_id, due_at, status, attempts, next_attempt_at, transfer_id, error, updated_at`
+
+func (st *SQLiteStore) PendingRuns(ctx context.Context, now time.Time, limit int) ([]*Run, error) {
+	return st.queryRuns(ctx, `SELECT `+runColumns+` FROM runs WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?`,
+		RunPending, now.Unix(), limit)
+}
+
+func (st *SQLiteStore) StartRun(ctx context.Context, id string, now time.Time) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE runs SET status = ?, attempts = attempts + 1, updated_at = ? WHERE id = ? AND status = ?`,
+		RunRunning, now.Unix(), id, RunPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) FinishRun(ctx context.Context, run *Run) error {
+	_, err := st.db.ExecContext(ctx, `UPDATE runs SET status = ?, next_attempt_at = ?, transfer_id = ?, error = ?, updated_at = ? WHERE id = ?`,
+		run.Status, run.NextAttemptAt.Unix(), run.TransferID, run.Error, run.UpdatedAt.Unix(), run.ID)
+	return err
+}
+
+func (st *SQLiteStore) AbandonRunning(ctx context.Context, now time.Time) (int, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE runs SET status = ?, error = ?, updated_at = ? WHERE status = ?`,
+		RunUnknown, "interrupted while the transfer was in flight", now.Unix(), RunRunning)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (st *SQLiteStore) ListRuns(ctx context.Context, scheduleID string) ([]*Run, error) {
+	return st.queryRuns(ctx, `SELECT `+runColumns+` FROM runs WHERE schedule_id = ? ORDER BY due_at DESC`, scheduleID)
+}
+
+func (st *SQLiteStore) querySchedules(ctx context.Context, query string, args ...any) ([]*Schedule, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*Schedule
+	for rows.Next() {
+		var s Schedule
+		var startAt, nextRunAt, createdAt int64
+		var endAt sql.NullInt64
+		err := rows.Scan(&s.ID, &s.UserID, &s.ToUserID, &s.Email, &s.Amount, &s.Currency, &s.Description, &s.Frequency, &s.TimeZone,
+			&startAt, &endAt, &nextRunAt, &s.Status, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		s.StartAt, s.NextRunAt, s.CreatedAt = time.Unix(startAt, 0), time.Unix(nextRunAt, 0), time.Unix(createdAt, 0)
+		if endAt.Valid {
+			t := time.Unix(endAt.Int64, 0)
+			s.EndAt = &t
+		}
+		schedules = append(schedules, &s)
+	}
+	return schedules, rows.Err()
+}
+
+func (st *SQLiteStore) queryRuns(ctx context.Context, query string, args ...any) ([]*Run, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*Run
+	for rows.Next() {
+		var r Run
+		var dueAt, nextAttemptAt, updatedAt int64
+		if err := rows.Scan(&r.ID, &r.ScheduleID, &dueAt, &r.Status, &r.Attempts, &nextAttemptAt, &r.TransferID, &r.Error, &updatedAt); err != nil {
+			return nil, err
+		}
+		r.DueAt, r.NextAttemptAt, r.UpdatedAt = time.Unix(dueAt, 0), time.Unix(nextAttemptAt, 0), time.Unix(updatedAt, 0)
+		runs = append(runs, &r)
+	}
+	return runs, rows.Err()
+}
+
+func nullableUnix(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+var _ Store = (*SQLiteStore)(nil)
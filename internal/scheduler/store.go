@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("scheduled transfer not found")
+
+// Persists schedules and their runs.
+type Store interface {
+	CreateSchedule(ctx context.Context, s *Schedule) error
+	// Schedules of a user, newest first.
+	ListSchedules(ctx context.Context, userID string) ([]*Schedule, error)
+	// ErrNotFound when the schedule doesn't exist or belongs to someone else.
+	GetSchedule(ctx context.Context, userID, id string) (*Schedule, error)
+	CancelSchedule(ctx context.Context, userID, id string) error
+	// Makes a canceled schedule active again. False when it wasn't canceled.
+	ResumeSchedule(ctx context.Context, userID, id string) (bool, error)
+	// Schedule by id regardless of owner, for the scheduler itself.
+	ScheduleByID(ctx context.Context, id string) (*Schedule, error)
+
+	// Active schedules whose next run is at or before now.
+	DueSchedules(ctx context.Context, now time.Time, limit int) ([]*Schedule, error)
+	// Atomically creates run and moves s on to its NextRunAt and Status, provided
+	// the schedule is still active and due at run.DueAt. False when another
+	// worker claimed it or it was canceled.
+	ClaimOccurrence(ctx context.Context, s *Schedule, run *Run) (bool, error)
+
+	// Pending runs whose next attempt is at or before now.
+	PendingRuns(ctx context.Context, now time.Time, limit int) ([]*Run, error)
+	// Marks a pending run as running and counts the attempt. False when it is
+	// no longer pending.
+	StartRun(ctx context.Context, id string, now time.Time) (bool, error)
+	// Records the outcome of an attempt: status, next attempt, transfer id, error.
+	FinishRun(ctx context.Context, run *Run) error
+	// Marks runs left running by a previous process as unknown.
+	AbandonRunning(ctx context.Context, now time.Time) (int, error)
+	// Runs of a schedule, newest first.
+	ListRuns(ctx context.Context, scheduleID string) ([]*Run, error)
+
+	Close() error
+}
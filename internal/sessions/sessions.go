@@ -0,0 +1,109 @@
+package sessions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("session not found")
+
+// A login: the access token it issued and the device it was made from. The
+// auth service doesn't put session ids in tokens, so a session is found by the
+// hash of its token.
+type Session struct {
+	ID     string
+	UserID string
+	// SHA-256 of the access token; see HashToken.
+	TokenHash string
+	// Human-readable device, e.g. "Chrome on Windows".
+	Device     string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// When the access token expires; the session ends with it.
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// Whether the session can still be used at now.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Keeps track of sessions.
+type Store interface {
+	Create(ctx context.Context, s *Session) error
+	// ErrNotFound when no session issued the token.
+	ByToken(ctx context.Context, tokenHash string) (*Session, error)
+	// Active sessions of userID, newest first.
+	List(ctx context.Context, userID string, now time.Time) ([]*Session, error)
+	// Records that the session was used at now.
+	Touch(ctx context.Context, id string, now time.Time) error
+	// Revokes a session of userID. False when it has no such active session.
+	Revoke(ctx context.Context, userID, id string, now time.Time) (bool, error)
+	// Revokes every active session of userID, returning how many there were.
+	RevokeAll(ctx context.Context, userID string, now time.Time) (int, error)
+	// When the store started tracking sessions. Every login since made one, so
+	// a token issued later without a session did not come from a login here.
+	TrackingSince() time.Time
+
+	Close() error
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Browsers and systems recognized in User-Agent headers, most specific first
+// (Edge and Opera also claim to be Chrome, Chrome claims to be Safari, ...).
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"}, {"FxiOS/", "Firefox"}, {"CriOS/", "Chrome"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	systems = []struct{ token, name string }{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+)
+
+// Short description of the device a User-Agent header comes from.
+func DeviceLabel(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	// Not a browser (curl, an app's HTTP client, ...): its product name.
+	product, _, _ := strings.Cut(userAgent, " ")
+	product, _, _ = strings.Cut(product, "/")
+	if len(product) > 40 {
+		product = product[:40]
+	}
+	return product
+}
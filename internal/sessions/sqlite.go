@@ -0,0 +1,162 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id           TEXT PRIMARY KEY,
+	user_id      TEXT NOT NULL,
+	token_hash   TEXT NOT NULL UNIQUE,
+	device       TEXT NOT NULL,
+	ip           TEXT NOT NULL,
+	created_at   INTEGER NOT NULL,
+	last_seen_at INTEGER NOT NULL,
+	expires_at   INTEGER NOT NULL,
+	revoked_at   INTEGER
+);
+CREATE INDEX IF NOT EXISTS sessions_user ON sessions (user_id, expires_at);
+CREATE TABLE IF NOT EXISTS session_tracking (
+	id         INTEGER PRIMARY KEY CHECK (id = 1),
+	started_at INTEGER NOT NULL
+);
+`
+
+const sessionColumns = `id, user_id, token_hash, device, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+// Store backed by a SQLite file. Times are stored as Unix seconds.
+type SQLiteStore struct {
+	db    *sql.DB
+	since time.Time
+}
+
+// Opens the store at path, recording the current time as the start of
+// tracking the first time the file is used.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating session tables: %w", err)
+	}
+	if _, err := db.Exec(`INSERT INTO session_tracking (id, started_at) VALUES (1, ?) ON CONFLICT (id) DO NOTHING`, time.Now().Unix()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("recording start of session tracking: %w", err)
+	}
+	var since int64
+	if err := db.QueryRow(`SELECT started_at FROM session_tracking WHERE id = 1`).Scan(&since); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("reading start of session tracking: %w", err)
+	}
+	return &SQLiteStore{db: db, since: time.Unix(since, 0)}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+func (st *SQLiteStore) TrackingSince() time.Time {
+	return st.since
+}
+
+func (st *SQLiteStore) Create(ctx context.Context, s *Session) error {
+	// Drops sessions whose token has expired: nothing can use them anymore.
+	if _, err := st.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, s.CreatedAt.Unix()); err != nil {
+		return err
+	}
+	_, err := st.db.ExecContext(ctx, `INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.UserID, s.TokenHash, s.Device, s.IP, s.CreatedAt.Unix(), s.LastSeenAt.Unix(), s.ExpiresAt.Unix(), nullableUnix(s.RevokedAt))
+	return err
+}
+
+func (st *SQLiteStore) ByToken(ctx context.Context, tokenHash string) (*Session, error) {
+	list, err := st.query(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (st *SQLiteStore) List(ctx context.Context, userID string, now time.Time) ([]*Session, error) {
+	return st.query(ctx, `SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY created_at DESC, id`, userID, now.Unix())
+}
+
+func (st *SQLiteStore) Touch(ctx context.Context, id string, now time.Time) error {
+	res, err := st.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at = MAX(last_seen_at, ?) WHERE id = ?`, now.Unix(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (st *SQLiteStore) Revoke(ctx context.Context, userID, id string, now time.Time) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?`, now.Unix(), id, userID, now.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) RevokeAll(ctx context.Context, userID string, now time.Time) (int, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?`, now.Unix(), userID, now.Unix())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (st *SQLiteStore) query(ctx context.Context, query string, args ...any) ([]*Session, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*Session
+	for rows.Next() {
+		var s Session
+		var createdAt, lastSeenAt, expiresAt int64
+		var revokedAt sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.UserID, &s.TokenHash, &s.Device, &s.IP, &createdAt, &lastSeenAt, &expiresAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		s.CreatedAt, s.LastSeenAt, s.ExpiresAt = time.Unix(createdAt, 0), time.Unix(lastSeenAt, 0), time.Unix(expiresAt, 0)
+		if revokedAt.Valid {
+			t := time.Unix(revokedAt.Int64, 0)
+			s.RevokedAt = &t
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+func nullableUnix(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+var _ Store = (*SQLiteStore)(nil)
@@ -0,0 +1,105 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStoreSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	now := time.Now().Truncate(time.Second)
+
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := st.TrackingSince()
+	s := &Session{
+		ID: "s1", UserID: "u1", TokenHash: HashToken("token"), Device: "Firefox on Linux", IP: "203.0.113.7",
+		CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour),
+	}
+	if err := st.Create(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	if got := st.TrackingSince(); !got.Equal(since) {
+		t.Errorf("TrackingSince after reopening = %v, want %v", got, since)
+	}
+	got, err := st.ByToken(ctx, HashToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != s.ID || got.Device != s.Device || !got.ExpiresAt.Equal(s.ExpiresAt) || got.RevokedAt != nil {
+		t.Errorf("ByToken = %+v, want %+v", got, s)
+	}
+}
+
+func TestSQLiteStoreRevoke(t *testing.T) {
+	ctx := context.Background()
+	st, err := OpenSQLite(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	now := time.Now().Truncate(time.Second)
+	for i, id := range []string{"s1", "s2", "s3"} {
+		user := "u1"
+		if id == "s3" {
+			user = "u2"
+		}
+		created := now.Add(time.Duration(i) * time.Minute)
+		err := st.Create(ctx, &Session{
+			ID: id, UserID: user, TokenHash: HashToken(id), Device: "curl",
+			CreatedAt: created, LastSeenAt: created, ExpiresAt: now.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := st.List(ctx, "u1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "s2" || list[1].ID != "s1" {
+		t.Fatalf("List = %v, want s2 then s1", list)
+	}
+
+	if ok, err := st.Revoke(ctx, "u2", "s1", now); err != nil || ok {
+		t.Errorf("revoking another user's session = %v, %v; want false", ok, err)
+	}
+	if ok, err := st.Revoke(ctx, "u1", "s1", now); err != nil || !ok {
+		t.Errorf("Revoke = %v, %v; want true", ok, err)
+	}
+	if ok, _ := st.Revoke(ctx, "u1", "s1", now); ok {
+		t.Error("revoking twice succeeded")
+	}
+	if n, err := st.RevokeAll(ctx, "u1", now); err != nil || n != 1 {
+		t.Errorf("RevokeAll = %d, %v; want 1", n, err)
+	}
+	if list, _ := st.List(ctx, "u2", now); len(list) != 1 {
+		t.Errorf("RevokeAll touched another user's sessions")
+	}
+	s, err := st.ByToken(ctx, HashToken("s2"))
+	if err != nil || s.RevokedAt == nil {
+		t.Errorf("ByToken after RevokeAll = %+v, %v; want revoked", s, err)
+	}
+	if _, err := st.ByToken(ctx, HashToken("unknown")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown token: err = %v, want ErrNotFound", err)
+	}
+	if err := st.Touch(ctx, "unknown", now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Touch of unknown session: err = %v, want ErrNotFound", err)
+	}
+}
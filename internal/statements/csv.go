@@ -0,0 +1,59 @@
+package statements
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Rows written between flushes to the client.
+const csvFlushEvery = 100
+
+// Writes one row per movement, flushing periodically so large exports stream.
+type csvWriter struct {
+	out      io.Writer
+	csv      *csv.Writer
+	currency string
+	rows     int
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{out: w, csv: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(s *Statement) error {
+	c.currency = s.Currency.Code
+	return c.csv.Write([]string{"date", "transfer_id", "from_username", "to_username", "direction", "amount", "currency"})
+}
+
+func (c *csvWriter) Write(e Entry) error {
+	direction := "out"
+	if e.Incoming {
+		direction = "in"
+	}
+	err := c.csv.Write([]string{
+		e.Time.UTC().Format(time.RFC3339), e.TransferId, e.FromUsername, e.ToUsername, direction, formatAmount(e.Amount), c.currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%csvFlushEvery == 0 {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *csvWriter) End(s *Statement) error {
+	return c.flush()
+}
+
+func (c *csvWriter) flush() error {
+	c.csv.Flush()
+	if f, ok := c.out.(http.Flusher); ok {
+		f.Flush()
+	}
+	return c.csv.Error()
+}
@@ -0,0 +1,79 @@
+package statements
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OFX date-time format (YYYYMMDDHHMMSS, UTC).
+const ofxTime = "20060102150405"
+
+// Writes an OFX 2.2 bank statement, streaming transactions as they come.
+type ofxWriter struct {
+	w *bufio.Writer
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{w: bufio.NewWriter(w)}
+}
+
+func (o *ofxWriter) Begin(s *Statement) error {
+	now := time.Now().UTC().Format(ofxTime)
+	fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>0</TRNUID>
+<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS>
+<CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>NOVA</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>%s</DTSTART>
+<DTEND>%s</DTEND>
+`, now, escapeXML(s.Currency.Code), escapeXML(s.UserID), s.From.UTC().Format(ofxTime), s.To.UTC().Format(ofxTime))
+	return o.w.Flush()
+}
+
+func (o *ofxWriter) Write(e Entry) error {
+	kind := "DEBIT"
+	if e.Incoming {
+		kind = "CREDIT"
+	}
+	_, err := fmt.Fprintf(o.w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+		kind, e.Time.UTC().Format(ofxTime), formatAmount(e.Amount), escapeXML(e.TransferId),
+		escapeXML(truncate(e.Counterparty(), 32)), escapeXML(e.FromUsername+" -> "+e.ToUsername))
+	return err
+}
+
+func (o *ofxWriter) End(s *Statement) error {
+	fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`, formatAmount(s.Closing), s.To.UTC().Format(ofxTime))
+	return o.w.Flush()
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// OFX limits NAME to 32 characters.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
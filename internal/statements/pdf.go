@@ -0,0 +1,145 @@
+package statements
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Page layout, in points (A4).
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLineHeight   = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfHeaderLines  = 6
+)
+
+// Renders a plain-text statement as a multi-page PDF using the built-in
+// Courier font, so columns line up without font metrics. The document is
+// assembled in memory and written out on End.
+type pdfWriter struct {
+	out   io.Writer
+	lines []string
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	return &pdfWriter{out: w}
+}
+
+func (p *pdfWriter) Begin(s *Statement) error {
+	p.lines = append(p.lines, fmt.Sprintf("Opening balance: %s %s", formatAmount(s.Opening), s.Currency.Code), "")
+	return nil
+}
+
+func (p *pdfWriter) Write(e Entry) error {
+	p.lines = append(p.lines, fmt.Sprintf("%-16s  %-20s  %-20s  %14s",
+		e.Time.UTC().Format("2006-01-02 15:04"), clip(e.FromUsername, 20), clip(e.ToUsername, 20), formatAmount(e.Amount)))
+	return nil
+}
+
+func (p *pdfWriter) End(s *Statement) error {
+	p.lines = append(p.lines, "", fmt.Sprintf("Closing balance: %s %s", formatAmount(s.Closing), s.Currency.Code))
+
+	perPage := pdfLinesPerPage - pdfHeaderLines
+	var pages [][]string
+	for start := 0; start < len(p.lines); start += perPage {
+		end := min(start+perPage, len(p.lines))
+		pages = append(pages, p.lines[start:end])
+	}
+
+	header := func(n int) []string {
+		return []string{
+			"Nova - Account statement",
+			fmt.Sprintf("Account: %s (%s)", s.Username, s.UserID),
+			fmt.Sprintf("Period: %s to %s", s.From.UTC().Format(time.DateOnly), s.To.UTC().Format(time.DateOnly)),
+			fmt.Sprintf("Page %d of %d", n, len(pages)),
+			"",
+			fmt.Sprintf("%-16s  %-20s  %-20s  %14s", "Date (UTC)", "From", "To", "Amount"),
+		}
+	}
+
+	var contents [][]byte
+	for i, body := range pages {
+		contents = append(contents, pdfPageContent(append(header(i+1), body...)))
+	}
+	_, err := p.out.Write(assemblePDF(contents))
+	return err
+}
+
+// Content stream drawing one line of text per entry, top to bottom.
+func pdfPageContent(lines []string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) '\n", pdfEscape(line))
+	}
+	b.WriteString("ET\n")
+	return b.Bytes()
+}
+
+// Builds the PDF file: catalog, page tree, font, then a page and content stream per page.
+func assemblePDF(contents [][]byte) []byte {
+	var b bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-3 are fixed; page i uses objects 4+2i (page) and 5+2i (content).
+	kids := make([]string, len(contents))
+	for i := range contents {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(contents)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, content := range contents {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
+
+// Escapes a string for a PDF literal, mapping to WinAnsi (Latin-1) and
+// replacing anything outside it.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "~"
+}
@@ -0,0 +1,72 @@
+package statements
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+)
+
+// Supported export formats.
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatPDF = "pdf"
+)
+
+// Account statement for a user over a period.
+type Statement struct {
+	UserID   string
+	Username string
+	Currency money.Currency
+	From     time.Time
+	To       time.Time
+	// Balances before the first and after the last movement of the period.
+	Opening money.Money
+	Closing money.Money
+}
+
+// A movement as seen from the statement owner.
+type Entry struct {
+	transformers.Movement
+	Time     time.Time
+	Incoming bool
+	Amount   money.Money // Signed: negative for outgoing movements.
+}
+
+// Counterparty of the entry.
+func (e Entry) Counterparty() string {
+	if e.Incoming {
+		return e.FromUsername
+	}
+	return e.ToUsername
+}
+
+// Renders a statement incrementally: Begin once, Write per entry in
+// chronological order, then End.
+type Writer interface {
+	Begin(s *Statement) error
+	Write(e Entry) error
+	End(s *Statement) error
+}
+
+// Returns the writer for format along with its content type. Format names
+// double as file extensions.
+func NewWriter(format string, w io.Writer) (Writer, string, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), "text/csv; charset=utf-8", nil
+	case FormatOFX:
+		return newOFXWriter(w), "application/x-ofx", nil
+	case FormatPDF:
+		return newPDFWriter(w), "application/pdf", nil
+	}
+	return nil, "", fmt.Errorf("unsupported format %q", format)
+}
+
+// Formats an amount with its currency's decimals.
+func formatAmount(m money.Money) string {
+	return m.String()
+}
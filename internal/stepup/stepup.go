@@ -0,0 +1,302 @@
+package stepup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/notify"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
+)
+
+// Actions a step-up grant can authorize.
+const (
+	ActionTransfer      = "transfer"
+	ActionDeleteUser    = "delete_user"
+	ActionUpdateContact = "update_contact"
+	ActionExportData    = "export_data"
+)
+
+var (
+	ErrNotFound        = errors.New("step-up challenge not found or expired")
+	ErrInvalidCode     = errors.New("invalid code")
+	ErrTooManyAttempts = errors.New("too many attempts; start a new challenge")
+)
+
+const (
+	codeDigits  = 6
+	codeTTL     = 5 * time.Minute
+	grantTTL    = 5 * time.Minute
+	maxAttempts = 5
+)
+
+// A one-time code sent to a user to confirm an operation.
+type Challenge struct {
+	ID        string
+	UserID    string
+	Action    string
+	ExpiresAt time.Time
+
+	operation string
+	codeMAC   []byte
+	attempts  int
+}
+
+// Proof that a user confirmed an operation, valid once until ExpiresAt.
+type Grant struct {
+	Token     string
+	Action    string
+	ExpiresAt time.Time
+}
+
+type grantClaims struct {
+	ID        string `json:"i"`
+	UserID    string `json:"u"`
+	Action    string `json:"a"`
+	Operation string `json:"o"`
+	Exp       int64  `json:"e"`
+}
+
+// Codes of an authenticator app the user may answer a challenge with instead
+// of the code sent to them; twofactor.Service implements it. Verify fails with
+// twofactor.ErrInvalidCode for a wrong code.
+type SecondFactor interface {
+	Enabled(ctx context.Context, userID string) (bool, error)
+	// Checks and uses up a code; the bool tells whether it was a recovery code.
+	Verify(ctx context.Context, userID, code string, now time.Time) (bool, error)
+}
+
+// Issues step-up challenges and the grants that answer them.
+//
+// Challenges and redeemed grants live in memory: with several gateway
+// instances, the one that issued a challenge must verify it, and a grant is
+// only known to be used on the instance that redeemed it. Grants are
+// HMAC-signed and work on any instance sharing the secret.
+type Service struct {
+	Notifier notify.Notifier
+	// Optional; when the user has it enabled, its codes answer challenges too.
+	SecondFactor SecondFactor
+	// Transfers above this amount (in the account currency) need a grant; nil
+	// means none do.
+	TransferThreshold *money.Decimal
+
+	key        []byte
+	mu         sync.Mutex
+	challenges map[string]*Challenge
+	// Expiry of each redeemed grant, by id; dropped once the grant expires.
+	redeemed map[string]time.Time
+}
+
+// Digest of what a grant approves, e.g. the recipient and amount of a
+// transfer. A grant only covers a request whose operation has the same digest.
+func Operation(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns a service signing grants with secret; an empty secret gets a random
+// per-process key, which invalidates outstanding grants on restart.
+func NewService(secret string, notifier notify.Notifier, secondFactor SecondFactor, transferThreshold *money.Decimal) *Service {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("stepup: generating grant key: %v", err))
+		}
+		log.Println("STEP_UP_SECRET not set, using a random key: step-up grants will not survive restarts.")
+	}
+	return &Service{
+		Notifier:          notifier,
+		SecondFactor:      secondFactor,
+		TransferThreshold: transferThreshold,
+		key:               key,
+		challenges:        make(map[string]*Challenge),
+		redeemed:          make(map[string]time.Time),
+	}
+}
+
+// Whether a transfer of amount needs a grant.
+func (s *Service) TransferNeedsStepUp(amount money.Decimal) bool {
+	return s.TransferThreshold != nil && amount.Cmp(*s.TransferThreshold) > 0
+}
+
+// Starts a challenge for userID to confirm operation, a digest made by
+// Operation, and sends its code to address. It replaces any open challenge of
+// the user for the same action.
+func (s *Service) Begin(ctx context.Context, userID, address, action, operation string, now time.Time) (*Challenge, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return nil, err
+	}
+	code := fmt.Sprintf("%0*d", codeDigits, n)
+	c := &Challenge{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Action:    action,
+		ExpiresAt: now.Add(codeTTL),
+		operation: operation,
+	}
+	c.codeMAC = s.codeMAC(c.ID, code)
+
+	s.mu.Lock()
+	for id, old := range s.challenges {
+		if !now.Before(old.ExpiresAt) || (old.UserID == userID && old.Action == action) {
+			delete(s.challenges, id)
+		}
+	}
+	s.challenges[c.ID] = c
+	s.mu.Unlock()
+
+	err = s.Notifier.Send(ctx, notify.Message{
+		To:      address,
+		Subject: "Your Nova confirmation code",
+		Body:    fmt.Sprintf("Your code to confirm the %s is %s. It expires in %d minutes.", strings.ReplaceAll(action, "_", " "), code, int(codeTTL.Minutes())),
+	})
+	if err != nil {
+		s.mu.Lock()
+		delete(s.challenges, c.ID)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("sending step-up code: %w", err)
+	}
+	return c, nil
+}
+
+// Checks code against challenge id of userID and returns a grant for its
+// operation. The code is the one sent to the user or, when they have the
+// second factor enabled, one of its codes. A challenge can be answered once
+// and tried at most maxAttempts times.
+func (s *Service) Verify(ctx context.Context, id, userID, code string, now time.Time) (*Grant, error) {
+	code = strings.TrimSpace(code)
+	s.mu.Lock()
+	c, ok := s.challenges[id]
+	if !ok || c.UserID != userID || !now.Before(c.ExpiresAt) {
+		s.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	if c.attempts >= maxAttempts {
+		s.mu.Unlock()
+		return nil, ErrTooManyAttempts
+	}
+	c.attempts++
+	attempts := c.attempts
+	matched := hmac.Equal(c.codeMAC, s.codeMAC(id, code))
+	s.mu.Unlock()
+
+	// The second factor is checked without the lock: it reads its store.
+	if !matched {
+		var err error
+		if matched, err = s.secondFactorCode(ctx, userID, code, now); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.challenges[id] != c {
+		// Answered concurrently.
+		return nil, ErrNotFound
+	}
+	if !matched {
+		if attempts >= maxAttempts {
+			delete(s.challenges, id)
+			return nil, ErrTooManyAttempts
+		}
+		return nil, ErrInvalidCode
+	}
+	delete(s.challenges, id)
+
+	claims := grantClaims{ID: uuid.NewString(), UserID: userID, Action: c.Action, Operation: c.operation, Exp: now.Add(grantTTL).Unix()}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.RawURLEncoding
+	return &Grant{
+		Token:     enc.EncodeToString(payload) + "." + enc.EncodeToString(s.sign(payload)),
+		Action:    c.Action,
+		ExpiresAt: time.Unix(claims.Exp, 0),
+	}, nil
+}
+
+// Whether code is a valid second factor code of userID; false when the user
+// doesn't have it enabled.
+func (s *Service) secondFactorCode(ctx context.Context, userID, code string, now time.Time) (bool, error) {
+	if s.SecondFactor == nil {
+		return false, nil
+	}
+	enabled, err := s.SecondFactor.Enabled(ctx, userID)
+	if err != nil || !enabled {
+		return false, err
+	}
+	_, err = s.SecondFactor.Verify(ctx, userID, code, now)
+	if errors.Is(err, twofactor.ErrInvalidCode) || errors.Is(err, twofactor.ErrNotEnabled) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Uses up token if it is an unexpired, unused grant for userID to perform
+// operation, a digest made by Operation, as action. Reports whether it was.
+func (s *Service) Redeem(token, userID, action, operation string, now time.Time) bool {
+	enc := base64.RawURLEncoding
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return false
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, s.sign(payload)) {
+		return false
+	}
+	var claims grantClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false
+	}
+	if claims.ID == "" || claims.UserID != userID || claims.Action != action || claims.Operation != operation || now.Unix() >= claims.Exp {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, exp := range s.redeemed {
+		if now.Unix() >= exp.Unix() {
+			delete(s.redeemed, id)
+		}
+	}
+	if _, used := s.redeemed[claims.ID]; used {
+		return false
+	}
+	s.redeemed[claims.ID] = time.Unix(claims.Exp, 0)
+	return true
+}
+
+func (s *Service) codeMAC(id, code string) []byte {
+	return s.sign([]byte("code:" + id + ":" + code))
+}
+
+func (s *Service) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
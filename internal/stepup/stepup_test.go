@@ -0,0 +1,200 @@
+package stepup
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/notify"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
+)
+
+var t0 = time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)
+
+// Keeps the last message instead of sending it.
+type lastMessage struct{ notify.Message }
+
+func (l *lastMessage) Send(_ context.Context, m notify.Message) error {
+	l.Message = m
+	return nil
+}
+
+var sentCode = regexp.MustCompile(`\b\d{6}\b`)
+
+// Accepts code for enabled users only.
+type fakeSecondFactor struct {
+	enabled bool
+	code    string
+}
+
+func (f fakeSecondFactor) Enabled(context.Context, string) (bool, error) { return f.enabled, nil }
+
+func (f fakeSecondFactor) Verify(_ context.Context, _, code string, _ time.Time) (bool, error) {
+	if !f.enabled {
+		return false, twofactor.ErrNotEnabled
+	}
+	if code != f.code {
+		return false, twofactor.ErrInvalidCode
+	}
+	return false, nil
+}
+
+// Starts a transfer challenge of u1 for op and answers it with the code sent.
+func grant(t *testing.T, s *Service, op string) *Grant {
+	t.Helper()
+	sent := s.Notifier.(*lastMessage)
+	c, err := s.Begin(context.Background(), "u1", "u1@example.com", ActionTransfer, op, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.Verify(context.Background(), c.ID, "u1", sentCode.FindString(sent.Body), t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestRedeem(t *testing.T) {
+	op := Operation("transfer", "u2", "100", "USD")
+	tests := []struct {
+		name   string
+		userID string
+		action string
+		op     string
+		at     time.Time
+		want   bool
+	}{
+		{"same operation", "u1", ActionTransfer, op, t0.Add(time.Minute), true},
+		{"expired", "u1", ActionTransfer, op, t0.Add(grantTTL), false},
+		{"wrong action", "u1", ActionDeleteUser, op, t0.Add(time.Minute), false},
+		{"wrong user", "u2", ActionTransfer, op, t0.Add(time.Minute), false},
+		{"other amount", "u1", ActionTransfer, Operation("transfer", "u2", "1000", "USD"), t0.Add(time.Minute), false},
+		{"other recipient", "u1", ActionTransfer, Operation("transfer", "u3", "100", "USD"), t0.Add(time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService("secret", &lastMessage{}, nil, nil)
+			g := grant(t, s, op)
+			if got := s.Redeem(g.Token, tt.userID, tt.action, tt.op, tt.at); got != tt.want {
+				t.Errorf("Redeem = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedeemOnce(t *testing.T) {
+	s := NewService("secret", &lastMessage{}, nil, nil)
+	op := Operation("transfer", "u2", "100", "USD")
+	g := grant(t, s, op)
+	if !s.Redeem(g.Token, "u1", ActionTransfer, op, t0) {
+		t.Fatal("first Redeem failed")
+	}
+	if s.Redeem(g.Token, "u1", ActionTransfer, op, t0.Add(time.Second)) {
+		t.Error("grant redeemed twice")
+	}
+	// A new grant for the same operation is a new approval.
+	if g2 := grant(t, s, op); !s.Redeem(g2.Token, "u1", ActionTransfer, op, t0) {
+		t.Error("second grant refused")
+	}
+}
+
+func TestRedeemRejectsForgedGrants(t *testing.T) {
+	s := NewService("secret", &lastMessage{}, nil, nil)
+	op := Operation("export")
+	g := grant(t, s, op)
+	other := NewService("other secret", &lastMessage{}, nil, nil)
+	if other.Redeem(g.Token, "u1", ActionTransfer, op, t0) {
+		t.Error("grant signed with another key accepted")
+	}
+	if s.Redeem(g.Token+"x", "u1", ActionTransfer, op, t0) || s.Redeem("", "u1", ActionTransfer, op, t0) {
+		t.Error("malformed grant accepted")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	sent := &lastMessage{}
+	s := NewService("secret", sent, fakeSecondFactor{enabled: true, code: "654321"}, nil)
+	op := Operation("profile", "u1")
+
+	t.Run("second factor code", func(t *testing.T) {
+		c, err := s.Begin(ctx, "u1", "u1@example.com", ActionDeleteUser, op, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := s.Verify(ctx, c.ID, "u1", "654321", t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Redeem(g.Token, "u1", ActionDeleteUser, op, t0) {
+			t.Error("grant from a second factor code refused")
+		}
+	})
+
+	t.Run("second factor not enabled", func(t *testing.T) {
+		s := NewService("secret", &lastMessage{}, fakeSecondFactor{code: "654321"}, nil)
+		c, err := s.Begin(ctx, "u1", "u1@example.com", ActionDeleteUser, op, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Verify(ctx, c.ID, "u1", "654321", t0); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("err = %v, want ErrInvalidCode", err)
+		}
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		c, err := s.Begin(ctx, "u1", "u1@example.com", ActionDeleteUser, op, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Verify(ctx, c.ID, "u2", sentCode.FindString(sent.Body), t0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("expired challenge", func(t *testing.T) {
+		c, err := s.Begin(ctx, "u1", "u1@example.com", ActionDeleteUser, op, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Verify(ctx, c.ID, "u1", sentCode.FindString(sent.Body), t0.Add(codeTTL)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("answered once", func(t *testing.T) {
+		c, err := s.Begin(ctx, "u1", "u1@example.com", ActionDeleteUser, op, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := sentCode.FindString(sent.Body)
+		if _, err := s.Verify(ctx, c.ID, "u1", code, t0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Verify(ctx, c.ID, "u1", code, t0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("attempt limit", func(t *testing.T) {
+		c, err := s.Begin(ctx, "u1", "u1@example.com", ActionDeleteUser, op, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := sentCode.FindString(sent.Body)
+		wrong := "x" + code
+		for i := 1; i < maxAttempts; i++ {
+			if _, err := s.Verify(ctx, c.ID, "u1", wrong, t0); !errors.Is(err, ErrInvalidCode) {
+				t.Fatalf("attempt %d: err = %v, want ErrInvalidCode", i, err)
+			}
+		}
+		if _, err := s.Verify(ctx, c.ID, "u1", wrong, t0); !errors.Is(err, ErrTooManyAttempts) {
+			t.Errorf("last attempt: err = %v, want ErrTooManyAttempts", err)
+		}
+		if _, err := s.Verify(ctx, c.ID, "u1", code, t0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("after the limit: err = %v, want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package timerange
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // The runtime image ships without a zoneinfo database.
+)
+
+const (
+	// Window used when neither end of the range is given.
+	DefaultWindow = 30 * 24 * time.Hour
+	// Widest range a single request may cover.
+	MaxWindow = 366 * 24 * time.Hour
+)
+
+// Header and query parameter naming the client's IANA time zone, e.g. America/Bogota.
+const (
+	TimezoneHeader = "X-Timezone"
+	TimezoneParam  = "tz"
+)
+
+// Query parameter selecting a named range instead of explicit bounds.
+const RangeParam = "range"
+
+var errTooWide = fmt.Errorf("Time range must not exceed %d days", int(MaxWindow/(24*time.Hour)))
+
+var lastPattern = regexp.MustCompile(`^last_([0-9]+)([hd])$`)
+
+// A closed interval of time, both ends inclusive to the second.
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Bounds as Unix seconds, the way the transaction service expects them.
+func (r Range) Unix() (from, to uint64) {
+	return uint64(r.From.Unix()), uint64(r.To.Unix())
+}
+
+// Resolves the time range of a request from the fromKey and toKey parameters
+// or the range parameter, in the client's time zone.
+//
+// Bounds may be Unix seconds, RFC 3339 timestamps, local date-times
+// (2006-01-02T15:04:05) or dates; a date as the upper bound covers the whole
+// day. Named ranges are today, yesterday, this_week, this_month, last_month,
+// this_year and last_<n>d / last_<n>h. Missing bounds default to now and to
+// DefaultWindow before the upper bound.
+func Parse(r *http.Request, fromKey, toKey string) (Range, error) {
+	loc, err := Location(r)
+	if err != nil {
+		return Range{}, err
+	}
+	return parse(r.URL.Query(), fromKey, toKey, loc, time.Now())
+}
+
+// Client time zone from the X-Timezone header or the tz parameter; UTC when neither is set.
+func Location(r *http.Request) (*time.Location, error) {
+	name := r.Header.Get(TimezoneHeader)
+	if name == "" {
+		name = r.URL.Query().Get(TimezoneParam)
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid time zone '%s'", name)
+	}
+	return loc, nil
+}
+
+func parse(query url.Values, fromKey, toKey string, loc *time.Location, now time.Time) (Range, error) {
+	now = now.In(loc)
+	fromStr, toStr, named := query.Get(fromKey), query.Get(toKey), query.Get(RangeParam)
+
+	var rng Range
+	var err error
+	switch {
+	case named != "":
+		if fromStr != "" || toStr != "" {
+			return Range{}, fmt.Errorf("'%s' cannot be combined with '%s' or '%s'", RangeParam, fromKey, toKey)
+		}
+		if rng, err = namedRange(named, now); err != nil {
+			return Range{}, err
+		}
+	default:
+		rng.To = now
+		if toStr != "" {
+			if rng.To, err = ParseTime(toStr, loc, true); err != nil {
+				return Range{}, fmt.Errorf("Invalid '%s' time format", toKey)
+			}
+		}
+		rng.From = rng.To.Add(-DefaultWindow)
+		if fromStr != "" {
+			if rng.From, err = ParseTime(fromStr, loc, false); err != nil {
+				return Range{}, fmt.Errorf("Invalid '%s' time format", fromKey)
+			}
+		}
+	}
+
+	if rng.From.Unix() < 0 {
+		return Range{}, fmt.Errorf("'%s' must not be before 1970", fromKey)
+	}
+	if rng.From.After(rng.To) {
+		return Range{}, fmt.Errorf("'%s' must not be after '%s'", fromKey, toKey)
+	}
+	if rng.To.Sub(rng.From) > MaxWindow {
+		return Range{}, errTooWide
+	}
+	return rng, nil
+}
+
+// Parses a time in any of the formats Parse accepts; end selects the last
+// second of the day for bare dates.
+func ParseTime(s string, loc *time.Location, end bool) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0).In(loc), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", s, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t, nil
+}
+
+func namedRange(name string, now time.Time) (Range, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	switch name {
+	case "today":
+		return Range{From: day, To: now}, nil
+	case "yesterday":
+		return Range{From: day.AddDate(0, 0, -1), To: day.Add(-time.Second)}, nil
+	case "this_week":
+		// Weeks start on Monday.
+		offset := (int(now.Weekday()) + 6) % 7
+		return Range{From: day.AddDate(0, 0, -offset), To: now}, nil
+	case "this_month":
+		return Range{From: month, To: now}, nil
+	case "last_month":
+		return Range{From: month.AddDate(0, -1, 0), To: month.Add(-time.Second)}, nil
+	case "this_year":
+		return Range{From: time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), To: now}, nil
+	}
+
+	if m := lastPattern.FindStringSubmatch(strings.ToLower(name)); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err == nil && n > 0 {
+			unit := time.Hour
+			if m[2] == "d" {
+				unit = 24 * time.Hour
+			}
+			if time.Duration(n) > MaxWindow/unit {
+				return Range{}, errTooWide
+			}
+			return Range{From: now.Add(-time.Duration(n) * unit), To: now}, nil
+		}
+	}
+	return Range{}, fmt.Errorf("Invalid '%s', use today, yesterday, this_week, this_month, last_month, this_year or last_<n>d", RangeParam)
+}
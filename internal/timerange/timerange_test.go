@@ -0,0 +1,144 @@
+package timerange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A Friday, mid-afternoon in UTC: Friday morning in Bogota, Saturday in Tokyo.
+var now = time.Date(2025, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+func zone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	bogota, tokyo, newYork := zone(t, "America/Bogota"), zone(t, "Asia/Tokyo"), zone(t, "America/New_York")
+	tests := []struct {
+		name     string
+		query    string
+		loc      *time.Location
+		from, to time.Time
+	}{
+		{"default window", "", time.UTC, now.Add(-DefaultWindow), now},
+		{"default window before to", "to=2025-03-01", time.UTC, utc("2025-03-01T23:59:59Z").Add(-DefaultWindow), utc("2025-03-01T23:59:59Z")},
+		{"from until now", "from=2025-03-01", time.UTC, utc("2025-03-01T00:00:00Z"), now},
+		{"dates in the client zone", "from=2025-03-01&to=2025-03-01", bogota, utc("2025-03-01T05:00:00Z"), utc("2025-03-02T04:59:59Z")},
+		{"local date-time", "from=2025-03-01T09:00:00&to=2025-03-01T18:00:00", tokyo, utc("2025-03-01T00:00:00Z"), utc("2025-03-01T09:00:00Z")},
+		{"offset wins over the zone", "from=2025-03-01T00:00:00%2B09:00&to=2025-03-01T00:00:00Z", bogota, utc("2025-02-28T15:00:00Z"), utc("2025-03-01T00:00:00Z")},
+		{"unix seconds", "from=1740787200&to=1740873599", tokyo, utc("2025-03-01T00:00:00Z"), utc("2025-03-01T23:59:59Z")},
+		// Clocks went forward that day: it has 23 hours.
+		{"daylight saving day", "from=2025-03-09&to=2025-03-09", newYork, utc("2025-03-09T05:00:00Z"), utc("2025-03-10T03:59:59Z")},
+		{"exactly the widest", "from=2024-03-01T00:00:00Z&to=2025-03-02T00:00:00Z", time.UTC, utc("2024-03-01T00:00:00Z"), utc("2025-03-02T00:00:00Z")},
+
+		{"today", "range=today", bogota, utc("2025-03-14T05:00:00Z"), now},
+		{"today where it is tomorrow", "range=today", tokyo, utc("2025-03-14T15:00:00Z"), now},
+		{"yesterday", "range=yesterday", tokyo, utc("2025-03-13T15:00:00Z"), utc("2025-03-14T14:59:59Z")},
+		{"this week", "range=this_week", bogota, utc("2025-03-10T05:00:00Z"), now},
+		{"this week on a Saturday", "range=this_week", tokyo, utc("2025-03-09T15:00:00Z"), now},
+		{"this month", "range=this_month", time.UTC, utc("2025-03-01T00:00:00Z"), now},
+		{"last month", "range=last_month", time.UTC, utc("2025-02-01T00:00:00Z"), utc("2025-02-28T23:59:59Z")},
+		{"this year", "range=this_year", tokyo, utc("2024-12-31T15:00:00Z"), now},
+		{"last days", "range=last_7d", bogota, now.Add(-7 * 24 * time.Hour), now},
+		{"last hours", "range=LAST_12H", time.UTC, now.Add(-12 * time.Hour), now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parse(query, "from", "to", tt.loc, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.From.Equal(tt.from) || !got.To.Equal(tt.to) {
+				t.Errorf("range = %v .. %v, want %v .. %v", got.From.UTC(), got.To.UTC(), tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"from after to", "from=2025-03-02&to=2025-03-01", "'from' must not be after 'to'"},
+		{"from after now", "from=2025-04-01", "'from' must not be after 'to'"},
+		{"too wide", "from=2024-03-01T00:00:00Z&to=2025-03-02T00:00:01Z", "must not exceed 366 days"},
+		{"too wide by default", "from=2020-01-01", "must not exceed 366 days"},
+		{"too many days", "range=last_367d", "must not exceed 366 days"},
+		{"too many hours", "range=last_8785h", "must not exceed 366 days"},
+		{"before 1970", "from=-1&to=10", "'from' must not be before 1970"},
+		{"bad from", "from=yesterday", "Invalid 'from' time format"},
+		{"bad to", "to=2025-13-01", "Invalid 'to' time format"},
+		{"range and bounds", "range=today&from=2025-03-01", "'range' cannot be combined"},
+		{"unknown range", "range=fortnight", "Invalid 'range'"},
+		{"empty last", "range=last_0d", "Invalid 'range'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = parse(query, "from", "to", time.UTC, now)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		param  string
+		want   string
+	}{
+		{"none", "", "", "UTC"},
+		{"header", "America/Bogota", "", "America/Bogota"},
+		{"parameter", "", "Asia/Tokyo", "Asia/Tokyo"},
+		{"header first", "America/Bogota", "Asia/Tokyo", "America/Bogota"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/movements?tz="+url.QueryEscape(tt.param), nil)
+			if tt.header != "" {
+				r.Header.Set(TimezoneHeader, tt.header)
+			}
+			loc, err := Location(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loc.String() != tt.want {
+				t.Errorf("location = %s, want %s", loc, tt.want)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/movements?tz=Mars/Olympus", nil)
+	if _, err := Location(r); err == nil || !strings.Contains(err.Error(), "Invalid time zone 'Mars/Olympus'") {
+		t.Errorf("unknown zone: err = %v", err)
+	}
+}
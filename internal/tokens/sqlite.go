@@ -0,0 +1,61 @@
+package tokens
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS used_tokens (
+	id         TEXT PRIMARY KEY,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS used_tokens_expiry ON used_tokens (expires_at);
+`
+
+// UsedStore backed by a SQLite file. Times are stored as Unix seconds.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating used token table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+func (st *SQLiteStore) MarkUsed(ctx context.Context, id string, expiresAt, now time.Time) (bool, error) {
+	// Expired tokens are rejected before they get here, so their ids can go.
+	if _, err := st.db.ExecContext(ctx, `DELETE FROM used_tokens WHERE expires_at <= ?`, now.Unix()); err != nil {
+		return false, err
+	}
+	res, err := st.db.ExecContext(ctx, `INSERT INTO used_tokens (id, expires_at) VALUES (?, ?) ON CONFLICT (id) DO NOTHING`, id, expiresAt.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Unmark(ctx context.Context, id string) error {
+	_, err := st.db.ExecContext(ctx, `DELETE FROM used_tokens WHERE id = ?`, id)
+	return err
+}
+
+var _ UsedStore = (*SQLiteStore)(nil)
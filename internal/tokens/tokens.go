@@ -0,0 +1,140 @@
+package tokens
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What a token can be used for; a token is only accepted for its purpose.
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+)
+
+var (
+	ErrInvalid = errors.New("invalid token")
+	ErrExpired = errors.New("token expired")
+	ErrUsed    = errors.New("token already used")
+)
+
+// What a token vouches for.
+type Claims struct {
+	ID      string `json:"jti"`
+	Purpose string `json:"p"`
+	UserID  string `json:"u"`
+	// Address the token was sent to.
+	Email string `json:"e"`
+	Exp   int64  `json:"exp"`
+}
+
+func (c *Claims) ExpiresAt() time.Time {
+	return time.Unix(c.Exp, 0)
+}
+
+// Remembers the tokens already redeemed until they expire.
+type UsedStore interface {
+	// Records token id as used. False when it already was.
+	MarkUsed(ctx context.Context, id string, expiresAt, now time.Time) (bool, error)
+	// Forgets that token id was used.
+	Unmark(ctx context.Context, id string) error
+	Close() error
+}
+
+// Issues and redeems signed, expiring, single-use tokens sent to users by
+// email. The payload is JSON, base64url-encoded and HMAC-signed; it is
+// readable by whoever holds the token, so it carries no secrets.
+type Issuer struct {
+	Used UsedStore
+	key  []byte
+}
+
+// Returns an issuer for secret; an empty secret gets a random per-process
+// key, which invalidates outstanding tokens on restart.
+func NewIssuer(secret string, used UsedStore) *Issuer {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("tokens: generating key: %v", err))
+		}
+		log.Println("ACCOUNT_TOKEN_SECRET not set, using a random key: emailed links will not survive restarts.")
+	}
+	return &Issuer{Used: used, key: key}
+}
+
+// A token for purpose, for userID at email, valid for ttl.
+func (i *Issuer) Issue(purpose, userID, email string, ttl time.Duration, now time.Time) (string, *Claims, error) {
+	c := &Claims{
+		ID:      uuid.NewString(),
+		Purpose: purpose,
+		UserID:  userID,
+		Email:   email,
+		Exp:     now.Add(ttl).Unix(),
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", nil, err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(i.sign(payload)), c, nil
+}
+
+// Verifies token's signature, purpose and expiry, without using it up.
+func (i *Issuer) Check(token, purpose string, now time.Time) (*Claims, error) {
+	enc := base64.RawURLEncoding
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalid
+	}
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, i.sign(payload)) {
+		return nil, ErrInvalid
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.ID == "" || c.Purpose != purpose {
+		return nil, ErrInvalid
+	}
+	if !now.Before(c.ExpiresAt()) {
+		return nil, ErrExpired
+	}
+	return &c, nil
+}
+
+// Uses up a checked token; ErrUsed when it was redeemed before.
+func (i *Issuer) Redeem(ctx context.Context, c *Claims, now time.Time) error {
+	fresh, err := i.Used.MarkUsed(ctx, c.ID, c.ExpiresAt(), now)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrUsed
+	}
+	return nil
+}
+
+// Makes a redeemed token usable again, when what it was redeemed for failed.
+func (i *Issuer) Release(ctx context.Context, c *Claims) error {
+	return i.Used.Unmark(ctx, c.ID)
+}
+
+func (i *Issuer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte("tokens:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,135 @@
+package tokens
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+
+func newIssuer(t *testing.T, secret string) *Issuer {
+	t.Helper()
+	used, err := OpenSQLite(filepath.Join(t.TempDir(), "tokens.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { used.Close() })
+	return NewIssuer(secret, used)
+}
+
+func TestCheck(t *testing.T) {
+	issuer := newIssuer(t, "secret")
+	token, issued, err := issuer.Issue(PurposePasswordReset, "u1", "u1@example.com", time.Hour, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same payload, signed for another purpose with the right key.
+	payload, _, _ := strings.Cut(token, ".")
+	raw, _ := base64.RawURLEncoding.DecodeString(payload)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(raw), PurposePasswordReset, PurposeEmailVerification, 1)))
+	_, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		issuer  *Issuer
+		token   string
+		purpose string
+		at      time.Time
+		want    error
+	}{
+		{"valid", issuer, token, PurposePasswordReset, t0.Add(59 * time.Minute), nil},
+		{"expired", issuer, token, PurposePasswordReset, t0.Add(time.Hour), ErrExpired},
+		{"other purpose", issuer, token, PurposeEmailVerification, t0, ErrInvalid},
+		{"other key", newIssuer(t, "other secret"), token, PurposePasswordReset, t0, ErrInvalid},
+		{"payload changed", issuer, forged + "." + sig, PurposeEmailVerification, t0, ErrInvalid},
+		{"signature missing", issuer, payload, PurposePasswordReset, t0, ErrInvalid},
+		{"not base64", issuer, "!." + sig, PurposePasswordReset, t0, ErrInvalid},
+		{"empty", issuer, "", PurposePasswordReset, t0, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.issuer.Check(tt.token, tt.purpose, tt.at)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (c.ID != issued.ID || c.UserID != "u1" || c.Email != "u1@example.com") {
+				t.Errorf("claims = %+v, want %+v", c, issued)
+			}
+		})
+	}
+}
+
+func TestRedeemOnce(t *testing.T) {
+	ctx := context.Background()
+	issuer := newIssuer(t, "secret")
+	token, _, err := issuer.Issue(PurposePasswordReset, "u1", "u1@example.com", time.Hour, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := issuer.Check(token, PurposePasswordReset, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := issuer.Redeem(ctx, c, t0); err != nil {
+		t.Fatalf("first Redeem: %v", err)
+	}
+	if err := issuer.Redeem(ctx, c, t0.Add(time.Minute)); !errors.Is(err, ErrUsed) {
+		t.Errorf("second Redeem: err = %v, want ErrUsed", err)
+	}
+	// A released token works again, once.
+	if err := issuer.Release(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.Redeem(ctx, c, t0.Add(2*time.Minute)); err != nil {
+		t.Errorf("Redeem after Release: %v", err)
+	}
+	if err := issuer.Redeem(ctx, c, t0.Add(3*time.Minute)); !errors.Is(err, ErrUsed) {
+		t.Errorf("Redeem after Release, twice: err = %v, want ErrUsed", err)
+	}
+
+	// Other tokens are unaffected.
+	other, _, err := issuer.Issue(PurposePasswordReset, "u1", "u1@example.com", time.Hour, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oc, err := issuer.Check(other, PurposePasswordReset, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.Redeem(ctx, oc, t0); err != nil {
+		t.Errorf("Redeem of another token: %v", err)
+	}
+}
+
+func TestUsedSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.db")
+	used, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := used.MarkUsed(ctx, "t1", t0.Add(time.Hour), t0); err != nil || !ok {
+		t.Fatalf("MarkUsed = %v, %v", ok, err)
+	}
+	used.Close()
+
+	used, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer used.Close()
+	if ok, err := used.MarkUsed(ctx, "t1", t0.Add(time.Hour), t0); err != nil || ok {
+		t.Errorf("MarkUsed after reopening = %v, %v; want false", ok, err)
+	}
+	// Once expired the id is forgotten; the token itself is refused by Check.
+	if ok, err := used.MarkUsed(ctx, "t1", t0.Add(3*time.Hour), t0.Add(2*time.Hour)); err != nil || !ok {
+		t.Errorf("MarkUsed after expiry = %v, %v; want true", ok, err)
+	}
+}
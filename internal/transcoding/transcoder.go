@@ -0,0 +1,170 @@
+package transcoding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/common"
+)
+
+// Field naming used when rendering responses.
+const (
+	NamingSnakeCase = "snake_case" // Field names as declared in the .proto files.
+	NamingCamelCase = "camelCase"  // lowerCamelCase JSON names.
+)
+
+// Maximum accepted request body, in bytes.
+const maxBodySize = 1 << 20
+
+// A gRPC backend the transcoder can route calls to.
+type Backend interface {
+	Conn() grpc.ClientConnInterface
+	ServiceName() string
+}
+
+// Maps HTTP requests onto unary gRPC methods using the registered protobuf descriptors.
+type Transcoder struct {
+	conns map[string]grpc.ClientConnInterface
+}
+
+func NewTranscoder(backends ...Backend) *Transcoder {
+	conns := make(map[string]grpc.ClientConnInterface, len(backends))
+	for _, b := range backends {
+		conns[b.ServiceName()] = b.Conn()
+	}
+	return &Transcoder{conns: conns}
+}
+
+// Returns an HTTP handler calling the gRPC method "package.Service/Method".
+//
+// The request message is built from the JSON body (if any), then path
+// variables, then query parameters, each overriding the previous one.
+func (t *Transcoder) Handler(fullMethod, naming string) (http.Handler, error) {
+	method, err := t.lookup(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	marshal := protojson.MarshalOptions{EmitUnpopulated: true}
+	switch naming {
+	case "", NamingSnakeCase:
+		marshal.UseProtoNames = true
+	case NamingCamelCase:
+	default:
+		return nil, fmt.Errorf("unknown field naming %q", naming)
+	}
+
+	conn := t.conns[string(method.Parent().FullName())]
+	invokeName := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	input, output := messageType(method.Input()), messageType(method.Output())
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := input.New().Interface()
+		if err := bindRequest(r, req); err != nil {
+			common.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// The route timeout middleware bounds the request context.
+		resp := output.New().Interface()
+		if err := conn.Invoke(r.Context(), invokeName, req, resp); err != nil {
+			common.RespondGrpcError(w, err)
+			return
+		}
+
+		body, err := marshal.Marshal(resp)
+		if err != nil {
+			common.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}), nil
+}
+
+// Returns the request and response descriptors of a transcodable method.
+func (t *Transcoder) Describe(fullMethod string) (protoreflect.MessageDescriptor, protoreflect.MessageDescriptor, error) {
+	method, err := t.lookup(fullMethod)
+	if err != nil {
+		return nil, nil, err
+	}
+	return method.Input(), method.Output(), nil
+}
+
+// Resolves and checks a "package.Service/Method" name.
+func (t *Transcoder) lookup(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, name, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok || service == "" || name == "" {
+		return nil, fmt.Errorf("gRPC method %q must look like package.Service/Method", fullMethod)
+	}
+	if _, ok := t.conns[service]; !ok {
+		return nil, fmt.Errorf("gRPC method %q: no backend serves %s", fullMethod, service)
+	}
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("gRPC method %q: service descriptor not found: %w", fullMethod, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("gRPC method %q: %s is not a service", fullMethod, service)
+	}
+	method := sd.Methods().ByName(protoreflect.Name(name))
+	if method == nil {
+		return nil, fmt.Errorf("gRPC method %q: %s has no method %s", fullMethod, service, name)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("gRPC method %q: only unary methods can be transcoded", fullMethod)
+	}
+	return method, nil
+}
+
+// Prefers the generated Go type, falling back to a dynamic message.
+func messageType(md protoreflect.MessageDescriptor) protoreflect.MessageType {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt
+	}
+	return dynamicpb.NewMessageType(md)
+}
+
+func bindRequest(r *http.Request, msg proto.Message) error {
+	if r.Body != nil && r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return fmt.Errorf("Invalid request payload")
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := protojson.Unmarshal(body, msg); err != nil {
+				return fmt.Errorf("Invalid request payload: %v", err)
+			}
+		}
+	}
+
+	m := msg.ProtoReflect()
+	for name, value := range mux.Vars(r) {
+		if err := setField(m, name, []string{value}); err != nil {
+			return err
+		}
+	}
+	// Query parameters the message doesn't declare are ignored, like in the
+	// hand-written handlers, so clients can add cache busters or tracking
+	// parameters.
+	for name, values := range r.URL.Query() {
+		if err := setField(m, name, values); err != nil && !errors.Is(err, errUnknownField) {
+			return err
+		}
+	}
+	return nil
+}
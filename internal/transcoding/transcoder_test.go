@@ -0,0 +1,121 @@
+package transcoding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/emptypb" // Registers google/protobuf/empty.proto.
+)
+
+// A service of its own, so the tests don't depend on the backend protos.
+const echoService = "transcodingtest.Echo"
+
+var registerEcho = sync.OnceValue(func() error {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("transcodingtest/echo.proto"),
+		Package:    proto.String("transcodingtest"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/empty.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Echo"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Call"),
+				InputType:  proto.String(".google.protobuf.Empty"),
+				OutputType: proto.String(".google.protobuf.Empty"),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		return err
+	}
+	return protoregistry.GlobalFiles.RegisterFile(fd)
+})
+
+// Fails every call with err.
+type failingConn struct {
+	grpc.ClientConnInterface
+	err error
+}
+
+func (c failingConn) Invoke(context.Context, string, any, any, ...grpc.CallOption) error {
+	return c.err
+}
+
+type echoBackend struct{ conn grpc.ClientConnInterface }
+
+func (b echoBackend) Conn() grpc.ClientConnInterface { return b.conn }
+func (b echoBackend) ServiceName() string            { return echoService }
+
+func TestHandlerMapsStatusCodes(t *testing.T) {
+	if err := registerEcho(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.Unimplemented, http.StatusNotImplemented},
+		{codes.Unavailable, http.StatusServiceUnavailable},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		// Everything else is reported as a backend error.
+		{codes.Canceled, http.StatusBadGateway},
+		{codes.Unknown, http.StatusBadGateway},
+		{codes.PermissionDenied, http.StatusBadGateway},
+		{codes.ResourceExhausted, http.StatusBadGateway},
+		{codes.FailedPrecondition, http.StatusBadGateway},
+		{codes.Aborted, http.StatusBadGateway},
+		{codes.OutOfRange, http.StatusBadGateway},
+		{codes.Internal, http.StatusBadGateway},
+		{codes.DataLoss, http.StatusBadGateway},
+		{codes.Unauthenticated, http.StatusBadGateway},
+		{codes.Code(99), http.StatusBadGateway},
+	}
+	covered := map[codes.Code]bool{}
+	for _, tt := range tests {
+		covered[tt.code] = true
+		t.Run(tt.code.String(), func(t *testing.T) {
+			tr := NewTranscoder(echoBackend{failingConn{err: status.Error(tt.code, "backend said no")}})
+			h, err := tr.Handler(echoService+"/Call", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/echo", nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+	// Every code but OK is an error a backend can return.
+	for c := codes.Canceled; c <= codes.Unauthenticated; c++ {
+		if !covered[c] {
+			t.Errorf("%v is not covered", c)
+		}
+	}
+
+	t.Run("not a status", func(t *testing.T) {
+		tr := NewTranscoder(echoBackend{failingConn{err: context.Canceled}})
+		h, err := tr.Handler(echoService+"/Call", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/echo", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package transformers
+
+import (
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+
+	tpb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+type AccountLock struct {
+	Reason   string `json:"reason"`
+	LockedBy string `json:"locked_by"`
+	LockedAt string `json:"locked_at"`
+}
+
+// A user as seen by support staff.
+type AdminUser struct {
+	UserId    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Birthdate string `json:"birthdate"`
+	// Set while the account is locked.
+	Lock *AccountLock `json:"lock"`
+}
+
+type AdminUserResp struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	User    AdminUser `json:"user"`
+}
+
+type AdminUserDetailResp struct {
+	Success       bool           `json:"success"`
+	Message       string         `json:"message"`
+	User          AdminUser      `json:"user"`
+	Verifications []Verification `json:"verifications"`
+	Pockets       []Pocket       `json:"pockets"`
+	// Latest movements, newest first.
+	RecentMovements []Movement `json:"recent_movements"`
+	Currency        string     `json:"currency"`
+}
+
+type AccountLockResp struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	UserId  string       `json:"user_id"`
+	Lock    *AccountLock `json:"lock"`
+}
+
+func AccountLockJSON(l *locks.Lock) *AccountLock {
+	if l == nil {
+		return nil
+	}
+	return &AccountLock{
+		Reason:   l.Reason,
+		LockedBy: l.LockedBy,
+		LockedAt: l.LockedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func AdminUserJSON(userID string, user *pb.GetUserByIdResponse, lock *locks.Lock) AdminUser {
+	return AdminUser{
+		UserId:    userID,
+		Username:  user.GetUsername(),
+		Email:     user.GetEmail(),
+		Phone:     user.GetPhone(),
+		FirstName: user.GetFirstName(),
+		LastName:  user.GetLastName(),
+		Birthdate: user.GetBirthdate(),
+		Lock:      AccountLockJSON(lock),
+	}
+}
+
+func AdminUserRespJSON(user AdminUser) AdminUserResp {
+	return AdminUserResp{Success: true, Message: "User found", User: user}
+}
+
+func AdminUserDetailRespJSON(user AdminUser, verifications *pb.GetVerificationsByUserIdResponse, pockets *pb.GetPocketsByUserIdResponse, movements []*tpb.Movement, f money.Formatter) AdminUserDetailResp {
+	recent := make([]Movement, 0, len(movements))
+	for _, m := range movements {
+		recent = append(recent, MovementJSON(m, f))
+	}
+	return AdminUserDetailResp{
+		Success:         true,
+		Message:         "User found",
+		User:            user,
+		Verifications:   GetVerificationsRespJSON(verifications).Verifications,
+		Pockets:         GetPocketsRespJSON(pockets).Pockets,
+		RecentMovements: recent,
+		Currency:        f.Currency.Code,
+	}
+}
+
+func AccountLockRespJSON(userID string, l *locks.Lock, message string) AccountLockResp {
+	return AccountLockResp{
+		Success: true,
+		Message: message,
+		UserId:  userID,
+		Lock:    AccountLockJSON(l),
+	}
+}
@@ -1,18 +1,193 @@
 package transformers
 
-import pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/auth_service"
+import (
+	"time"
 
-func LoginRespJSON(resp *pb.Response) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
-		"data":    resp.GetData(),
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/auth_service"
+)
+
+type LoginResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+	// Set instead of data when the login needs a second factor: send the code
+	// with partial_token to /login/2fa before expires_at.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	PartialToken      string `json:"partial_token,omitempty"`
+	ExpiresAt         string `json:"expires_at,omitempty"`
+}
+
+type LogOutResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+func LoginRespJSON(resp *pb.Response) LoginResp {
+	return LoginResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
+		Data:    resp.GetData(),
+	}
+}
+
+func LoginTwoFactorRespJSON(partialToken string, expiresAt time.Time) LoginResp {
+	return LoginResp{
+		Success:           true,
+		Message:           "Two-factor authentication required",
+		TwoFactorRequired: true,
+		PartialToken:      partialToken,
+		ExpiresAt:         expiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func LogOutRespJSON() LogOutResp {
+	return LogOutResp{
+		Success: true,
+		Message: "Logged out successfully",
+	}
+}
+
+// Body of the 401 asking the client to confirm an action.
+type StepUpChallengeResp struct {
+	Error       string `json:"error"`
+	Code        string `json:"code"`
+	ChallengeId string `json:"challenge_id"`
+	Action      string `json:"action"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+type StepUpResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Sent back in the X-Step-Up-Token header to perform the action.
+	Token     string `json:"token"`
+	Action    string `json:"action"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+func StepUpChallengeRespJSON(c *stepup.Challenge) StepUpChallengeResp {
+	return StepUpChallengeResp{
+		Error:       "Step-up authentication required: submit the code sent to you",
+		Code:        "step_up_required",
+		ChallengeId: c.ID,
+		Action:      c.Action,
+		ExpiresAt:   c.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func StepUpRespJSON(g *stepup.Grant) StepUpResp {
+	return StepUpResp{
+		Success:   true,
+		Message:   "Step-up authentication successful",
+		Token:     g.Token,
+		Action:    g.Action,
+		ExpiresAt: g.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type TwoFactorStatusResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Logins need a TOTP or recovery code.
+	Enabled bool `json:"enabled"`
+	// An authenticator was enrolled but not confirmed yet.
+	Pending           bool   `json:"pending"`
+	RecoveryCodesLeft int    `json:"recovery_codes_left"`
+	ConfirmedAt       string `json:"confirmed_at,omitempty"`
+}
+
+type TOTPEnrollResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Secret  string `json:"secret"`
+	// otpauth:// URI to render as a QR code.
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Shown only once.
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func TwoFactorStatusRespJSON(e *twofactor.Enrollment) TwoFactorStatusResp {
+	resp := TwoFactorStatusResp{Success: true, Message: "Two-factor status retrieved"}
+	if e == nil {
+		return resp
+	}
+	resp.Enabled, resp.Pending = e.Confirmed, !e.Confirmed
+	if e.Confirmed {
+		resp.RecoveryCodesLeft = e.RecoveryCodesLeft
+	}
+	if e.ConfirmedAt != nil {
+		resp.ConfirmedAt = e.ConfirmedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+func TOTPEnrollRespJSON(e *twofactor.Enrollment, uri string) TOTPEnrollResp {
+	return TOTPEnrollResp{
+		Success:         true,
+		Message:         "Scan the code with an authenticator app, then confirm with a code from it",
+		Secret:          e.Secret,
+		ProvisioningUri: uri,
 	}
 }
 
-func LogOutRespJSON() map[string]interface{} {
-	return map[string]interface{}{
-		"success": "true",
-		"message": "Logged out successfully",
+func RecoveryCodesRespJSON(codes []string, message string) RecoveryCodesResp {
+	return RecoveryCodesResp{Success: true, Message: message, RecoveryCodes: codes}
+}
+
+type AccountMessageResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type Session struct {
+	Id         string `json:"id"`
+	Device     string `json:"device"`
+	Ip         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	// The session making the request.
+	Current bool `json:"current"`
+}
+
+type SessionsResp struct {
+	Success  bool      `json:"success"`
+	Message  string    `json:"message"`
+	Sessions []Session `json:"sessions"`
+}
+
+type RevokeSessionsResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Number of sessions ended.
+	Revoked int `json:"revoked"`
+}
+
+func SessionsRespJSON(list []*sessions.Session, currentID string) SessionsResp {
+	resp := SessionsResp{Success: true, Message: "Sessions retrieved", Sessions: make([]Session, 0, len(list))}
+	for _, s := range list {
+		resp.Sessions = append(resp.Sessions, Session{
+			Id:         s.ID,
+			Device:     s.Device,
+			Ip:         s.IP,
+			CreatedAt:  s.CreatedAt.UTC().Format(time.RFC3339),
+			LastSeenAt: s.LastSeenAt.UTC().Format(time.RFC3339),
+			ExpiresAt:  s.ExpiresAt.UTC().Format(time.RFC3339),
+			Current:    s.ID == currentID,
+		})
 	}
+	return resp
+}
+
+func RevokeSessionsRespJSON(revoked int, message string) RevokeSessionsResp {
+	return RevokeSessionsResp{Success: true, Message: message, Revoked: revoked}
 }
@@ -0,0 +1,40 @@
+package transformers
+
+import (
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/exports"
+)
+
+type ExportJob struct {
+	Id        string `json:"id"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	// When the archive is removed; only set once the job has ended.
+	ExpiresAt string `json:"expires_at,omitempty"`
+	// Size of the archive in bytes, once ready.
+	Size  int64  `json:"size,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type ExportJobResp struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Export  ExportJob `json:"export"`
+}
+
+func ExportJobRespJSON(j *exports.Job, message string) ExportJobResp {
+	job := ExportJob{
+		Id:        j.ID,
+		Status:    j.Status,
+		CreatedAt: j.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: j.UpdatedAt.UTC().Format(time.RFC3339),
+		Size:      j.Size,
+		Error:     j.Error,
+	}
+	if !j.ExpiresAt.IsZero() {
+		job.ExpiresAt = j.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return ExportJobResp{Success: true, Message: message, Export: job}
+}
@@ -0,0 +1,50 @@
+package transformers
+
+import (
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/deletion"
+)
+
+type DeletionStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// When the step last changed; unset while pending.
+	At string `json:"at,omitempty"`
+}
+
+type Deletion struct {
+	Status      string `json:"status"`
+	RequestedAt string `json:"requested_at"`
+	// End of the grace period, after which the account is deleted.
+	DueAt     string         `json:"due_at"`
+	UpdatedAt string         `json:"updated_at"`
+	Steps     []DeletionStep `json:"steps"`
+	Error     string         `json:"error,omitempty"`
+}
+
+type DeletionResp struct {
+	Success  bool     `json:"success"`
+	Message  string   `json:"message"`
+	Deletion Deletion `json:"deletion"`
+}
+
+func DeletionRespJSON(r *deletion.Request, message string) DeletionResp {
+	d := Deletion{
+		Status:      r.Status,
+		RequestedAt: r.RequestedAt.UTC().Format(time.RFC3339),
+		DueAt:       r.DueAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   r.UpdatedAt.UTC().Format(time.RFC3339),
+		Steps:       make([]DeletionStep, 0, len(r.Steps)),
+		Error:       r.Error,
+	}
+	for _, s := range r.Steps {
+		step := DeletionStep{Name: s.Name, Status: s.Status, Error: s.Error}
+		if !s.At.IsZero() {
+			step.At = s.At.UTC().Format(time.RFC3339)
+		}
+		d.Steps = append(d.Steps, step)
+	}
+	return DeletionResp{Success: true, Message: message, Deletion: d}
+}
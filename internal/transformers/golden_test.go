@@ -0,0 +1,350 @@
+package transformers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/deletion"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/exports"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/insights"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/scheduler"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
+
+	apb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/auth_service"
+	tpb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
+)
+
+// Rewrites the golden files from the current output:
+//
+//	go test ./internal/transformers -update
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+// Fixed instant the fixtures are built around.
+var testTime = time.Date(2025, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+// Formatter for USD amounts shown in English and converted into EUR.
+func testFormatter(t *testing.T) money.Formatter {
+	t.Helper()
+	usd, err := money.LookupCurrency("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eur, err := money.LookupCurrency("EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return money.Formatter{
+		Currency:   usd,
+		Locale:     &money.Locale{Tag: "en", Group: ",", Decimal: ".", UseCodes: true},
+		Conversion: &money.Conversion{To: eur, Rate: money.MustParse("0.92")},
+	}
+}
+
+// A response and the golden file, testdata/<name>.golden, it must match.
+type goldenCase struct {
+	name string
+	resp any
+}
+
+func TestGolden(t *testing.T) {
+	var tests []goldenCase
+	for _, cases := range [][]goldenCase{
+		userProductCases(),
+		transactionCases(t),
+		authCases(),
+		insightsCases(t),
+		scheduledTransferCases(),
+		paymentRequestCases(),
+		adminCases(t),
+		deletionCases(),
+		dataExportCases(),
+	} {
+		tests = append(tests, cases...)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, tt.resp)
+		})
+	}
+}
+
+func userProductCases() []goldenCase {
+	return []goldenCase{
+		{"user_product_create_user", CreateUserRespJSON(&pb.CreateUserResponse{Success: true, Message: "User created", UserId: "u1"})},
+		{"user_product_get_user", GetUserRespJSON(&pb.GetUserByIdResponse{
+			Success: true, Message: "User found", Email: "alice@example.com", Username: "alice",
+			Phone: "+573001234567", FirstName: "Alice", LastName: "Doe", Birthdate: "1990-01-31",
+		})},
+		{"user_product_get_username", GetUsernameRespJSON(&pb.GetUserByUsernameResponse{Success: true, Message: "User found", Email: "bob@example.com", UserId: "u2"})},
+		{"user_product_update_user", UpdateUserRespJSON(&pb.UpdateUserByIdResponse{
+			Success: true, Message: "User updated", Email: "alice@example.org", Username: "alice",
+			Phone: "+573001234567", FirstName: "Alice", LastName: "Smith", Birthdate: "1990-01-31",
+		})},
+		{"user_product_get_favorites", GetFavoritesRespJSON(&pb.GetFavoritesByUserIdResponse{Success: true, Message: "Favorites found", Favorites: []*pb.Favorite{
+			{Id: "f1", UserId: "u1", FavoriteUserId: "u2", FavoriteUsername: "bob", Alias: "Bobby"},
+		}})},
+		{"user_product_get_favorites_empty", GetFavoritesRespJSON(&pb.GetFavoritesByUserIdResponse{Success: true, Message: "Favorites found"})},
+		{"user_product_create_favorite", CreateFavoriteRespJSON(&pb.CreateFavoriteResponse{Success: true, Message: "Favorite created", FavoriteId: "f1"})},
+		{"user_product_update_favorite", UpdateFavoriteRespJSON(&pb.UpdateFavoriteByIdResponse{Success: true, Message: "Favorite updated", NewAlias: "Rob"})},
+		{"user_product_delete_favorite", DeleteFavoriteRespJSON(&pb.DeleteFavoriteByIdResponse{Success: true, Message: "Favorite deleted"})},
+		{"user_product_get_pockets", GetPocketsRespJSON(&pb.GetPocketsByUserIdResponse{Success: true, Message: "Pockets found", Pockets: []*pb.Pocket{
+			{Id: "p1", UserId: "u1", Name: "Groceries", Category: "food", MaxAmount: 500},
+		}})},
+		{"user_product_create_pocket", CreatePocketRespJSON(&pb.CreatePocketResponse{Success: true, Message: "Pocket created", PocketId: "p1"})},
+		{"user_product_update_pocket", UpdatePocketRespJSON(&pb.UpdatePocketByIdResponse{Success: true, Message: "Pocket updated", Name: "Food", Category: "food", MaxAmount: 600})},
+		{"user_product_delete_pocket", DeletePocketRespJSON(&pb.DeletePocketByIdResponse{Success: true, Message: "Pocket deleted"})},
+		{"user_product_get_verifications", GetVerificationsRespJSON(&pb.GetVerificationsByUserIdResponse{Success: true, Message: "Verifications found", Verifications: []*pb.Verification{
+			{Id: "v1", UserId: "u1", Type: "email", Status: "COMPLETE"},
+		}})},
+		{"user_product_update_verification", UpdateVerificationRespJSON(&pb.UpdateVerificationByUserIdResponse{Success: true, Message: "Verification updated", Type: "email", Status: "COMPLETE"})},
+		{"user_product_country_codes", GetCountryCodesRespJSON(&pb.GetCountryCodesResponse{Success: true, Message: "Country codes found", Codes: []*pb.CountryCode{
+			{Id: "1", Name: "Colombia", Code: "+57"},
+		}})},
+	}
+}
+
+func transactionCases(t *testing.T) []goldenCase {
+	f := testFormatter(t)
+	plain := money.Formatter{Currency: f.Currency}
+	amount, err := money.ParseMoney("1234.5", f.Currency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := &tpb.TransferFundsResponse{Success: true, Message: "Transfer completed", TransferId: "t1", Timestamp: "2025-03-14T15:09:26Z"}
+	movements := &tpb.GetMovementsResponse{
+		Success: true,
+		Message: "Movements retrieved",
+		Movements: []*tpb.Movement{
+			{TransferId: "t1", FromUsername: "alice", ToUsername: "bob", Amount: "1234.5", Timestamp: "2025-03-14T15:09:26Z"},
+			{TransferId: "t2", FromUsername: "bob", ToUsername: "alice", Amount: "0.1", Timestamp: "2025-03-13T09:00:00Z"},
+		},
+	}
+	balance := &tpb.GetBalanceResponse{
+		Success:   true,
+		Message:   "Balance retrieved",
+		Timestamp: "2025-03-14T15:09:26Z",
+		Current:   "1000000",
+		Balances:  []*tpb.Balance{{Income: "1500.25", Outcome: "499.75"}},
+	}
+
+	return []goldenCase{
+		{"transaction_transfer", TransferFundsRespJSON(transfer, amount, nil)},
+		{"transaction_transfer_fx", TransferFundsRespJSON(transfer, amount, &fx.Rate{From: "USD", To: "EUR", Value: money.MustParse("0.92"), AsOf: testTime, Source: "ecb"})},
+		{"transaction_create_account", CreateAccountRespJSON(&tpb.CreateAccountResponse{Success: true, Message: "Account created", UserId: "u1", Timestamp: "2025-03-14T15:09:26Z"})},
+		{"transaction_balance", GetBalanceRespJSON(balance, plain)},
+		{"transaction_balance_display", GetBalanceRespJSON(balance, f)},
+		// Movement fields used to be camelCase (transferId, fromUsername, ...);
+		// they are snake_case since the typed responses.
+		{"transaction_movements", GetMovementsRespJSON(movements, plain)},
+		{"transaction_movements_display", GetMovementsRespJSON(movements, f)},
+		{"transaction_movements_empty", GetMovementsRespJSON(&tpb.GetMovementsResponse{Success: true, Message: "Movements retrieved"}, plain)},
+	}
+}
+
+func authCases() []goldenCase {
+	confirmedAt := testTime.Add(-time.Hour)
+	list := []*sessions.Session{
+		{ID: "s1", Device: "Chrome on Windows", IP: "203.0.113.7", CreatedAt: testTime.Add(-2 * time.Hour), LastSeenAt: testTime, ExpiresAt: testTime.Add(22 * time.Hour)},
+		{ID: "s2", Device: "Safari on iOS", IP: "198.51.100.4", CreatedAt: testTime.Add(-48 * time.Hour), LastSeenAt: testTime.Add(-time.Hour), ExpiresAt: testTime.Add(time.Hour)},
+	}
+
+	return []goldenCase{
+		{"auth_login", LoginRespJSON(&apb.Response{Success: true, Message: "Login successful", Data: "access-token"})},
+		{"auth_login_two_factor", LoginTwoFactorRespJSON("partial-token", testTime.Add(5*time.Minute))},
+		// success used to be the string "true"; it is a boolean since the typed responses.
+		{"auth_logout", LogOutRespJSON()},
+		{"auth_step_up_challenge", StepUpChallengeRespJSON(&stepup.Challenge{ID: "c1", Action: stepup.ActionTransfer, ExpiresAt: testTime.Add(5 * time.Minute)})},
+		{"auth_step_up", StepUpRespJSON(&stepup.Grant{Token: "grant-token", Action: stepup.ActionTransfer, ExpiresAt: testTime.Add(5 * time.Minute)})},
+		{"auth_two_factor_status_none", TwoFactorStatusRespJSON(nil)},
+		{"auth_two_factor_status_pending", TwoFactorStatusRespJSON(&twofactor.Enrollment{UserID: "u1", Secret: "SECRET", RecoveryCodesLeft: 10})},
+		{"auth_two_factor_status_enabled", TwoFactorStatusRespJSON(&twofactor.Enrollment{UserID: "u1", Confirmed: true, ConfirmedAt: &confirmedAt, RecoveryCodesLeft: 8})},
+		{"auth_totp_enroll", TOTPEnrollRespJSON(&twofactor.Enrollment{Secret: "JBSWY3DPEHPK3PXP"}, "otpauth://totp/Nova:alice?secret=JBSWY3DPEHPK3PXP&issuer=Nova")},
+		{"auth_recovery_codes", RecoveryCodesRespJSON([]string{"aaaa-bbbb", "cccc-dddd"}, "Two-factor authentication enabled")},
+		{"auth_sessions", SessionsRespJSON(list, "s1")},
+		{"auth_sessions_empty", SessionsRespJSON(nil, "")},
+		{"auth_revoke_sessions", RevokeSessionsRespJSON(2, "Other sessions revoked")},
+	}
+}
+
+func insightsCases(t *testing.T) []goldenCase {
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.March, 31, 23, 59, 59, 0, time.UTC)
+	report := insights.NewReport(from, to)
+	report.Add(time.Date(2025, time.January, 5, 0, 0, 0, 0, time.UTC), "employer", money.MustParse("3000"))
+	report.Add(time.Date(2025, time.January, 9, 0, 0, 0, 0, time.UTC), "bob", money.MustParse("-120.5"))
+	report.Add(time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC), "bob", money.MustParse("-80"))
+	report.Add(time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), "carol", money.MustParse("15.25"))
+	categories := insights.Categories([]*pb.Pocket{
+		{Name: "Groceries", Category: "food", MaxAmount: 400},
+		{Name: "Eating out", Category: "food", MaxAmount: 150},
+		{Name: "Misc"},
+	})
+	f := testFormatter(t)
+	plain := money.Formatter{Currency: f.Currency}
+
+	return []goldenCase{
+		{"insights_plain", InsightsRespJSON(report, categories, from, to, plain, "2814.75", 5)},
+		{"insights_display", InsightsRespJSON(report, categories, from, to, f, "2814.75", 2)},
+		{"insights_empty", InsightsRespJSON(insights.NewReport(from, from), nil, from, from, plain, "0", 5)},
+	}
+}
+
+func scheduledTransferCases() []goldenCase {
+	end := testTime.AddDate(1, 0, 0)
+	monthly := &scheduler.Schedule{
+		ID: "sc1", UserID: "u1", ToUserID: "u2", Amount: "250", Currency: "USD", Description: "Rent",
+		Frequency: scheduler.FrequencyMonthly, TimeZone: "America/Bogota",
+		StartAt: testTime, EndAt: &end, NextRunAt: testTime.AddDate(0, 1, 0), Status: scheduler.StatusActive, CreatedAt: testTime,
+	}
+	once := &scheduler.Schedule{
+		ID: "sc2", UserID: "u1", ToUserID: "u3", Amount: "5", Currency: "USD",
+		Frequency: scheduler.FrequencyOnce, TimeZone: "UTC",
+		StartAt: testTime, NextRunAt: testTime, Status: scheduler.StatusCompleted, CreatedAt: testTime,
+	}
+	runs := []*scheduler.Run{
+		{ID: scheduler.ExecutionID("sc1", testTime), ScheduleID: "sc1", DueAt: testTime, Status: scheduler.RunSucceeded, Attempts: 1, TransferID: "t1", UpdatedAt: testTime},
+		{
+			ID: scheduler.ExecutionID("sc1", testTime.AddDate(0, 1, 0)), ScheduleID: "sc1", DueAt: testTime.AddDate(0, 1, 0),
+			Status: scheduler.RunPending, Attempts: 2, NextAttemptAt: testTime.AddDate(0, 1, 0).Add(10 * time.Minute),
+			Error: "unavailable", UpdatedAt: testTime.AddDate(0, 1, 0),
+		},
+		{
+			ID: scheduler.ExecutionID("sc1", testTime.AddDate(0, 2, 0)), ScheduleID: "sc1", DueAt: testTime.AddDate(0, 2, 0),
+			Status: scheduler.RunUnknown, Attempts: 1, Error: "context deadline exceeded", UpdatedAt: testTime.AddDate(0, 2, 0),
+		},
+	}
+
+	return []goldenCase{
+		{"scheduled_transfer_schedule", ScheduledTransferRespJSON(monthly, "Scheduled transfer created")},
+		{"scheduled_transfer_schedules", GetScheduledTransfersRespJSON([]*scheduler.Schedule{monthly, once})},
+		{"scheduled_transfer_schedules_empty", GetScheduledTransfersRespJSON(nil)},
+		{"scheduled_transfer_runs", GetScheduledRunsRespJSON(monthly, runs)},
+	}
+}
+
+func paymentRequestCases() []goldenCase {
+	request := func(id, payer, amount, status string) *payments.Request {
+		return &payments.Request{
+			ID: id, RequesterID: "u1", RequesterUsername: "alice", PayerID: payer + "-id", PayerUsername: payer,
+			Amount: amount, Currency: "USD", Note: "Dinner", Status: status,
+			CreatedAt: testTime, ExpiresAt: testTime.Add(7 * 24 * time.Hour), UpdatedAt: testTime, SplitID: "sp1",
+		}
+	}
+	paid := request("r1", "bob", "33.34", payments.StatusPaid)
+	paid.TransferID = "t1"
+	failed := request("r2", "carol", "33.33", payments.StatusPending)
+	failed.Error = "insufficient funds"
+	shares := []*payments.Request{paid, failed}
+	single := request("r3", "bob", "10", payments.StatusPending)
+	single.Note, single.SplitID = "", ""
+	split := &payments.Split{
+		ID: "sp1", RequesterID: "u1", RequesterUsername: "alice", Total: "100", OwnShare: "33.33",
+		Currency: "USD", Description: "Dinner", Mode: payments.SplitEven, CreatedAt: testTime,
+	}
+
+	return []goldenCase{
+		{"payment_request_request", PaymentRequestRespJSON(single, "Payment request created")},
+		{"payment_request_request_paid", PaymentRequestRespJSON(paid, "Payment request paid")},
+		{"payment_request_requests", GetPaymentRequestsRespJSON(shares)},
+		{"payment_request_requests_empty", GetPaymentRequestsRespJSON(nil)},
+		{"payment_request_split", SplitRespJSON(split, shares, "Split created")},
+		{"payment_request_splits", GetSplitsRespJSON([]*payments.Split{split}, [][]*payments.Request{shares})},
+	}
+}
+
+func adminCases(t *testing.T) []goldenCase {
+	user := &pb.GetUserByIdResponse{
+		Success: true, Message: "User found", Email: "alice@example.com", Username: "alice",
+		Phone: "+573001234567", FirstName: "Alice", LastName: "Doe", Birthdate: "1990-01-31",
+	}
+	lock := &locks.Lock{UserID: "u1", Reason: "suspected fraud", LockedBy: "admin1", LockedAt: testTime}
+	verifications := &pb.GetVerificationsByUserIdResponse{Verifications: []*pb.Verification{{Id: "v1", UserId: "u1", Type: "email", Status: "COMPLETE"}}}
+	pockets := &pb.GetPocketsByUserIdResponse{Pockets: []*pb.Pocket{{Id: "p1", UserId: "u1", Name: "Rent", Category: "housing", MaxAmount: 900}}}
+	movements := []*tpb.Movement{{TransferId: "t1", FromUsername: "alice", ToUsername: "bob", Amount: "42", Timestamp: "2025-03-14T15:09:26Z"}}
+
+	return []goldenCase{
+		{"admin_user", AdminUserRespJSON(AdminUserJSON("u1", user, nil))},
+		{"admin_user_locked", AdminUserRespJSON(AdminUserJSON("u1", user, lock))},
+		{"admin_user_detail", AdminUserDetailRespJSON(AdminUserJSON("u1", user, lock), verifications, pockets, movements, testFormatter(t))},
+		{"admin_user_detail_empty", AdminUserDetailRespJSON(AdminUserJSON("u1", user, nil), nil, nil, nil, testFormatter(t))},
+		{"admin_lock", AccountLockRespJSON("u1", lock, "Account locked")},
+		{"admin_unlock", AccountLockRespJSON("u1", nil, "Account unlocked")},
+	}
+}
+
+func deletionCases() []goldenCase {
+	due := testTime.Add(14 * 24 * time.Hour)
+	scheduled := &deletion.Request{
+		UserID: "u1", Status: deletion.StatusScheduled, RequestedAt: testTime, DueAt: due, UpdatedAt: testTime,
+		Steps: []deletion.StepState{{Name: "lock", Status: deletion.StepPending}, {Name: "profile", Status: deletion.StepPending}},
+	}
+	failed := &deletion.Request{
+		UserID: "u1", Status: deletion.StatusFailed, RequestedAt: testTime, DueAt: due, UpdatedAt: due.Add(time.Minute),
+		Steps: []deletion.StepState{
+			{Name: "lock", Status: deletion.StepUndone, At: due.Add(time.Minute)},
+			{Name: "profile", Status: deletion.StepFailed, Error: "unavailable", At: due},
+		},
+		Error: "profile: unavailable",
+	}
+
+	return []goldenCase{
+		{"deletion_scheduled", DeletionRespJSON(scheduled, "Account deletion status")},
+		{"deletion_failed", DeletionRespJSON(failed, "Account deletion status")},
+	}
+}
+
+func dataExportCases() []goldenCase {
+	pending := &exports.Job{ID: "e1", UserID: "u1", Status: exports.StatusPending, CreatedAt: testTime, UpdatedAt: testTime}
+	ready := &exports.Job{
+		ID: "e1", UserID: "u1", Status: exports.StatusReady, CreatedAt: testTime, UpdatedAt: testTime.Add(time.Minute),
+		ExpiresAt: testTime.Add(24 * time.Hour), File: "/app/data/exports/e1.zip", Size: 20480,
+	}
+	failed := &exports.Job{
+		ID: "e1", UserID: "u1", Status: exports.StatusFailed, CreatedAt: testTime, UpdatedAt: testTime.Add(time.Minute),
+		ExpiresAt: testTime.Add(24 * time.Hour), Error: "fetching movements: unavailable",
+	}
+
+	return []goldenCase{
+		{"data_export_pending", ExportJobRespJSON(pending, "Data export retrieved")},
+		{"data_export_ready", ExportJobRespJSON(ready, "Data export retrieved")},
+		{"data_export_failed", ExportJobRespJSON(failed, "Data export retrieved")},
+	}
+}
+
+// Compares v, rendered as the response body would be, with testdata/name.golden.
+// The golden files pin the wire format: a failing test means clients see a change.
+func checkGolden(t *testing.T, name string, v any) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshaling %s: %v", name, err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match %s:\n--- got\n%s--- want\n%s", name, path, got, want)
+	}
+}
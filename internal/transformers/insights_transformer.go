@@ -0,0 +1,105 @@
+package transformers
+
+import (
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/insights"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+)
+
+type MonthlyInsight struct {
+	Month   string `json:"month"`
+	Income  string `json:"income"`
+	Outcome string `json:"outcome"`
+	Net     string `json:"net"`
+	// Change against the previous month; absent for the first month.
+	IncomeDelta  string `json:"income_delta,omitempty"`
+	OutcomeDelta string `json:"outcome_delta,omitempty"`
+}
+
+type CounterpartyInsight struct {
+	Username string `json:"username"`
+	Income   string `json:"income"`
+	Outcome  string `json:"outcome"`
+	Count    int    `json:"count"`
+}
+
+type CategoryInsight struct {
+	Category string `json:"category"`
+	Pockets  int    `json:"pockets"`
+	Budget   string `json:"budget"`
+}
+
+type InsightsResp struct {
+	Success        bool   `json:"success"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Currency       string `json:"currency"`
+	CurrentBalance string `json:"current_balance"`
+	Income         string `json:"income"`
+	Outcome        string `json:"outcome"`
+	// Locale-formatted amounts, present when the client asks for a locale.
+	CurrentBalanceDisplay string                `json:"current_balance_display,omitempty"`
+	IncomeDisplay         string                `json:"income_display,omitempty"`
+	OutcomeDisplay        string                `json:"outcome_display,omitempty"`
+	Months                []MonthlyInsight      `json:"months"`
+	TopCounterparties     []CounterpartyInsight `json:"top_counterparties"`
+	Categories            []CategoryInsight     `json:"categories"`
+	// Spending not attributed to any pocket category. Movements carry no pocket
+	// reference, so for now this is all of the period's outcome.
+	UncategorizedOutcome string `json:"uncategorized_outcome"`
+}
+
+func InsightsRespJSON(report *insights.Report, categories []*insights.Category, from, to time.Time, f money.Formatter, current string, top int) InsightsResp {
+	decimalString := func(d money.Decimal) string {
+		s, _ := f.Decimal(d)
+		return s
+	}
+
+	months := make([]MonthlyInsight, 0, len(report.Months))
+	for i, m := range report.Months {
+		month := MonthlyInsight{
+			Month:   m.Start.Format("2006-01"),
+			Income:  decimalString(m.Income),
+			Outcome: decimalString(m.Outcome),
+			Net:     decimalString(m.Income.Sub(m.Outcome)),
+		}
+		if i > 0 {
+			prev := report.Months[i-1]
+			month.IncomeDelta = decimalString(m.Income.Sub(prev.Income))
+			month.OutcomeDelta = decimalString(m.Outcome.Sub(prev.Outcome))
+		}
+		months = append(months, month)
+	}
+
+	counterparties := make([]CounterpartyInsight, 0, top)
+	for _, c := range report.TopCounterparties(top) {
+		counterparties = append(counterparties, CounterpartyInsight{
+			Username: c.Username,
+			Income:   decimalString(c.Income),
+			Outcome:  decimalString(c.Outcome),
+			Count:    c.Count,
+		})
+	}
+
+	cats := make([]CategoryInsight, 0, len(categories))
+	for _, c := range categories {
+		cats = append(cats, CategoryInsight{Category: c.Name, Pockets: c.Pockets, Budget: decimalString(c.Budget)})
+	}
+
+	resp := InsightsResp{
+		Success:               true,
+		From:                  from.Format(time.RFC3339),
+		To:                    to.Format(time.RFC3339),
+		Currency:              f.Currency.Code,
+		CurrentBalance:        f.Amount(current),
+		CurrentBalanceDisplay: f.Display(current),
+		Months:                months,
+		TopCounterparties:     counterparties,
+		Categories:            cats,
+		UncategorizedOutcome:  decimalString(report.Outcome),
+	}
+	resp.Income, resp.IncomeDisplay = f.Decimal(report.Income)
+	resp.Outcome, resp.OutcomeDisplay = f.Decimal(report.Outcome)
+	return resp
+}
@@ -0,0 +1,160 @@
+package transformers
+
+import (
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+)
+
+type PaymentRequest struct {
+	Id string `json:"id"`
+	// Username of the user asking for the money.
+	Requester string `json:"requester"`
+	// Username of the user asked to pay.
+	Payer      string `json:"payer"`
+	Amount     string `json:"amount"`
+	Currency   string `json:"currency"`
+	Note       string `json:"note,omitempty"`
+	Status     string `json:"status"`
+	TransferId string `json:"transfer_id,omitempty"`
+	// Why the last accept failed, if it did.
+	Error     string `json:"error,omitempty"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+	UpdatedAt string `json:"updated_at"`
+	// Split the request is a share of, if any.
+	SplitId string `json:"split_id,omitempty"`
+}
+
+type PaymentRequestResp struct {
+	Success        bool           `json:"success"`
+	Message        string         `json:"message"`
+	PaymentRequest PaymentRequest `json:"payment_request"`
+}
+
+type GetPaymentRequestsResp struct {
+	Success         bool             `json:"success"`
+	Message         string           `json:"message"`
+	PaymentRequests []PaymentRequest `json:"payment_requests"`
+}
+
+func PaymentRequestJSON(r *payments.Request) PaymentRequest {
+	return PaymentRequest{
+		Id:         r.ID,
+		Requester:  r.RequesterUsername,
+		Payer:      r.PayerUsername,
+		Amount:     r.Amount,
+		Currency:   r.Currency,
+		Note:       r.Note,
+		Status:     r.Status,
+		TransferId: r.TransferID,
+		Error:      r.Error,
+		CreatedAt:  r.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:  r.ExpiresAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  r.UpdatedAt.UTC().Format(time.RFC3339),
+		SplitId:    r.SplitID,
+	}
+}
+
+func PaymentRequestRespJSON(r *payments.Request, message string) PaymentRequestResp {
+	return PaymentRequestResp{
+		Success:        true,
+		Message:        message,
+		PaymentRequest: PaymentRequestJSON(r),
+	}
+}
+
+func GetPaymentRequestsRespJSON(requests []*payments.Request) GetPaymentRequestsResp {
+	items := make([]PaymentRequest, 0, len(requests))
+	for _, r := range requests {
+		items = append(items, PaymentRequestJSON(r))
+	}
+	return GetPaymentRequestsResp{
+		Success:         true,
+		Message:         "Payment requests retrieved",
+		PaymentRequests: items,
+	}
+}
+
+type SplitParticipant struct {
+	PaymentRequestId string `json:"payment_request_id"`
+	Username         string `json:"username"`
+	Amount           string `json:"amount"`
+	Status           string `json:"status"`
+	TransferId       string `json:"transfer_id,omitempty"`
+}
+
+type Split struct {
+	Id          string `json:"id"`
+	Total       string `json:"total"`
+	Currency    string `json:"currency"`
+	Description string `json:"description,omitempty"`
+	Mode        string `json:"mode"`
+	// open, settled or closed.
+	Status string `json:"status"`
+	// Share the requester pays themselves.
+	OwnShare     string             `json:"own_share"`
+	Collected    string             `json:"collected"`
+	Outstanding  string             `json:"outstanding"`
+	CreatedAt    string             `json:"created_at"`
+	Participants []SplitParticipant `json:"participants"`
+}
+
+type SplitResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Split   Split  `json:"split"`
+}
+
+type GetSplitsResp struct {
+	Success bool    `json:"success"`
+	Message string  `json:"message"`
+	Splits  []Split `json:"splits"`
+}
+
+func SplitJSON(s *payments.Split, requests []*payments.Request) Split {
+	collected, outstanding := payments.SplitTotals(requests)
+	participants := make([]SplitParticipant, 0, len(requests))
+	for _, r := range requests {
+		participants = append(participants, SplitParticipant{
+			PaymentRequestId: r.ID,
+			Username:         r.PayerUsername,
+			Amount:           r.Amount,
+			Status:           r.Status,
+			TransferId:       r.TransferID,
+		})
+	}
+	return Split{
+		Id:           s.ID,
+		Total:        s.Total,
+		Currency:     s.Currency,
+		Description:  s.Description,
+		Mode:         s.Mode,
+		Status:       payments.SplitStatus(requests),
+		OwnShare:     s.OwnShare,
+		Collected:    collected.String(),
+		Outstanding:  outstanding.String(),
+		CreatedAt:    s.CreatedAt.UTC().Format(time.RFC3339),
+		Participants: participants,
+	}
+}
+
+func SplitRespJSON(s *payments.Split, requests []*payments.Request, message string) SplitResp {
+	return SplitResp{
+		Success: true,
+		Message: message,
+		Split:   SplitJSON(s, requests),
+	}
+}
+
+func GetSplitsRespJSON(splits []*payments.Split, requests [][]*payments.Request) GetSplitsResp {
+	items := make([]Split, 0, len(splits))
+	for i, s := range splits {
+		items = append(items, SplitJSON(s, requests[i]))
+	}
+	return GetSplitsResp{
+		Success: true,
+		Message: "Splits retrieved",
+		Splits:  items,
+	}
+}
@@ -0,0 +1,146 @@
+package transformers
+
+import (
+	"time"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/scheduler"
+)
+
+type ScheduledTransfer struct {
+	Id          string `json:"id"`
+	ToUser      string `json:"to_user"`
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description,omitempty"`
+	Frequency   string `json:"frequency"`
+	TimeZone    string `json:"time_zone"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at,omitempty"`
+	// Only set while the schedule is active.
+	NextRunAt string `json:"next_run_at,omitempty"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ScheduledTransferResp struct {
+	Success           bool              `json:"success"`
+	Message           string            `json:"message"`
+	ScheduledTransfer ScheduledTransfer `json:"scheduled_transfer"`
+}
+
+type GetScheduledTransfersResp struct {
+	Success            bool                `json:"success"`
+	Message            string              `json:"message"`
+	ScheduledTransfers []ScheduledTransfer `json:"scheduled_transfers"`
+}
+
+type ScheduledRun struct {
+	// Execution id: the schedule id and the occurrence's Unix time.
+	Id       string `json:"id"`
+	DueAt    string `json:"due_at"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// Only set while a retry is pending.
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	TransferId    string `json:"transfer_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+	// What the user has to do about the run; only set on unknown runs.
+	ActionRequired string `json:"action_required,omitempty"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+type GetScheduledRunsResp struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	ScheduleId string `json:"schedule_id"`
+	// Runs that may or may not have moved money and need checking.
+	UnknownRuns int            `json:"unknown_runs"`
+	Runs        []ScheduledRun `json:"runs"`
+}
+
+// Shown on unknown runs, which the scheduler never retries.
+const unknownRunAction = "The transfer may or may not have been made and won't be retried. Check your movements for it around due_at and make it by hand only if it isn't there."
+
+// Times are shown in the schedule's time zone.
+func ScheduledTransferJSON(s *scheduler.Schedule) ScheduledTransfer {
+	loc := scheduleLocation(s)
+	st := ScheduledTransfer{
+		Id:          s.ID,
+		ToUser:      s.ToUserID,
+		Amount:      s.Amount,
+		Currency:    s.Currency,
+		Description: s.Description,
+		Frequency:   s.Frequency,
+		TimeZone:    s.TimeZone,
+		StartAt:     s.StartAt.In(loc).Format(time.RFC3339),
+		Status:      s.Status,
+		CreatedAt:   s.CreatedAt.In(loc).Format(time.RFC3339),
+	}
+	if s.EndAt != nil {
+		st.EndAt = s.EndAt.In(loc).Format(time.RFC3339)
+	}
+	if s.Status == scheduler.StatusActive {
+		st.NextRunAt = s.NextRunAt.In(loc).Format(time.RFC3339)
+	}
+	return st
+}
+
+func ScheduledTransferRespJSON(s *scheduler.Schedule, message string) ScheduledTransferResp {
+	return ScheduledTransferResp{
+		Success:           true,
+		Message:           message,
+		ScheduledTransfer: ScheduledTransferJSON(s),
+	}
+}
+
+func GetScheduledTransfersRespJSON(schedules []*scheduler.Schedule) GetScheduledTransfersResp {
+	items := make([]ScheduledTransfer, 0, len(schedules))
+	for _, s := range schedules {
+		items = append(items, ScheduledTransferJSON(s))
+	}
+	return GetScheduledTransfersResp{
+		Success:            true,
+		Message:            "Scheduled transfers retrieved",
+		ScheduledTransfers: items,
+	}
+}
+
+func GetScheduledRunsRespJSON(s *scheduler.Schedule, runs []*scheduler.Run) GetScheduledRunsResp {
+	loc := scheduleLocation(s)
+	items := make([]ScheduledRun, 0, len(runs))
+	unknown := 0
+	for _, run := range runs {
+		item := ScheduledRun{
+			Id:         run.ID,
+			DueAt:      run.DueAt.In(loc).Format(time.RFC3339),
+			Status:     run.Status,
+			Attempts:   run.Attempts,
+			TransferId: run.TransferID,
+			Error:      run.Error,
+			UpdatedAt:  run.UpdatedAt.In(loc).Format(time.RFC3339),
+		}
+		switch run.Status {
+		case scheduler.RunPending:
+			item.NextAttemptAt = run.NextAttemptAt.In(loc).Format(time.RFC3339)
+		case scheduler.RunUnknown:
+			item.ActionRequired = unknownRunAction
+			unknown++
+		}
+		items = append(items, item)
+	}
+	return GetScheduledRunsResp{
+		Success:     true,
+		Message:     "Scheduled transfer runs retrieved",
+		ScheduleId:  s.ID,
+		UnknownRuns: unknown,
+		Runs:        items,
+	}
+}
+
+func scheduleLocation(s *scheduler.Schedule) *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
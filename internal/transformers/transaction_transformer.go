@@ -1,58 +1,164 @@
 package transformers
 
-import pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+import (
+	"time"
 
-func TransferFundsRespJSON(resp *pb.TransferFundsResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":     resp.GetSuccess(),
-		"message":     resp.GetMessage(),
-		"transfer_id": resp.GetTransferId(),
-		"timestamp":   resp.GetTimestamp(),
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+
+	pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/transaction_service"
+)
+
+type TransferFundsResp struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	TransferId string `json:"transfer_id"`
+	Timestamp  string `json:"timestamp"`
+	// Amount moved, in the account currency.
+	Amount   string  `json:"amount"`
+	Currency string  `json:"currency"`
+	FX       *FXRate `json:"fx,omitempty"`
+}
+
+// Exchange rate applied to a response, for the UI to disclose.
+type FXRate struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Rate   string `json:"rate"`
+	AsOf   string `json:"as_of"`
+	Source string `json:"source"`
+}
+
+type CreateAccountResp struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	UserId    string `json:"user_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+type BalanceEntry struct {
+	Income           string `json:"income"`
+	Outcome          string `json:"outcome"`
+	IncomeDisplay    string `json:"income_display,omitempty"`
+	OutcomeDisplay   string `json:"outcome_display,omitempty"`
+	IncomeConverted  string `json:"income_converted,omitempty"`
+	OutcomeConverted string `json:"outcome_converted,omitempty"`
+}
+
+type GetBalanceResp struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+	Currency  string `json:"currency"`
+	Current   string `json:"current"`
+	// Locale-formatted current balance, present when the client asks for a locale.
+	CurrentDisplay string `json:"current_display,omitempty"`
+	// Current balance in the requested display currency, at the rate in FX.
+	CurrentConverted string         `json:"current_converted,omitempty"`
+	FX               *FXRate        `json:"fx,omitempty"`
+	Balances         []BalanceEntry `json:"balances"`
+}
+
+type Movement struct {
+	TransferId      string `json:"transfer_id"`
+	FromUsername    string `json:"from_username"`
+	ToUsername      string `json:"to_username"`
+	Amount          string `json:"amount"`
+	AmountDisplay   string `json:"amount_display,omitempty"`
+	AmountConverted string `json:"amount_converted,omitempty"`
+	Timestamp       string `json:"timestamp"`
+}
+
+type GetMovementsResp struct {
+	Success    bool       `json:"success"`
+	Message    string     `json:"message"`
+	Currency   string     `json:"currency"`
+	Movements  []Movement `json:"movements"`
+	FX         *FXRate    `json:"fx,omitempty"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+func FXRateJSON(rate *fx.Rate) *FXRate {
+	if rate == nil {
+		return nil
+	}
+	return &FXRate{
+		From:   rate.From,
+		To:     rate.To,
+		Rate:   rate.Value.String(),
+		AsOf:   rate.AsOf.UTC().Format(time.RFC3339),
+		Source: rate.Source,
 	}
 }
 
-func CreateAccountRespJSON(resp *pb.CreateAccountResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":   resp.GetSuccess(),
-		"message":   resp.GetMessage(),
-		"user_id":   resp.GetUserId(),
-		"timestamp": resp.GetTimestamp(),
+func TransferFundsRespJSON(resp *pb.TransferFundsResponse, amount money.Money, rate *fx.Rate) TransferFundsResp {
+	return TransferFundsResp{
+		Success:    resp.GetSuccess(),
+		Message:    resp.GetMessage(),
+		TransferId: resp.GetTransferId(),
+		Timestamp:  resp.GetTimestamp(),
+		Amount:     amount.String(),
+		Currency:   amount.Currency.Code,
+		FX:         FXRateJSON(rate),
 	}
 }
 
-func GetBalanceRespJSON(resp *pb.GetBalanceResponse) map[string]interface{} {
-	balances := make([]map[string]string, len(resp.GetBalances()))
-	for i, gbResult := range resp.GetBalances() {
-		balances[i] = map[string]string{
-			"income":  gbResult.GetIncome(),
-			"outcome": gbResult.GetOutcome(),
-		}
+func CreateAccountRespJSON(resp *pb.CreateAccountResponse) CreateAccountResp {
+	return CreateAccountResp{
+		Success:   resp.GetSuccess(),
+		Message:   resp.GetMessage(),
+		UserId:    resp.GetUserId(),
+		Timestamp: resp.GetTimestamp(),
 	}
+}
+
+func GetBalanceRespJSON(resp *pb.GetBalanceResponse, f money.Formatter) GetBalanceResp {
+	balances := make([]BalanceEntry, 0, len(resp.GetBalances()))
+	for _, gbResult := range resp.GetBalances() {
+		balances = append(balances, BalanceEntry{
+			Income:           f.Amount(gbResult.GetIncome()),
+			Outcome:          f.Amount(gbResult.GetOutcome()),
+			IncomeDisplay:    f.Display(gbResult.GetIncome()),
+			OutcomeDisplay:   f.Display(gbResult.GetOutcome()),
+			IncomeConverted:  f.Converted(gbResult.GetIncome()),
+			OutcomeConverted: f.Converted(gbResult.GetOutcome()),
+		})
+	}
+
+	return GetBalanceResp{
+		Success:          resp.GetSuccess(),
+		Message:          resp.GetMessage(),
+		Timestamp:        resp.GetTimestamp(),
+		Currency:         f.Currency.Code,
+		Current:          f.Amount(resp.GetCurrent()),
+		CurrentDisplay:   f.Display(resp.GetCurrent()),
+		CurrentConverted: f.Converted(resp.GetCurrent()),
+		Balances:         balances,
+	}
+}
 
-	return map[string]interface{}{
-		"success":   resp.GetSuccess(),
-		"message":   resp.GetMessage(),
-		"timestamp": resp.GetTimestamp(),
-		"current":   resp.GetCurrent(),
-		"balances":  balances,
+func MovementJSON(m *pb.Movement, f money.Formatter) Movement {
+	return Movement{
+		TransferId:      m.GetTransferId(),
+		FromUsername:    m.GetFromUsername(),
+		ToUsername:      m.GetToUsername(),
+		Amount:          f.Amount(m.GetAmount()),
+		AmountDisplay:   f.Display(m.GetAmount()),
+		AmountConverted: f.Converted(m.GetAmount()),
+		Timestamp:       m.GetTimestamp(),
 	}
 }
 
-func GetMovementsRespJSON(resp *pb.GetMovementsResponse) map[string]interface{} {
-	movements := make([]map[string]string, len(resp.GetMovements()))
-	for i, gtResult := range resp.GetMovements() {
-		movements[i] = map[string]string{
-			"transferId":   gtResult.GetTransferId(),
-			"fromUsername": gtResult.GetFromUsername(),
-			"toUsername":   gtResult.GetToUsername(),
-			"amount":       gtResult.GetAmount(),
-			"timestamp":    gtResult.GetTimestamp(),
-		}
+func GetMovementsRespJSON(resp *pb.GetMovementsResponse, f money.Formatter) GetMovementsResp {
+	movements := make([]Movement, 0, len(resp.GetMovements()))
+	for _, gtResult := range resp.GetMovements() {
+		movements = append(movements, MovementJSON(gtResult, f))
 	}
 
-	return map[string]interface{}{
-		"success":   resp.GetSuccess(),
-		"message":   resp.GetMessage(),
-		"movements": movements,
+	return GetMovementsResp{
+		Success:   resp.GetSuccess(),
+		Message:   resp.GetMessage(),
+		Currency:  f.Currency.Code,
+		Movements: movements,
 	}
 }
@@ -2,178 +2,298 @@ package transformers
 
 import pb "github.com/software-architecture-proj/nova-backend-common-protos/gen/go/user_product_service"
 
-func CreateUserRespJSON(resp *pb.CreateUserResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
-		"user_id": resp.GetUserId(),
-	}
+type CreateUserResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	UserId  string `json:"user_id"`
+}
+
+type GetUserResp struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Phone     string `json:"phone"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Birthdate string `json:"birthdate"`
+}
+
+type GetUsernameResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Email   string `json:"email"`
+	UserId  string `json:"user_id"`
+}
+
+type UpdateUserResp struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Phone     string `json:"phone"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Birthdate string `json:"birthdate"`
+}
+
+// Shared by every endpoint that only reports the outcome.
+type StatusResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type Favorite struct {
+	Id               string `json:"id"`
+	UserId           string `json:"user_id"`
+	FavoriteUserId   string `json:"favorite_user_id"`
+	FavoriteUsername string `json:"favorite_username"`
+	Alias            string `json:"alias"`
+}
+
+type GetFavoritesResp struct {
+	Success   bool       `json:"success"`
+	Message   string     `json:"message"`
+	Favorites []Favorite `json:"favorites"`
+}
+
+type CreateFavoriteResp struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	FavoriteId string `json:"favorite_id"`
+}
+
+type UpdateFavoriteResp struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	NewAlias string `json:"new_alias"`
+}
+
+type Pocket struct {
+	Id        string `json:"id"`
+	UserId    string `json:"user_id"`
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	MaxAmount int32  `json:"max_amount"`
+}
+
+type GetPocketsResp struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Pockets []Pocket `json:"pockets"`
+}
+
+type CreatePocketResp struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	PocketId string `json:"pocket_id"`
+}
+
+type UpdatePocketResp struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	MaxAmount int32  `json:"max_amount"`
+}
+
+type Verification struct {
+	Id     string `json:"id"`
+	UserId string `json:"user_id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+type GetVerificationsResp struct {
+	Success       bool           `json:"success"`
+	Message       string         `json:"message"`
+	Verifications []Verification `json:"verifications"`
+}
+
+type UpdateVerificationResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+}
+
+type CountryCode struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+type GetCountryCodesResp struct {
+	Success      bool          `json:"success"`
+	Message      string        `json:"message"`
+	CountryCodes []CountryCode `json:"country_codes"`
 }
 
-func GetUserRespJSON(resp *pb.GetUserByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":    resp.GetSuccess(),
-		"message":    resp.GetMessage(),
-		"email":      resp.GetEmail(),
-		"username":   resp.GetUsername(),
-		"phone":      resp.GetPhone(),
-		"first_name": resp.GetFirstName(),
-		"last_name":  resp.GetLastName(),
-		"birthdate":  resp.GetBirthdate(),
+func CreateUserRespJSON(resp *pb.CreateUserResponse) CreateUserResp {
+	return CreateUserResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
+		UserId:  resp.GetUserId(),
 	}
 }
 
-func GetUsernameRespJSON(resp *pb.GetUserByUsernameResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
-		"email":   resp.GetEmail(),
-		"user_id": resp.GetUserId(),
+func GetUserRespJSON(resp *pb.GetUserByIdResponse) GetUserResp {
+	return GetUserResp{
+		Success:   resp.GetSuccess(),
+		Message:   resp.GetMessage(),
+		Email:     resp.GetEmail(),
+		Username:  resp.GetUsername(),
+		Phone:     resp.GetPhone(),
+		FirstName: resp.GetFirstName(),
+		LastName:  resp.GetLastName(),
+		Birthdate: resp.GetBirthdate(),
 	}
 }
 
-func UpdateUserRespJSON(resp *pb.UpdateUserByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":    resp.GetSuccess(),
-		"message":    resp.GetMessage(),
-		"email":      resp.GetEmail(),
-		"username":   resp.GetUsername(),
-		"phone":      resp.GetPhone(),
-		"first_name": resp.GetFirstName(),
-		"last_name":  resp.GetLastName(),
-		"birthdate":  resp.GetBirthdate(),
+func GetUsernameRespJSON(resp *pb.GetUserByUsernameResponse) GetUsernameResp {
+	return GetUsernameResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
+		Email:   resp.GetEmail(),
+		UserId:  resp.GetUserId(),
 	}
 }
 
-func DeleteUserRespJSON(resp *pb.DeleteUserByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
+func UpdateUserRespJSON(resp *pb.UpdateUserByIdResponse) UpdateUserResp {
+	return UpdateUserResp{
+		Success:   resp.GetSuccess(),
+		Message:   resp.GetMessage(),
+		Email:     resp.GetEmail(),
+		Username:  resp.GetUsername(),
+		Phone:     resp.GetPhone(),
+		FirstName: resp.GetFirstName(),
+		LastName:  resp.GetLastName(),
+		Birthdate: resp.GetBirthdate(),
 	}
 }
 
-func GetFavoritesRespJSON(resp *pb.GetFavoritesByUserIdResponse) map[string]interface{} {
-	var favorites []map[string]interface{}
+func GetFavoritesRespJSON(resp *pb.GetFavoritesByUserIdResponse) GetFavoritesResp {
+	favorites := make([]Favorite, 0, len(resp.GetFavorites()))
 	for _, f := range resp.GetFavorites() {
-		favorites = append(favorites, map[string]interface{}{
-			"id":                f.GetId(),
-			"user_id":           f.GetUserId(),
-			"favorite_user_id":  f.GetFavoriteUserId(),
-			"favorite_username": f.GetFavoriteUsername(),
-			"alias":             f.GetAlias(),
+		favorites = append(favorites, Favorite{
+			Id:               f.GetId(),
+			UserId:           f.GetUserId(),
+			FavoriteUserId:   f.GetFavoriteUserId(),
+			FavoriteUsername: f.GetFavoriteUsername(),
+			Alias:            f.GetAlias(),
 		})
 	}
-	return map[string]interface{}{
-		"success":   resp.GetSuccess(),
-		"message":   resp.GetMessage(),
-		"favorites": favorites,
+	return GetFavoritesResp{
+		Success:   resp.GetSuccess(),
+		Message:   resp.GetMessage(),
+		Favorites: favorites,
 	}
 }
 
-func CreateFavoriteRespJSON(resp *pb.CreateFavoriteResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":     resp.GetSuccess(),
-		"message":     resp.GetMessage(),
-		"favorite_id": resp.GetFavoriteId(),
+func CreateFavoriteRespJSON(resp *pb.CreateFavoriteResponse) CreateFavoriteResp {
+	return CreateFavoriteResp{
+		Success:    resp.GetSuccess(),
+		Message:    resp.GetMessage(),
+		FavoriteId: resp.GetFavoriteId(),
 	}
 }
 
-func UpdateFavoriteRespJSON(resp *pb.UpdateFavoriteByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":   resp.GetSuccess(),
-		"message":   resp.GetMessage(),
-		"new_alias": resp.GetNewAlias(),
+func UpdateFavoriteRespJSON(resp *pb.UpdateFavoriteByIdResponse) UpdateFavoriteResp {
+	return UpdateFavoriteResp{
+		Success:  resp.GetSuccess(),
+		Message:  resp.GetMessage(),
+		NewAlias: resp.GetNewAlias(),
 	}
 }
 
-func DeleteFavoriteRespJSON(resp *pb.DeleteFavoriteByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
+func DeleteFavoriteRespJSON(resp *pb.DeleteFavoriteByIdResponse) StatusResp {
+	return StatusResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
 	}
 }
 
-func GetPocketsRespJSON(resp *pb.GetPocketsByUserIdResponse) map[string]interface{} {
-	var pockets []map[string]interface{}
+func GetPocketsRespJSON(resp *pb.GetPocketsByUserIdResponse) GetPocketsResp {
+	pockets := make([]Pocket, 0, len(resp.GetPockets()))
 	for _, p := range resp.GetPockets() {
-		pockets = append(pockets, map[string]interface{}{
-			"id":         p.GetId(),
-			"user_id":    p.GetUserId(),
-			"name":       p.GetName(),
-			"category":   p.GetCategory(),
-			"max_amount": p.GetMaxAmount(),
+		pockets = append(pockets, Pocket{
+			Id:        p.GetId(),
+			UserId:    p.GetUserId(),
+			Name:      p.GetName(),
+			Category:  p.GetCategory(),
+			MaxAmount: p.GetMaxAmount(),
 		})
 	}
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
-		"pockets": pockets,
+	return GetPocketsResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
+		Pockets: pockets,
 	}
 }
 
-func CreatePocketRespJSON(resp *pb.CreatePocketResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":   resp.GetSuccess(),
-		"message":   resp.GetMessage(),
-		"pocket_id": resp.GetPocketId(),
+func CreatePocketRespJSON(resp *pb.CreatePocketResponse) CreatePocketResp {
+	return CreatePocketResp{
+		Success:  resp.GetSuccess(),
+		Message:  resp.GetMessage(),
+		PocketId: resp.GetPocketId(),
 	}
 }
 
-func UpdatePocketRespJSON(resp *pb.UpdatePocketByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success":    resp.GetSuccess(),
-		"message":    resp.GetMessage(),
-		"name":       resp.GetName(),
-		"category":   resp.GetCategory(),
-		"max_amount": resp.GetMaxAmount(),
+func UpdatePocketRespJSON(resp *pb.UpdatePocketByIdResponse) UpdatePocketResp {
+	return UpdatePocketResp{
+		Success:   resp.GetSuccess(),
+		Message:   resp.GetMessage(),
+		Name:      resp.GetName(),
+		Category:  resp.GetCategory(),
+		MaxAmount: resp.GetMaxAmount(),
 	}
 }
 
-func DeletePocketRespJSON(resp *pb.DeletePocketByIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
+func DeletePocketRespJSON(resp *pb.DeletePocketByIdResponse) StatusResp {
+	return StatusResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
 	}
 }
 
-func GetVerificationsRespJSON(resp *pb.GetVerificationsByUserIdResponse) map[string]interface{} {
-	var verifications []map[string]interface{}
+func GetVerificationsRespJSON(resp *pb.GetVerificationsByUserIdResponse) GetVerificationsResp {
+	verifications := make([]Verification, 0, len(resp.GetVerifications()))
 	for _, v := range resp.GetVerifications() {
-		verifications = append(verifications, map[string]interface{}{
-			"id":      v.GetId(),
-			"user_id": v.GetUserId(),
-			"type":    v.GetType(),
-			"status":  v.GetStatus(),
+		verifications = append(verifications, Verification{
+			Id:     v.GetId(),
+			UserId: v.GetUserId(),
+			Type:   v.GetType(),
+			Status: v.GetStatus(),
 		})
 	}
-	return map[string]interface{}{
-		"success":       resp.GetSuccess(),
-		"message":       resp.GetMessage(),
-		"verifications": verifications,
+	return GetVerificationsResp{
+		Success:       resp.GetSuccess(),
+		Message:       resp.GetMessage(),
+		Verifications: verifications,
 	}
 }
 
-func UpdateVerificationRespJSON(resp *pb.UpdateVerificationByUserIdResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"success": resp.GetSuccess(),
-		"message": resp.GetMessage(),
-		"type":    resp.GetType(),
-		"status":  resp.GetStatus(),
+func UpdateVerificationRespJSON(resp *pb.UpdateVerificationByUserIdResponse) UpdateVerificationResp {
+	return UpdateVerificationResp{
+		Success: resp.GetSuccess(),
+		Message: resp.GetMessage(),
+		Type:    resp.GetType(),
+		Status:  resp.GetStatus(),
 	}
 }
 
-func GetCountryCodesRespJSON(resp *pb.GetCountryCodesResponse) map[string]interface{} {
-	var codes []map[string]interface{}
+func GetCountryCodesRespJSON(resp *pb.GetCountryCodesResponse) GetCountryCodesResp {
+	codes := make([]CountryCode, 0, len(resp.GetCodes()))
 	for _, c := range resp.GetCodes() {
-		codes = append(codes, map[string]interface{}{
-			"id":   c.GetId(),
-			"name": c.GetName(),
-			"code": c.GetCode(),
+		codes = append(codes, CountryCode{
+			Id:   c.GetId(),
+			Name: c.GetName(),
+			Code: c.GetCode(),
 		})
 	}
-	return map[string]interface{}{
-		"success":       resp.GetSuccess(),
-		"message":       resp.GetMessage(),
-		"country_codes": codes,
+	return GetCountryCodesResp{
+		Success:      resp.GetSuccess(),
+		Message:      resp.GetMessage(),
+		CountryCodes: codes,
 	}
 }
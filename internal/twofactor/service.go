@@ -0,0 +1,274 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrInvalidCode     = errors.New("invalid code")
+	ErrLoginNotFound   = errors.New("login not found or expired")
+	ErrTooManyAttempts = errors.New("too many attempts; log in again")
+)
+
+const (
+	recoveryCodes = 10
+	// Time to complete a login with the second factor.
+	loginTTL      = 5 * time.Minute
+	loginAttempts = 5
+)
+
+// A login waiting for its second factor.
+type pendingLogin struct {
+	userID      string
+	accessToken string
+	expiresAt   time.Time
+	attempts    int
+}
+
+// Enrolls TOTP authenticators, checks codes and holds logins halfway
+// through. Every method takes the current time, so callers (and tests)
+// control the clock.
+//
+// Pending logins live in memory: with several gateway instances, the one
+// that started a login must complete it.
+type Service struct {
+	Store Store
+	// Shown by authenticator apps next to the account.
+	Issuer string
+
+	mu     sync.Mutex
+	logins map[string]*pendingLogin
+}
+
+func NewService(store Store, issuer string) *Service {
+	return &Service{Store: store, Issuer: issuer, logins: make(map[string]*pendingLogin)}
+}
+
+// Whether logins of userID need a second factor.
+func (s *Service) Enabled(ctx context.Context, userID string) (bool, error) {
+	e, err := s.Store.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return e.Confirmed, nil
+}
+
+// Status of userID's enrollment; nil when there is none.
+func (s *Service) Status(ctx context.Context, userID string) (*Enrollment, error) {
+	e, err := s.Store.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return e, err
+}
+
+// Starts enrolling a new authenticator for userID, labelled account in the
+// app. Returns the enrollment and its provisioning URI; it takes effect once
+// confirmed with a code.
+func (s *Service) Enroll(ctx context.Context, userID, account string, now time.Time) (*Enrollment, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	e := &Enrollment{UserID: userID, Secret: secret, CreatedAt: now}
+	ok, err := s.Store.Begin(ctx, e)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", ErrAlreadyEnabled
+	}
+	return e, provisioningURI(s.Issuer, account, secret), nil
+}
+
+// Confirms the pending enrollment of userID with a code from the app and
+// returns the recovery codes, which are not stored in the clear and can't be
+// shown again.
+func (s *Service) Confirm(ctx context.Context, userID, code string, now time.Time) ([]string, error) {
+	e, err := s.Store.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.Confirmed {
+		return nil, ErrAlreadyEnabled
+	}
+	step, ok := matchTOTP(e.Secret, normalizeCode(code), now)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	ok, err = s.Store.Confirm(ctx, userID, step, hashes, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAlreadyEnabled
+	}
+	return codes, nil
+}
+
+// Checks a TOTP code or, failing that, a recovery code of userID, using it
+// up. Reports whether a recovery code was used.
+func (s *Service) Verify(ctx context.Context, userID, code string, now time.Time) (bool, error) {
+	e, err := s.Store.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, ErrNotEnabled
+	}
+	if err != nil {
+		return false, err
+	}
+	if !e.Confirmed {
+		return false, ErrNotEnabled
+	}
+	code = normalizeCode(code)
+	if step, ok := matchTOTP(e.Secret, code, now); ok {
+		fresh, err := s.Store.UseStep(ctx, userID, step)
+		if err != nil {
+			return false, err
+		}
+		if !fresh {
+			return false, ErrInvalidCode
+		}
+		return false, nil
+	}
+	used, err := s.Store.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+	if !used {
+		return false, ErrInvalidCode
+	}
+	return true, nil
+}
+
+// Replaces the recovery codes of userID after checking code.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID, code string, now time.Time) ([]string, error) {
+	if _, err := s.Verify(ctx, userID, code, now); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Removes the authenticator of userID after checking code. An unconfirmed
+// enrollment is removed without one.
+func (s *Service) Disable(ctx context.Context, userID, code string, now time.Time) error {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		if _, err := s.Verify(ctx, userID, code, now); err != nil {
+			return err
+		}
+	}
+	return s.Store.Delete(ctx, userID)
+}
+
+// Holds accessToken until the second factor of userID is checked. Returns
+// the id to complete the login with and when it expires.
+func (s *Service) StartLogin(userID, accessToken string, now time.Time) (string, time.Time) {
+	id := uuid.NewString()
+	expiresAt := now.Add(loginTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for old, l := range s.logins {
+		if !now.Before(l.expiresAt) {
+			delete(s.logins, old)
+		}
+	}
+	s.logins[id] = &pendingLogin{userID: userID, accessToken: accessToken, expiresAt: expiresAt}
+	return id, expiresAt
+}
+
+// Checks code for the login id and returns its access token. A login can be
+// completed once and tried at most loginAttempts times.
+func (s *Service) FinishLogin(ctx context.Context, id, code string, now time.Time) (string, error) {
+	s.mu.Lock()
+	l, ok := s.logins[id]
+	if !ok || !now.Before(l.expiresAt) {
+		s.mu.Unlock()
+		return "", ErrLoginNotFound
+	}
+	if l.attempts >= loginAttempts {
+		delete(s.logins, id)
+		s.mu.Unlock()
+		return "", ErrTooManyAttempts
+	}
+	l.attempts++
+	s.mu.Unlock()
+
+	_, err := s.Verify(ctx, l.userID, code, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.logins, id)
+		return l.accessToken, nil
+	}
+	if errors.Is(err, ErrInvalidCode) && l.attempts >= loginAttempts {
+		delete(s.logins, id)
+		return "", ErrTooManyAttempts
+	}
+	return "", err
+}
+
+// Codes as typed: spaces and dashes are ignored, case doesn't matter.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Recovery codes look like "k3j9x-7qz2m", from an alphabet without 0/o or 1/l.
+func newRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+	codes := make([]string, recoveryCodes)
+	hashes := make([]string, recoveryCodes)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, c := range buf {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(alphabet[int(c)%len(alphabet)])
+		}
+		codes[i] = b.String()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
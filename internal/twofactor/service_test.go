@@ -0,0 +1,196 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A fixed clock: every Service method takes the time, so tests move it by hand.
+var t0 = time.Date(2025, time.March, 14, 15, 9, 0, 0, time.UTC)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "two_factor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewService(store, "Nova")
+}
+
+func code(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	c, err := totpCode(secret, totpStep(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// A well-formed code that doesn't match secret around at.
+func wrongCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	for _, c := range []string{"000000", "111111", "222222"} {
+		if _, ok := matchTOTP(secret, c, at); !ok {
+			return c
+		}
+	}
+	t.Fatal("no wrong code found")
+	return ""
+}
+
+// Enrolls and confirms userID at t0, returning the secret and recovery codes.
+func enable(t *testing.T, s *Service, userID string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	e, _, err := s.Enroll(ctx, userID, userID+"@example.com", t0)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	codes, err := s.Confirm(ctx, userID, code(t, e.Secret, t0), t0)
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	return e.Secret, codes
+}
+
+func TestVerifyRejectsReusedStep(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	secret, _ := enable(t, s, "u1")
+
+	// The confirming code's step is used up.
+	if _, err := s.Verify(ctx, "u1", code(t, secret, t0), t0); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("Verify with the confirming code = %v, want ErrInvalidCode", err)
+	}
+
+	later := t0.Add(2 * time.Minute)
+	c := code(t, secret, later)
+	if recovery, err := s.Verify(ctx, "u1", c, later); err != nil || recovery {
+		t.Fatalf("Verify = %v, %v, want a TOTP match", recovery, err)
+	}
+	// Within the step and its skew, the same code is still valid but spent.
+	if _, err := s.Verify(ctx, "u1", c, later.Add(totpPeriod*time.Second)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("reusing a code = %v, want ErrInvalidCode", err)
+	}
+	// Codes are accepted one step either side of the clock.
+	next := later.Add(totpPeriod * time.Second)
+	if _, err := s.Verify(ctx, "u1", code(t, secret, next), later); err != nil {
+		t.Fatalf("Verify with the next step's code: %v", err)
+	}
+	// But not further away.
+	far := later.Add(5 * totpPeriod * time.Second)
+	if _, err := s.Verify(ctx, "u1", code(t, secret, far), later); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("Verify with a code five steps ahead = %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestVerifyConsumesRecoveryCodeOnce(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	_, codes := enable(t, s, "u1")
+	if len(codes) != recoveryCodes {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodes)
+	}
+
+	// Typed in upper case and without the dash.
+	typed := strings.ToUpper(codes[0][:5] + " " + codes[0][6:])
+	recovery, err := s.Verify(ctx, "u1", typed, t0.Add(time.Minute))
+	if err != nil || !recovery {
+		t.Fatalf("Verify with a recovery code = %v, %v, want a recovery match", recovery, err)
+	}
+	if _, err := s.Verify(ctx, "u1", codes[0], t0.Add(2*time.Minute)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("reusing a recovery code = %v, want ErrInvalidCode", err)
+	}
+	if recovery, err := s.Verify(ctx, "u1", codes[1], t0.Add(3*time.Minute)); err != nil || !recovery {
+		t.Fatalf("Verify with another recovery code = %v, %v", recovery, err)
+	}
+
+	st, err := s.Status(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.RecoveryCodesLeft != recoveryCodes-2 {
+		t.Errorf("RecoveryCodesLeft = %d, want %d", st.RecoveryCodesLeft, recoveryCodes-2)
+	}
+}
+
+func TestVerifyNotEnabled(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	if _, err := s.Verify(ctx, "nobody", "123456", t0); !errors.Is(err, ErrNotEnabled) {
+		t.Fatalf("Verify without enrollment = %v, want ErrNotEnabled", err)
+	}
+	e, _, err := s.Enroll(ctx, "u1", "u1@example.com", t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(ctx, "u1", code(t, e.Secret, t0), t0); !errors.Is(err, ErrNotEnabled) {
+		t.Fatalf("Verify before confirming = %v, want ErrNotEnabled", err)
+	}
+}
+
+func TestFinishLogin(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	secret, _ := enable(t, s, "u1")
+
+	start := t0.Add(time.Minute)
+	id, expiresAt := s.StartLogin("u1", "access-token", start)
+	if want := start.Add(loginTTL); !expiresAt.Equal(want) {
+		t.Fatalf("expiresAt = %s, want %s", expiresAt, want)
+	}
+	at := start.Add(time.Minute)
+	token, err := s.FinishLogin(ctx, id, code(t, secret, at), at)
+	if err != nil || token != "access-token" {
+		t.Fatalf("FinishLogin = %q, %v", token, err)
+	}
+	// A login completes once.
+	at = at.Add(time.Minute)
+	if _, err := s.FinishLogin(ctx, id, code(t, secret, at), at); !errors.Is(err, ErrLoginNotFound) {
+		t.Fatalf("finishing twice = %v, want ErrLoginNotFound", err)
+	}
+}
+
+func TestFinishLoginExpires(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	secret, _ := enable(t, s, "u1")
+
+	start := t0.Add(time.Minute)
+	id, expiresAt := s.StartLogin("u1", "access-token", start)
+	if _, err := s.FinishLogin(ctx, id, code(t, secret, expiresAt), expiresAt); !errors.Is(err, ErrLoginNotFound) {
+		t.Fatalf("FinishLogin at expiry = %v, want ErrLoginNotFound", err)
+	}
+	// Expired logins are dropped when the next one starts.
+	s.StartLogin("u2", "other-token", expiresAt)
+	if _, ok := s.logins[id]; ok {
+		t.Error("expired login still held after StartLogin")
+	}
+}
+
+func TestFinishLoginAttemptLimit(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	secret, _ := enable(t, s, "u1")
+
+	at := t0.Add(time.Minute)
+	id, _ := s.StartLogin("u1", "access-token", at)
+	wrong := wrongCode(t, secret, at)
+	for i := 1; i < loginAttempts; i++ {
+		if _, err := s.FinishLogin(ctx, id, wrong, at); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d = %v, want ErrInvalidCode", i, err)
+		}
+	}
+	if _, err := s.FinishLogin(ctx, id, wrong, at); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("last attempt = %v, want ErrTooManyAttempts", err)
+	}
+	// The login is gone, even with the right code.
+	if _, err := s.FinishLogin(ctx, id, code(t, secret, at), at); !errors.Is(err, ErrLoginNotFound) {
+		t.Fatalf("after the limit = %v, want ErrLoginNotFound", err)
+	}
+}
@@ -0,0 +1,168 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so the binary still builds with CGO_ENABLED=0.
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS totp_enrollments (
+	user_id      TEXT PRIMARY KEY,
+	secret       TEXT NOT NULL,
+	confirmed    INTEGER NOT NULL,
+	created_at   INTEGER NOT NULL,
+	confirmed_at INTEGER,
+	last_step    INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	user_id TEXT NOT NULL,
+	hash    TEXT NOT NULL,
+	PRIMARY KEY (user_id, hash)
+);
+`
+
+// Store backed by a SQLite file. Times are stored as Unix seconds; TOTP
+// secrets are stored as they are, so the file must be protected like a key.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating two-factor tables: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (st *SQLiteStore) Close() error {
+	return st.db.Close()
+}
+
+func (st *SQLiteStore) Get(ctx context.Context, userID string) (*Enrollment, error) {
+	var e Enrollment
+	var confirmedAt sql.NullInt64
+	var createdAt int64
+	err := st.db.QueryRowContext(ctx, `
+		SELECT user_id, secret, confirmed, created_at, confirmed_at, last_step,
+			(SELECT COUNT(*) FROM recovery_codes WHERE recovery_codes.user_id = totp_enrollments.user_id)
+		FROM totp_enrollments WHERE user_id = ?`, userID).
+		Scan(&e.UserID, &e.Secret, &e.Confirmed, &createdAt, &confirmedAt, &e.LastStep, &e.RecoveryCodesLeft)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.CreatedAt = time.Unix(createdAt, 0)
+	if confirmedAt.Valid {
+		t := time.Unix(confirmedAt.Int64, 0)
+		e.ConfirmedAt = &t
+	}
+	return &e, nil
+}
+
+func (st *SQLiteStore) Begin(ctx context.Context, e *Enrollment) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `
+		INSERT INTO totp_enrollments (user_id, secret, confirmed, created_at, confirmed_at, last_step)
+		VALUES (?, ?, 0, ?, NULL, 0)
+		ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, created_at = excluded.created_at
+		WHERE confirmed = 0`,
+		e.UserID, e.Secret, e.CreatedAt.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) Confirm(ctx context.Context, userID string, step int64, hashes []string, now time.Time) (bool, error) {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE totp_enrollments SET confirmed = 1, confirmed_at = ?, last_step = ? WHERE user_id = ? AND confirmed = 0`,
+		now.Unix(), step, userID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := replaceCodes(ctx, tx, userID, hashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (st *SQLiteStore) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `UPDATE totp_enrollments SET last_step = ? WHERE user_id = ? AND last_step < ?`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	res, err := st.db.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ? AND hash = ?`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (st *SQLiteStore) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := replaceCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceCodes(ctx context.Context, tx *sql.Tx, userID string, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, hash) VALUES (?, ?)`, userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *SQLiteStore) Delete(ctx context.Context, userID string) error {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM totp_enrollments WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var _ Store = (*SQLiteStore)(nil)
@@ -0,0 +1,45 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("two-factor enrollment not found")
+
+// A user's TOTP authenticator. It protects logins once confirmed.
+type Enrollment struct {
+	UserID string
+	// Base32 TOTP secret.
+	Secret      string
+	Confirmed   bool
+	CreatedAt   time.Time
+	ConfirmedAt *time.Time
+	// Last TOTP step accepted, so a code can't be used twice.
+	LastStep int64
+	// Recovery codes not used yet.
+	RecoveryCodesLeft int
+}
+
+// Persists two-factor enrollments and recovery codes.
+type Store interface {
+	// ErrNotFound when the user has no enrollment.
+	Get(ctx context.Context, userID string) (*Enrollment, error)
+	// Starts an unconfirmed enrollment, replacing any unconfirmed one. False
+	// when the user already has a confirmed enrollment.
+	Begin(ctx context.Context, e *Enrollment) (bool, error)
+	// Confirms the enrollment at step and replaces the recovery codes with
+	// hashes. False when there was nothing to confirm.
+	Confirm(ctx context.Context, userID string, step int64, hashes []string, now time.Time) (bool, error)
+	// Records step as used. False when it isn't newer than the last one used.
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	// Removes a recovery code. False when the user has no such code.
+	UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error)
+	// Replaces the recovery codes of a confirmed enrollment.
+	ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error
+	// Removes the enrollment and its recovery codes.
+	Delete(ctx context.Context, userID string) error
+
+	Close() error
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	_ "embed" // For the VERSION file.
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,11 +15,38 @@ import (
 
 	"github.com/software-architecture-proj/nova-backend-api-gateway/config"
 
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/audit"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/clients"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/deletion"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/exports"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/fx"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/handlers"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/limits"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/locks"
 	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/middleware"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/money"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/notify"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/openapi"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/pagination"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/payments"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/routes"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/scheduler"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/sessions"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/stepup"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/tokens"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transcoding"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/twofactor"
 )
 
+//go:embed VERSION
+var versionFile string
+
+// Version from the VERSION file ("name:semver").
+func apiVersion() string {
+	line := strings.TrimSpace(versionFile)
+	return line[strings.Index(line, ":")+1:]
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
@@ -26,7 +55,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Timezone, X-Currency, X-Step-Up-Token, X-Request-Id")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if r.Method == "OPTIONS" {
@@ -60,57 +89,230 @@ func main() {
 	}
 	defer TransactionClient.CloseConnection() // Ensure connection is closed when main exits.
 
+	currency, err := money.LookupCurrency(cfg.DefaultCurrency)
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CURRENCY: %v", err)
+	}
+
+	// Background work (rate refreshes, scheduled transfers, account deletions, data exports) stops when main returns.
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	rates, err := fx.NewFileProvider(cfg.FXRatesFile, config.DefaultFXRates)
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err) //  Critical
+	}
+	// The built-in rates never change, so a refresh interval only makes sense with a file.
+	refresh := time.Hour
+	if cfg.FXRefreshInterval != "" {
+		if cfg.FXRatesFile == "" {
+			log.Fatalf("FX_REFRESH_INTERVAL is set but FX_RATES_FILE is not: the built-in rates are never refreshed")
+		}
+		if refresh, err = time.ParseDuration(cfg.FXRefreshInterval); err != nil {
+			log.Fatalf("Invalid FX_REFRESH_INTERVAL: %v", err)
+		}
+	}
+	go rates.Run(background, refresh)
+	maxRateAge, err := time.ParseDuration(cfg.FXMaxRateAge)
+	if err != nil || maxRateAge <= 0 {
+		log.Fatalf("Invalid FX_MAX_RATE_AGE %q: must be a positive duration", cfg.FXMaxRateAge)
+	}
+
+	schedules, err := scheduler.OpenSQLite(cfg.SchedulerDB)
+	if err != nil {
+		log.Fatalf("Failed to open scheduler database: %v", err) //  Critical
+	}
+	defer schedules.Close()
+	schedulerInterval, err := time.ParseDuration(cfg.SchedulerInterval)
+	if err != nil {
+		log.Fatalf("Invalid SCHEDULER_INTERVAL: %v", err)
+	}
+
+	var transferLimits *limits.Engine
+	if cfg.TransferLimitsFile != "" {
+		transferLimits, err = limits.Load(cfg.TransferLimitsFile)
+	} else {
+		transferLimits, err = limits.Parse(config.DefaultTransferLimits)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load transfer limits: %v", err) //  Critical
+	}
+
+	notifier, err := notify.New(cfg.Notifier)
+	if err != nil {
+		log.Fatalf("Invalid NOTIFIER: %v", err)
+	}
+	var stepUpThreshold *money.Decimal
+	if cfg.StepUpTransferThreshold != "" {
+		threshold, err := money.Parse(cfg.StepUpTransferThreshold)
+		if err != nil {
+			log.Fatalf("Invalid STEP_UP_TRANSFER_THRESHOLD: %v", err)
+		}
+		stepUpThreshold = &threshold
+	}
+
+	paymentRequests, err := payments.OpenSQLite(cfg.PaymentRequestsDB)
+	if err != nil {
+		log.Fatalf("Failed to open payment requests database: %v", err) //  Critical
+	}
+	defer paymentRequests.Close()
+
+	twoFactorStore, err := twofactor.OpenSQLite(cfg.TwoFactorDB)
+	if err != nil {
+		log.Fatalf("Failed to open two-factor database: %v", err) //  Critical
+	}
+	defer twoFactorStore.Close()
+	twoFactor := twofactor.NewService(twoFactorStore, "Nova")
+	stepUp := stepup.NewService(cfg.StepUpSecret, notifier, twoFactor, stepUpThreshold)
+
+	usedTokens, err := tokens.OpenSQLite(cfg.AccountTokensDB)
+	if err != nil {
+		log.Fatalf("Failed to open account tokens database: %v", err) //  Critical
+	}
+	defer usedTokens.Close()
+	accountTokens := tokens.NewIssuer(cfg.AccountTokenSecret, usedTokens)
+
+	auditSink, err := audit.OpenFile(cfg.AuditLog)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err) //  Critical
+	}
+	defer auditSink.Close()
+
+	var policy *middleware.Policy
+	if cfg.PolicyFile != "" {
+		policy, err = middleware.LoadPolicy(cfg.PolicyFile)
+	} else {
+		policy, err = middleware.ParsePolicy(config.DefaultPolicy)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load access policy: %v", err) //  Critical
+	}
+	var admins []string
+	for _, id := range strings.Split(cfg.AdminUserIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			admins = append(admins, id)
+		}
+	}
+	if err := policy.Grant("admin", admins...); err != nil {
+		log.Fatalf("Invalid ADMIN_USER_IDS: %v", err)
+	}
+	policy.Audit = auditSink
+
+	accountLocks, err := locks.OpenSQLite(cfg.AccountLocksDB)
+	if err != nil {
+		log.Fatalf("Failed to open account locks database: %v", err) //  Critical
+	}
+	defer accountLocks.Close()
+	sessionStore, err := sessions.OpenSQLite(cfg.SessionsDB)
+	if err != nil {
+		log.Fatalf("Failed to open sessions database: %v", err) //  Critical
+	}
+	defer sessionStore.Close()
+
+	deletions, err := deletion.OpenSQLite(cfg.DeletionDB)
+	if err != nil {
+		log.Fatalf("Failed to open account deletion database: %v", err) //  Critical
+	}
+	defer deletions.Close()
+	gracePeriod, err := time.ParseDuration(cfg.DeletionGracePeriod)
+	if err != nil {
+		log.Fatalf("Invalid DELETION_GRACE_PERIOD: %v", err)
+	}
+	deletionInterval, err := time.ParseDuration(cfg.DeletionInterval)
+	if err != nil {
+		log.Fatalf("Invalid DELETION_INTERVAL: %v", err)
+	}
+
+	exportJobs, err := exports.OpenSQLite(cfg.ExportsDB)
+	if err != nil {
+		log.Fatalf("Failed to open exports database: %v", err) //  Critical
+	}
+	defer exportJobs.Close()
+
+	// Initialize HTTP handlers
+	userProductHandler := handlers.NewUserProductHandler(userProductClient, TransactionClient, AuthClient, stepUp, auditSink)
+	AuthHandler := handlers.NewAuthHandler(AuthClient, stepUp, twoFactor, accountLocks, auditSink, sessionStore)
+	TransactionHandler := handlers.NewTransactionHandler(TransactionClient, userProductClient, pagination.NewSigner(cfg.CursorSecret), currency, rates, maxRateAge, transferLimits, stepUp, auditSink)
+	ScheduledTransferHandler := handlers.NewScheduledTransferHandler(schedules, TransactionHandler, accountLocks, deletions)
+	PaymentRequestHandler := handlers.NewPaymentRequestHandler(paymentRequests, userProductClient, TransactionHandler)
+	AccountHandler := handlers.NewAccountHandler(userProductClient, handlers.UnsupportedPasswords{}, accountTokens, notifier, cfg.AppURL, auditSink)
+	AdminHandler := handlers.NewAdminHandler(userProductClient, TransactionHandler, AccountHandler, accountLocks, auditSink)
+	DeletionHandler := handlers.NewDeletionHandler(deletions, userProductClient, TransactionClient, handlers.UnsupportedDeletion{}, accountLocks, sessionStore, schedules, paymentRequests, stepUp, auditSink, gracePeriod)
+	DataExportHandler := handlers.NewDataExportHandler(exportJobs, userProductClient, TransactionHandler, sessionStore, auditSink, stepUp, auditSink)
+
+	// Execute scheduled transfers in the background.
+	go scheduler.New(schedules, ScheduledTransferHandler.Execute).Run(background, schedulerInterval)
+	// Carry out account deletions whose grace period is over.
+	go deletion.NewRunner(deletions, DeletionHandler.Steps(), auditSink).Run(background, deletionInterval)
+	// Build requested data exports in the background; users poll for them, so a
+	// few seconds of delay doesn't matter.
+	exportRunner, err := exports.NewRunner(exportJobs, cfg.ExportsDir, DataExportHandler.Build)
+	if err != nil {
+		log.Fatalf("Failed to set up data exports: %v", err) //  Critical
+	}
+	go exportRunner.Run(background, 5*time.Second)
+
+	// Handlers the route table can bind to, by name.
+	registry := newHandlerRegistry(userProductHandler, AuthHandler, TransactionHandler, ScheduledTransferHandler, PaymentRequestHandler, AccountHandler, AdminHandler, DeletionHandler, DataExportHandler)
+	apiDocs := &openapi.Docs{}
+	registry["GetOpenAPISpec"] = routes.Handler{Func: apiDocs.ServeSpec, Summary: "OpenAPI document for this gateway"}
+	registry["GetAPIDocs"] = routes.Handler{Func: apiDocs.ServeUI, Summary: "Swagger UI"}
+
+	// Load the route table (built-in unless ROUTES_FILE points elsewhere).
+	var routeTable *routes.Table
+	if cfg.RoutesFile != "" {
+		routeTable, err = routes.Load(cfg.RoutesFile)
+	} else {
+		routeTable, err = routes.Parse(config.DefaultRoutes)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load route table: %v", err) //  Critical
+	}
+	for _, route := range routeTable.Routes {
+		for _, scope := range route.Scopes {
+			if !policy.Defines(scope) {
+				log.Fatalf("Route %s %s requires scope %q, which no role in the access policy grants", route.Method, route.Path, scope) //  Critical
+			}
+		}
+	}
+
+	transcoder := transcoding.NewTranscoder(userProductClient, AuthClient, TransactionClient)
+
+	// Generate the OpenAPI document from the route table.
+	apiDocs.Spec, err = openapi.Build(routeTable, registry, openapi.Info{
+		Title:   "Nova API Gateway",
+		Version: apiVersion(),
+	}, transcoder.Describe)
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI document: %v", err) //  Critical
+	}
+
 	// Set up HTTP router
 	router := mux.NewRouter()
-	apiRouter := router.PathPrefix("/api").Subrouter()
+	authToken, rejectLocked := middleware.NewMiddleware(auditSink, sessionStore).AuthToken, middleware.RejectLocked(accountLocks)
+	err = routeTable.Mount(router, registry, routes.Options{
+		Auth:          func(next http.Handler) http.Handler { return authToken(rejectLocked(next)) },
+		Transcode:     transcoder.Handler,
+		RequireScopes: policy.RequireScopes,
+	})
+	if err != nil {
+		log.Fatalf("Failed to mount routes: %v", err) //  Critical
+	}
 
-	// Initialize HTTP handlers
-	userProductHandler := handlers.NewUserProductHandler(userProductClient, TransactionClient, AuthClient)
-	AuthHandler := handlers.NewAuthHandler(AuthClient)
-	TransactionHandler := handlers.NewTransactionHandler(TransactionClient, userProductClient)
-
-	// Public routes (no authentication required)
-	apiRouter.HandleFunc("/country-codes", userProductHandler.GetCountryCodes).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/users", userProductHandler.CreateUser).Methods(http.MethodPost)
-	apiRouter.HandleFunc("/users/{user_id}", userProductHandler.GetUser).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/users/name/{username}", userProductHandler.GetUsername).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/login", AuthHandler.PostLogin).Methods(http.MethodPost)
-	apiRouter.HandleFunc("/logout", AuthHandler.PostLogout).Methods(http.MethodPost)
-	apiRouter.HandleFunc("/balance", TransactionHandler.GetBalance).Methods(http.MethodGet)
-	apiRouter.HandleFunc("/movements", TransactionHandler.GetMovements).Methods(http.MethodGet)
-
-	// Protected routes (authentication required)
-	protectedRouter := apiRouter.PathPrefix("").Subrouter()
-	protectedRouter.Use(middleware.NewMiddleware().AuthToken)
-
-	// User and Products routes
-	protectedRouter.HandleFunc("/users/{user_id}", userProductHandler.UpdateUser).Methods(http.MethodPut)
-	protectedRouter.HandleFunc("/users/{user_id}", userProductHandler.DeleteUser).Methods(http.MethodDelete)
-
-	// Favorites routes
-	protectedRouter.HandleFunc("/users/{user_id}/favorites", userProductHandler.GetFavoritesByUserId).Methods(http.MethodGet)
-	protectedRouter.HandleFunc("/users/{user_id}/favorites", userProductHandler.CreateFavorite).Methods(http.MethodPost)
-	protectedRouter.HandleFunc("/users/{user_id}/favorites/{favorite_id}", userProductHandler.UpdateFavorite).Methods(http.MethodPut)
-	protectedRouter.HandleFunc("/users/{user_id}/favorites/{favorite_id}", userProductHandler.DeleteFavorite).Methods(http.MethodDelete)
-
-	// Pockets routes
-	protectedRouter.HandleFunc("/users/{user_id}/pockets", userProductHandler.GetPocketsByUserId).Methods(http.MethodGet)
-	protectedRouter.HandleFunc("/users/{user_id}/pockets", userProductHandler.CreatePocket).Methods(http.MethodPost)
-	protectedRouter.HandleFunc("/users/{user_id}/pockets/{pocket_id}", userProductHandler.UpdatePocket).Methods(http.MethodPut)
-	protectedRouter.HandleFunc("/users/{user_id}/pockets/{pocket_id}", userProductHandler.DeletePocket).Methods(http.MethodDelete)
-
-	// Verification routes
-	protectedRouter.HandleFunc("/users/{user_id}/verifications", userProductHandler.GetVerificationsByUserId).Methods(http.MethodGet)
-	protectedRouter.HandleFunc("/users/{user_id}/verifications", userProductHandler.UpdateVerificationByUserId).Methods(http.MethodPut)
-
-	// Transaction routes
-	protectedRouter.HandleFunc("/accounts", TransactionHandler.PostAccount).Methods(http.MethodPost) //deprecated
-	protectedRouter.HandleFunc("/transfers", TransactionHandler.PostTransfer).Methods(http.MethodPost)
+	// Check requests and responses against the OpenAPI document (development/test only).
+	if cfg.SchemaValidation != openapi.ValidationOff {
+		validator, err := openapi.NewValidator(apiDocs.Spec, cfg.SchemaValidation)
+		if err != nil {
+			log.Fatalf("Failed to enable schema validation: %v", err) //  Critical
+		}
+		router.Use(validator.Middleware)
+		log.Printf("OpenAPI schema validation enabled (%s mode)", cfg.SchemaValidation)
+	}
 
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         ":" + cfg.APIGatewayPort,
-		Handler:      corsMiddleware(router),
+		Handler:      corsMiddleware(middleware.RequestOrigin(router)),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
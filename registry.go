@@ -0,0 +1,431 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/handlers"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/routes"
+	"github.com/software-architecture-proj/nova-backend-api-gateway/internal/transformers"
+)
+
+// Shared by every endpoint taking a time range.
+const (
+	rangeParamDescription    = "Named range instead of from/to: today, yesterday, this_week, this_month, last_month, this_year or last_<n>d"
+	tzParamDescription       = "IANA time zone for dates and named ranges (also read from the X-Timezone header; default UTC)"
+	currencyParamDescription = "ISO 4217 currency to also show amounts in (also read from the X-Currency header)"
+)
+
+// Handlers the route table can bind to, by name.
+func newHandlerRegistry(userProductHandler *handlers.UserProductHandler, AuthHandler *handlers.AuthHandler, TransactionHandler *handlers.TransactionHandler, ScheduledTransferHandler *handlers.ScheduledTransferHandler, PaymentRequestHandler *handlers.PaymentRequestHandler, AccountHandler *handlers.AccountHandler, AdminHandler *handlers.AdminHandler, DeletionHandler *handlers.DeletionHandler, DataExportHandler *handlers.DataExportHandler) routes.Handlers {
+	registry := routes.Handlers{
+		// Users and Products
+		"GetCountryCodes": {
+			Func:     userProductHandler.GetCountryCodes,
+			Summary:  "List country calling codes",
+			Response: transformers.GetCountryCodesResp{},
+		},
+		"CreateUser": {
+			Func:     userProductHandler.CreateUser,
+			Summary:  "Create a user and their transaction account",
+			Request:  handlers.CreateUserReq{},
+			Response: transformers.CreateUserResp{},
+			Status:   http.StatusCreated,
+		},
+		"GetUser": {
+			Func:     userProductHandler.GetUser,
+			Summary:  "Get a user by id",
+			Response: transformers.GetUserResp{},
+		},
+		"GetUsername": {
+			Func:     userProductHandler.GetUsername,
+			Summary:  "Get a user by username",
+			Response: transformers.GetUsernameResp{},
+		},
+		"UpdateUser": {
+			Func:     userProductHandler.UpdateUser,
+			Summary:  "Update a user",
+			Request:  handlers.UpdateUserReq{},
+			Response: transformers.UpdateUserResp{},
+		},
+		"GetFavoritesByUserId": {
+			Func:     userProductHandler.GetFavoritesByUserId,
+			Summary:  "List a user's favorites",
+			Response: transformers.GetFavoritesResp{},
+		},
+		"CreateFavorite": {
+			Func:     userProductHandler.CreateFavorite,
+			Summary:  "Add a favorite",
+			Request:  handlers.CreateFavoriteReq{},
+			Response: transformers.CreateFavoriteResp{},
+			Status:   http.StatusCreated,
+		},
+		"UpdateFavorite": {
+			Func:     userProductHandler.UpdateFavorite,
+			Summary:  "Rename a favorite",
+			Request:  handlers.UpdateFavoriteReq{},
+			Response: transformers.UpdateFavoriteResp{},
+		},
+		"DeleteFavorite": {
+			Func:     userProductHandler.DeleteFavorite,
+			Summary:  "Delete a favorite",
+			Response: transformers.StatusResp{},
+		},
+		"GetPocketsByUserId": {
+			Func:     userProductHandler.GetPocketsByUserId,
+			Summary:  "List a user's pockets",
+			Response: transformers.GetPocketsResp{},
+		},
+		"CreatePocket": {
+			Func:     userProductHandler.CreatePocket,
+			Summary:  "Create a pocket",
+			Request:  handlers.CreatePocketReq{},
+			Response: transformers.CreatePocketResp{},
+			Status:   http.StatusCreated,
+		},
+		"UpdatePocket": {
+			Func:     userProductHandler.UpdatePocket,
+			Summary:  "Update a pocket",
+			Request:  handlers.UpdatePocketReq{},
+			Response: transformers.UpdatePocketResp{},
+		},
+		"DeletePocket": {
+			Func:     userProductHandler.DeletePocket,
+			Summary:  "Delete a pocket",
+			Response: transformers.StatusResp{},
+		},
+		"GetVerificationsByUserId": {
+			Func:     userProductHandler.GetVerificationsByUserId,
+			Summary:  "List a user's verifications",
+			Response: transformers.GetVerificationsResp{},
+		},
+		"UpdateVerificationByUserId": {
+			Func:     userProductHandler.UpdateVerificationByUserId,
+			Summary:  "Update a verification status",
+			Request:  handlers.UpdateVerificationReq{},
+			Response: transformers.UpdateVerificationResp{},
+		},
+
+		// Auth
+		"PostLogin": {
+			Func:     AuthHandler.PostLogin,
+			Summary:  "Log in and receive an access token (also set as the accessToken cookie)",
+			Request:  handlers.LoginReq{},
+			Response: transformers.LoginResp{},
+		},
+		"PostLogout": {
+			Func:     AuthHandler.PostLogout,
+			Summary:  "Clear the access token cookie",
+			Response: transformers.LogOutResp{},
+		},
+		"PostLoginTwoFactor": {
+			Func:     AuthHandler.PostLoginTwoFactor,
+			Summary:  "Complete a login with a TOTP or recovery code",
+			Request:  handlers.LoginTwoFactorReq{},
+			Response: transformers.LoginResp{},
+		},
+		"GetTwoFactor": {
+			Func:     AuthHandler.GetTwoFactor,
+			Summary:  "Two-factor authentication status of the authenticated user",
+			Response: transformers.TwoFactorStatusResp{},
+		},
+		"EnrollTOTP": {
+			Func:     AuthHandler.EnrollTOTP,
+			Summary:  "Start enrolling a TOTP authenticator",
+			Response: transformers.TOTPEnrollResp{},
+			Status:   http.StatusCreated,
+		},
+		"ConfirmTOTP": {
+			Func:     AuthHandler.ConfirmTOTP,
+			Summary:  "Confirm the TOTP authenticator and get recovery codes",
+			Request:  handlers.TwoFactorCodeReq{},
+			Response: transformers.RecoveryCodesResp{},
+		},
+		"DisableTOTP": {
+			Func:     AuthHandler.DisableTOTP,
+			Summary:  "Turn off two-factor authentication",
+			Request:  handlers.TwoFactorCodeReq{},
+			Response: transformers.TwoFactorStatusResp{},
+		},
+		"RegenerateRecoveryCodes": {
+			Func:     AuthHandler.RegenerateRecoveryCodes,
+			Summary:  "Replace the recovery codes",
+			Request:  handlers.TwoFactorCodeReq{},
+			Response: transformers.RecoveryCodesResp{},
+		},
+		"ForgotPassword": {
+			Func:     AccountHandler.ForgotPassword,
+			Summary:  "Email a password reset link",
+			Request:  handlers.ForgotPasswordReq{},
+			Response: transformers.AccountMessageResp{},
+			Status:   http.StatusAccepted,
+		},
+		"ResetPassword": {
+			Func:     AccountHandler.ResetPassword,
+			Summary:  "Set a new password with an emailed reset link",
+			Request:  handlers.ResetPasswordReq{},
+			Response: transformers.AccountMessageResp{},
+		},
+		"SendEmailVerification": {
+			Func:     AccountHandler.SendEmailVerification,
+			Summary:  "Email a link to verify the authenticated user's email",
+			Response: transformers.AccountMessageResp{},
+			Status:   http.StatusAccepted,
+		},
+		"ConfirmEmail": {
+			Func:     AccountHandler.ConfirmEmail,
+			Summary:  "Mark the email as verified with an emailed link",
+			Request:  handlers.ConfirmEmailReq{},
+			Response: transformers.UpdateVerificationResp{},
+		},
+		"GetSessions": {
+			Func:     AuthHandler.GetSessions,
+			Summary:  "List the authenticated user's active sessions",
+			Response: transformers.SessionsResp{},
+		},
+		"RevokeSession": {
+			Func:     AuthHandler.RevokeSession,
+			Summary:  "End one of the authenticated user's sessions",
+			Response: transformers.RevokeSessionsResp{},
+		},
+		"RevokeAllSessions": {
+			Func:     AuthHandler.RevokeAllSessions,
+			Summary:  "Log the authenticated user out everywhere",
+			Response: transformers.RevokeSessionsResp{},
+		},
+		"ScheduleDeletion": {
+			Func:     DeletionHandler.ScheduleDeletion,
+			Summary:  "Schedule the deletion of the caller's account after a grace period",
+			Response: transformers.DeletionResp{},
+		},
+		"GetDeletion": {
+			Func:     DeletionHandler.GetDeletion,
+			Summary:  "Status of the authenticated user's account deletion",
+			Response: transformers.DeletionResp{},
+		},
+		"CancelDeletion": {
+			Func:     DeletionHandler.CancelDeletion,
+			Summary:  "Cancel a scheduled account deletion during its grace period",
+			Response: transformers.DeletionResp{},
+		},
+		"RequestExport": {
+			Func:     DataExportHandler.RequestExport,
+			Summary:  "Start an export of all the authenticated user's data, or get the one in progress",
+			Response: transformers.ExportJobResp{},
+		},
+		"GetExport": {
+			Func:     DataExportHandler.GetExport,
+			Summary:  "Status of a data export",
+			Response: transformers.ExportJobResp{},
+		},
+		"DownloadExport": {
+			Func:    DataExportHandler.DownloadExport,
+			Summary: "Download a data export as a ZIP archive",
+		},
+		"PostStepUp": {
+			Func:     AuthHandler.PostStepUp,
+			Summary:  "Answer a step-up challenge with the code sent to the user",
+			Request:  handlers.StepUpReq{},
+			Response: transformers.StepUpResp{},
+		},
+
+		// Transactions
+		"GetBalance": {
+			Func:    TransactionHandler.GetBalance,
+			Summary: "Get the current balance and income/outcome per period",
+			Query: []routes.Param{
+				{Name: "user_id", Required: true},
+				{Name: "from_time", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: 30 days before to_time)"},
+				{Name: "to_time", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: now)"},
+				{Name: "range", Description: rangeParamDescription},
+				{Name: "tz", Description: tzParamDescription},
+				{Name: "currency", Description: currencyParamDescription},
+			},
+			Response: transformers.GetBalanceResp{},
+		},
+		"GetMovements": {
+			Func:    TransactionHandler.GetMovements,
+			Summary: "List a user's movements",
+			Query: []routes.Param{
+				{Name: "id", Description: "User id", Required: true},
+				{Name: "from", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: 30 days before to)"},
+				{Name: "to", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: now)"},
+				{Name: "range", Description: rangeParamDescription},
+				{Name: "tz", Description: tzParamDescription},
+				{Name: "lim", Description: "Limit the number of movements returned (true/false)"},
+				{Name: "page_size", Description: "Movements per page, 1-200 (default 50)"},
+				{Name: "cursor", Description: "Opaque cursor from a previous page's next_cursor"},
+				{Name: "counterparty", Description: "Only movements with this username on the other side"},
+				{Name: "direction", Description: "in or out"},
+				{Name: "min_amount", Description: "Minimum amount, inclusive"},
+				{Name: "max_amount", Description: "Maximum amount, inclusive"},
+				{Name: "currency", Description: currencyParamDescription},
+			},
+			Response: transformers.GetMovementsResp{},
+		},
+		"ExportMovements": {
+			Func:    TransactionHandler.ExportMovements,
+			Summary: "Download the authenticated user's statement as CSV, OFX or PDF",
+			Query: []routes.Param{
+				{Name: "format", Description: "csv (default), ofx or pdf"},
+				{Name: "from", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: 30 days before to)"},
+				{Name: "to", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: now)"},
+				{Name: "range", Description: rangeParamDescription},
+				{Name: "tz", Description: tzParamDescription},
+			},
+		},
+		"GetInsights": {
+			Func:    TransactionHandler.GetInsights,
+			Summary: "Monthly income/outcome, top counterparties and pocket categories of the authenticated user",
+			Query: []routes.Param{
+				{Name: "from", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: start of the month 5 months before to)"},
+				{Name: "to", Description: "Unix seconds, RFC 3339 or YYYY-MM-DD (default: now)"},
+				{Name: "range", Description: rangeParamDescription},
+				{Name: "tz", Description: tzParamDescription},
+			},
+			Response: transformers.InsightsResp{},
+		},
+		"PostAccount": {
+			Func:     TransactionHandler.PostAccount,
+			Summary:  "Create a transaction account (deprecated)",
+			Request:  handlers.CreateAccountReq{},
+			Response: transformers.CreateAccountResp{},
+			Status:   http.StatusCreated,
+		},
+		"PostTransfer": {
+			Func:     TransactionHandler.PostTransfer,
+			Summary:  "Transfer funds between users",
+			Request:  handlers.TransferReq{},
+			Response: transformers.TransferFundsResp{},
+			Status:   http.StatusCreated,
+		},
+
+		// Scheduled transfers
+		"CreateScheduledTransfer": {
+			Func:    ScheduledTransferHandler.CreateScheduledTransfer,
+			Summary: "Schedule a one-off or recurring transfer from the authenticated user",
+			Query: []routes.Param{
+				{Name: "tz", Description: "IANA time zone for start_at, end_at and the recurrence (also read from the X-Timezone header; default UTC)"},
+			},
+			Request:  handlers.CreateScheduledTransferReq{},
+			Response: transformers.ScheduledTransferResp{},
+			Status:   http.StatusCreated,
+		},
+		"GetScheduledTransfers": {
+			Func:     ScheduledTransferHandler.GetScheduledTransfers,
+			Summary:  "List the authenticated user's scheduled transfers",
+			Response: transformers.GetScheduledTransfersResp{},
+		},
+		"CancelScheduledTransfer": {
+			Func:     ScheduledTransferHandler.CancelScheduledTransfer,
+			Summary:  "Cancel a scheduled transfer",
+			Response: transformers.StatusResp{},
+		},
+		"GetScheduledTransferRuns": {
+			Func:     ScheduledTransferHandler.GetScheduledTransferRuns,
+			Summary:  "History of a scheduled transfer's runs",
+			Response: transformers.GetScheduledRunsResp{},
+		},
+
+		// Payment requests
+		"CreatePaymentRequest": {
+			Func:    PaymentRequestHandler.CreatePaymentRequest,
+			Summary: "Ask a user, by username or favorite, to send money to the authenticated user",
+			Query: []routes.Param{
+				{Name: "tz", Description: "IANA time zone for expires_at (also read from the X-Timezone header; default UTC)"},
+			},
+			Request:  handlers.CreatePaymentRequestReq{},
+			Response: transformers.PaymentRequestResp{},
+			Status:   http.StatusCreated,
+		},
+		"GetPaymentRequests": {
+			Func:    PaymentRequestHandler.GetPaymentRequests,
+			Summary: "List payment requests addressed to or made by the authenticated user",
+			Query: []routes.Param{
+				{Name: "direction", Description: "incoming (default) or outgoing"},
+				{Name: "status", Description: "pending, accepting, paid, declined, canceled, expired or unknown"},
+			},
+			Response: transformers.GetPaymentRequestsResp{},
+		},
+		"AcceptPaymentRequest": {
+			Func:     PaymentRequestHandler.AcceptPaymentRequest,
+			Summary:  "Pay an incoming payment request",
+			Response: transformers.PaymentRequestResp{},
+		},
+		"DeclinePaymentRequest": {
+			Func:     PaymentRequestHandler.DeclinePaymentRequest,
+			Summary:  "Decline an incoming payment request",
+			Response: transformers.PaymentRequestResp{},
+		},
+		"CancelPaymentRequest": {
+			Func:     PaymentRequestHandler.CancelPaymentRequest,
+			Summary:  "Withdraw a payment request made by the authenticated user",
+			Response: transformers.PaymentRequestResp{},
+		},
+		"CreateSplit": {
+			Func:    PaymentRequestHandler.CreateSplit,
+			Summary: "Split a bill with favorites, sending each a payment request for their share",
+			Query: []routes.Param{
+				{Name: "tz", Description: "IANA time zone for expires_at (also read from the X-Timezone header; default UTC)"},
+			},
+			Request:  handlers.CreateSplitReq{},
+			Response: transformers.SplitResp{},
+			Status:   http.StatusCreated,
+		},
+		"GetSplits": {
+			Func:     PaymentRequestHandler.GetSplits,
+			Summary:  "List the authenticated user's splits and their settlement",
+			Response: transformers.GetSplitsResp{},
+		},
+		"GetSplit": {
+			Func:     PaymentRequestHandler.GetSplit,
+			Summary:  "Get a split and the status of each share",
+			Response: transformers.SplitResp{},
+		},
+
+		// Admin
+		"AdminFindUser": {
+			Func:    AdminHandler.FindUser,
+			Summary: "Find a user by username, email or phone",
+			Query: []routes.Param{
+				{Name: "username"},
+				{Name: "email"},
+				{Name: "phone"},
+			},
+			Response: transformers.AdminUserResp{},
+		},
+		"AdminGetUser": {
+			Func:    AdminHandler.GetUser,
+			Summary: "Get a user with their verifications, pockets and latest movements",
+			Query: []routes.Param{
+				{Name: "currency", Description: currencyParamDescription},
+			},
+			Response: transformers.AdminUserDetailResp{},
+		},
+		"AdminLockAccount": {
+			Func:     AdminHandler.LockAccount,
+			Summary:  "Lock an account: its tokens are refused and it can't log in",
+			Request:  handlers.LockAccountReq{},
+			Response: transformers.AccountLockResp{},
+		},
+		"AdminUnlockAccount": {
+			Func:     AdminHandler.UnlockAccount,
+			Summary:  "Unlock an account",
+			Response: transformers.AccountLockResp{},
+		},
+		"AdminSendPasswordReset": {
+			Func:     AdminHandler.SendPasswordReset,
+			Summary:  "Email a user a password reset link",
+			Response: transformers.AccountMessageResp{},
+			Status:   http.StatusAccepted,
+		},
+	}
+
+	// Every reset would fail until the auth service can look users up by
+	// email and set passwords, so a route table naming these fails to load.
+	if !AccountHandler.Passwords.Supported() {
+		delete(registry, "ForgotPassword")
+		delete(registry, "ResetPassword")
+		delete(registry, "AdminSendPasswordReset")
+	}
+	return registry
+}